@@ -0,0 +1,231 @@
+// Package bench scores generated commit messages against the messages
+// people wrote for the same diffs, so models and prompt versions can be
+// compared on a fixed corpus.
+package bench
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Sample is one diff of the dataset and the message a person wrote for it.
+type Sample struct {
+	Name    string
+	Diff    string
+	Message string
+}
+
+// Load reads a dataset directory: every NAME.diff with a NAME.msg next to
+// it is a sample, in name order. Diffs without a message are skipped.
+func Load(dir string) ([]Sample, error) {
+	diffs, err := filepath.Glob(filepath.Join(dir, "*.diff"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(diffs)
+	var samples []Sample
+	for _, path := range diffs {
+		name := strings.TrimSuffix(filepath.Base(path), ".diff")
+		msg, err := os.ReadFile(filepath.Join(dir, name+".msg"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		d, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(string(d)) == "" || strings.TrimSpace(string(msg)) == "" {
+			continue
+		}
+		samples = append(samples, Sample{Name: name, Diff: string(d), Message: strings.TrimSpace(string(msg))})
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no samples in %s: expected NAME.diff files with a NAME.msg next to each", dir)
+	}
+	return samples, nil
+}
+
+var (
+	conventionalSubject = regexp.MustCompile(`^([A-Za-z]+)(?:\([^)]*\))?!?:\s*(.+)$`)
+	bracketedSubject    = regexp.MustCompile(`^(?:\S+\s+)?\[([A-Za-z]+)(?:\([^)]*\))?\]\s*(.+)$`)
+)
+
+// Subject splits the headline of msg into its commit type and description:
+// "fix(api): handle nil" and "TES-1 [fix] handle nil" both give "fix" and
+// "handle nil". Headlines without a type give "" and the whole headline.
+func Subject(msg string) (commitType, description string) {
+	headline, _, _ := strings.Cut(strings.TrimSpace(msg), "\n")
+	headline = strings.TrimSpace(headline)
+	for _, pattern := range []*regexp.Regexp{conventionalSubject, bracketedSubject} {
+		if m := pattern.FindStringSubmatch(headline); m != nil {
+			return strings.ToLower(m[1]), strings.TrimSpace(m[2])
+		}
+	}
+	return "", headline
+}
+
+// words lowercases s and splits it into words and numbers.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// RougeL is the ROUGE-L F1 score of candidate against reference: how much
+// of their word sequences they share, in order, from 0 to 1.
+func RougeL(candidate, reference string) float64 {
+	c, r := words(candidate), words(reference)
+	if len(c) == 0 || len(r) == 0 {
+		return 0
+	}
+	// longest common subsequence, one row at a time
+	prev, cur := make([]int, len(r)+1), make([]int, len(r)+1)
+	for i := range c {
+		for j := range r {
+			switch {
+			case c[i] == r[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] >= cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	lcs := float64(prev[len(r)])
+	if lcs == 0 {
+		return 0
+	}
+	precision, recall := lcs/float64(len(c)), lcs/float64(len(r))
+	return 2 * precision * recall / (precision + recall)
+}
+
+// Score is how one generated message compares to the human one.
+type Score struct {
+	// Rouge is RougeL of the descriptions.
+	Rouge float64
+	// Similarity is the cosine similarity of the embedded messages; only
+	// meaningful with Embedded set.
+	Similarity float64
+	Embedded   bool
+	// Typed is set when the human headline has a type; TypeMatch when
+	// the generated type is the same.
+	Typed     bool
+	TypeMatch bool
+	Elapsed   time.Duration
+	// Err is why no message was generated; the other fields are zero.
+	Err error
+}
+
+// Row is the result of one model over the dataset, a line of the
+// comparison table.
+type Row struct {
+	Model string `json:"model"`
+	// Prompt is the prompt.Version the messages were generated with.
+	Prompt  string    `json:"prompt"`
+	Dataset string    `json:"dataset"`
+	Date    time.Time `json:"date"`
+	Samples int       `json:"samples"`
+	Failed  int       `json:"failed"`
+	// Rouge, Similarity and TypeAccuracy are means over the samples that
+	// produced a message; Similarity is -1 without embeddings and
+	// TypeAccuracy -1 when no human headline has a type.
+	Rouge        float64 `json:"rouge_l"`
+	Similarity   float64 `json:"similarity"`
+	TypeAccuracy float64 `json:"type_accuracy"`
+	// Latency is the mean generation time.
+	Latency time.Duration `json:"latency"`
+}
+
+// Summarise averages the scores of one model's run.
+func Summarise(model, promptVersion, dataset string, scores []Score) Row {
+	row := Row{Model: model, Prompt: promptVersion, Dataset: dataset, Date: time.Now().UTC(), Samples: len(scores), Similarity: -1, TypeAccuracy: -1}
+	var ok, embedded, typed, matched int
+	var rouge, similarity float64
+	var elapsed time.Duration
+	for _, s := range scores {
+		if s.Err != nil {
+			row.Failed++
+			continue
+		}
+		ok++
+		rouge += s.Rouge
+		elapsed += s.Elapsed
+		if s.Embedded {
+			embedded++
+			similarity += s.Similarity
+		}
+		if s.Typed {
+			typed++
+			if s.TypeMatch {
+				matched++
+			}
+		}
+	}
+	if ok > 0 {
+		row.Rouge = rouge / float64(ok)
+		row.Latency = elapsed / time.Duration(ok)
+	}
+	if embedded > 0 {
+		row.Similarity = similarity / float64(embedded)
+	}
+	if typed > 0 {
+		row.TypeAccuracy = float64(matched) / float64(typed)
+	}
+	return row
+}
+
+// ReadHistory returns the rows appended to path by earlier runs; a missing
+// file has none.
+func ReadHistory(path string) ([]Row, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rows []Row
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var row Row
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+// AppendHistory adds rows to the JSONL file at path, creating it.
+func AppendHistory(path string, rows []Row) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
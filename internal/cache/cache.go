@@ -0,0 +1,293 @@
+// Package cache keeps model answers on disk keyed by everything that
+// shapes them: the request (prompts, format, sampling options), the exact
+// model build and the prompt template version. Generating again for an
+// unchanged diff then costs nothing, while a re-pulled model or a new
+// release of the prompts never reuses stale answers.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/logging"
+	"github.com/riskibarqy/go-commitgen/internal/ollama"
+	"github.com/riskibarqy/go-commitgen/internal/prompt"
+)
+
+// Entry is one cached answer.
+type Entry struct {
+	Created       time.Time `json:"created"`
+	Model         string    `json:"model"`
+	Digest        string    `json:"digest"`
+	PromptVersion string    `json:"promptVersion"`
+	Response      string    `json:"response"`
+}
+
+// DefaultDir returns the cache location, honouring COMMITGEN_CACHE_DIR.
+func DefaultDir() string {
+	if v := os.Getenv("COMMITGEN_CACHE_DIR"); v != "" {
+		return v
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "go-commitgen", "generations")
+}
+
+// Store is a directory of entries, one JSON file per key.
+type Store struct {
+	Dir string
+}
+
+func (s Store) path(key string) string {
+	return filepath.Join(s.Dir, key+".json")
+}
+
+// Get returns the entry for key unless it is missing, unreadable or older
+// than ttl (zero keeps entries forever).
+func (s Store) Get(key string, ttl time.Duration) (Entry, bool) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return Entry{}, false
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Entry{}, false
+	}
+	if ttl > 0 && time.Since(e.Created) > ttl {
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// Put stores e under key, replacing the file atomically.
+func (s Store) Put(key string, e Entry) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal cache entry: %w", err)
+	}
+	tmp, err := os.CreateTemp(s.Dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	return os.Rename(tmp.Name(), s.path(key))
+}
+
+// entries lists the entry files of the store; a missing directory is
+// empty.
+func (s Store) entries() ([]os.DirEntry, error) {
+	list, err := os.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cache dir: %w", err)
+	}
+	out := list[:0]
+	for _, e := range list {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// Clear removes every entry and returns how many there were.
+func (s Store) Clear() (int, error) {
+	list, err := s.entries()
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range list {
+		if err := os.Remove(filepath.Join(s.Dir, e.Name())); err != nil && !os.IsNotExist(err) {
+			return 0, fmt.Errorf("clear cache: %w", err)
+		}
+	}
+	return len(list), nil
+}
+
+// Stats summarises the store.
+type Stats struct {
+	Dir            string
+	Entries        int
+	Bytes          int64
+	Oldest, Newest time.Time
+	// Models counts the entries per model name.
+	Models map[string]int
+}
+
+// Stats reads every entry of the store.
+func (s Store) Stats() (Stats, error) {
+	st := Stats{Dir: s.Dir, Models: map[string]int{}}
+	list, err := s.entries()
+	if err != nil {
+		return st, err
+	}
+	for _, f := range list {
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		e, ok := s.Get(strings.TrimSuffix(f.Name(), ".json"), 0)
+		if !ok {
+			continue
+		}
+		st.Entries++
+		st.Bytes += info.Size()
+		st.Models[e.Model]++
+		if st.Oldest.IsZero() || e.Created.Before(st.Oldest) {
+			st.Oldest = e.Created
+		}
+		if e.Created.After(st.Newest) {
+			st.Newest = e.Created
+		}
+	}
+	return st, nil
+}
+
+func (st Stats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cache: %s\nentries: %d (%.1f KiB)\n", st.Dir, st.Entries, float64(st.Bytes)/1024)
+	if st.Entries == 0 {
+		return b.String()
+	}
+	fmt.Fprintf(&b, "oldest: %s\nnewest: %s\n", st.Oldest.Format(time.RFC3339), st.Newest.Format(time.RFC3339))
+	models := make([]string, 0, len(st.Models))
+	for m := range st.Models {
+		models = append(models, m)
+	}
+	sort.Strings(models)
+	for _, m := range models {
+		fmt.Fprintf(&b, "  %s: %d\n", m, st.Models[m])
+	}
+	return b.String()
+}
+
+type skipKey struct{}
+
+// Skip returns a context whose model calls bypass the cache lookup; the
+// fresh answers are still stored. Regenerating uses it, since the point
+// is a different answer to the same prompt.
+func Skip(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+// Generator is the model call being cached; ollama.Client implements it.
+type Generator interface {
+	Generate(ctx context.Context, endpoint string, req ollama.Request) (string, error)
+}
+
+// Shower describes a model; ollama.Client implements it.
+type Shower interface {
+	Show(ctx context.Context, endpoint, model string) (ollama.ModelInfo, error)
+}
+
+// Client answers from the store when it can and asks Next otherwise,
+// storing the answer. Requests are only cached once the model's digest is
+// known, since without it a changed model would go unnoticed.
+type Client struct {
+	Next  Generator
+	Show  Shower
+	Store Store
+	// TTL expires entries; zero keeps them until cleared.
+	TTL time.Duration
+	// Log receives a debug record per hit and miss; nil discards them.
+	Log *slog.Logger
+
+	mu      sync.Mutex
+	digests map[string]string // endpoint + model -> digest, "" when unknown
+}
+
+// Generate implements the model call with caching.
+func (c *Client) Generate(ctx context.Context, endpoint string, req ollama.Request) (string, error) {
+	log := logging.Or(c.Log).With("model", req.Model)
+	digest := c.digest(ctx, endpoint, req.Model)
+	if digest == "" {
+		return c.Next.Generate(ctx, endpoint, req)
+	}
+	key, err := Key(req, digest)
+	if err != nil {
+		return c.Next.Generate(ctx, endpoint, req)
+	}
+	if skip, _ := ctx.Value(skipKey{}).(bool); skip {
+		log.Debug("cache skipped", "key", key[:12])
+	} else if e, ok := c.Store.Get(key, c.TTL); ok {
+		log.Debug("cache hit", "key", key[:12], "age", time.Since(e.Created).Round(time.Second))
+		return e.Response, nil
+	} else {
+		log.Debug("cache miss", "key", key[:12])
+	}
+
+	out, err := c.Next.Generate(ctx, endpoint, req)
+	if err != nil {
+		return "", err
+	}
+	entry := Entry{Created: time.Now(), Model: req.Model, Digest: digest, PromptVersion: prompt.Version, Response: out}
+	if err := c.Store.Put(key, entry); err != nil {
+		log.Warn("cannot store answer in cache", "err", err)
+	}
+	return out, nil
+}
+
+// digest returns the model's digest, asking the endpoint once per model.
+func (c *Client) digest(ctx context.Context, endpoint, model string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := endpoint + "\x00" + model
+	if d, ok := c.digests[id]; ok {
+		return d
+	}
+	if c.digests == nil {
+		c.digests = map[string]string{}
+	}
+	info, err := c.Show.Show(ctx, endpoint, model)
+	if err != nil {
+		logging.Or(c.Log).Debug("model digest unavailable, not caching", "model", model, "err", err)
+	}
+	c.digests[id] = info.Digest
+	return info.Digest
+}
+
+// Key hashes everything that shapes the answer to req from the model
+// build digest: the prompt template version, model, prompts, response
+// format and sampling options.
+func Key(req ollama.Request, digest string) (string, error) {
+	material, err := json.Marshal(struct {
+		PromptVersion string                 `json:"v"`
+		Digest        string                 `json:"digest"`
+		Model         string                 `json:"model"`
+		System        string                 `json:"system"`
+		Prompt        string                 `json:"prompt"`
+		Format        interface{}            `json:"format"`
+		Options       map[string]interface{} `json:"options"`
+		Context       []int                  `json:"context,omitempty"`
+	}{prompt.Version, digest, req.Model, req.System, req.Prompt, req.Format, req.MergedOptions(), req.Context})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(material)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,96 @@
+// Package capture bundles what a generation saw and produced (diff, branch,
+// settings, prompts and raw model answers) into a tarball for bug reports,
+// with secrets redacted, so model-quality issues can be reproduced.
+package capture
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// File is one entry of a bundle.
+type File struct {
+	Name string
+	Text string
+}
+
+// Bundle is the set of files of a capture, written under a directory
+// named after its creation time.
+type Bundle struct {
+	Created time.Time
+	Files   []File
+}
+
+// Add appends a file whose text is redacted with secrets.
+func (b *Bundle) Add(name, text string, secrets ...string) {
+	b.Files = append(b.Files, File{Name: name, Text: Redact(text, secrets...)})
+}
+
+// placeholder replaces every redacted value.
+const placeholder = "[REDACTED]"
+
+// secretPatterns match well-known token formats and assignments of values
+// to secret-looking names. Groups named "keep" and "tail" survive the
+// redaction.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,}\b|\bgithub_pat_[A-Za-z0-9_]{22,}\b`),
+	regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`),
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),
+	regexp.MustCompile(`\bsk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}\b`),
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`),
+	regexp.MustCompile(`(?P<keep>(?i)\b(?:bearer|basic|token)\s+)[A-Za-z0-9._~+/=-]{16,}`),
+	regexp.MustCompile(`(?P<keep>\b[a-z][a-z0-9+.-]*://[^\s:/@]+:)[^\s@/]+(?P<tail>@)`),
+	regexp.MustCompile(`(?P<keep>(?i)[\w.-]*(?:password|passwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credentials?)["']?\s*[:=]\s*["']?)[^\s"',;]{6,}`),
+}
+
+// Redact replaces the given secrets (an API key, header values) and
+// anything matching a known token format or assigned to a secret-looking
+// name with a placeholder.
+func Redact(text string, secrets ...string) string {
+	// longest first, so a secret containing another is replaced whole
+	sorted := append([]string(nil), secrets...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, secret := range sorted {
+		if len(strings.TrimSpace(secret)) >= 4 {
+			text = strings.ReplaceAll(text, secret, placeholder)
+		}
+	}
+	for _, re := range secretPatterns {
+		text = re.ReplaceAllString(text, "${keep}"+placeholder+"${tail}")
+	}
+	return text
+}
+
+// Write writes b as a gzipped tarball to w.
+func (b Bundle) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	dir := "commitgen-debug-" + b.Created.UTC().Format("20060102-150405")
+	for _, f := range b.Files {
+		header := &tar.Header{
+			Name:    dir + "/" + f.Name,
+			Mode:    0o644,
+			Size:    int64(len(f.Text)),
+			ModTime: b.Created,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("write %s: %w", f.Name, err)
+		}
+		if _, err := io.WriteString(tw, f.Text); err != nil {
+			return fmt.Errorf("write %s: %w", f.Name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("close tarball: %w", err)
+	}
+	return gz.Close()
+}
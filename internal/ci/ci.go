@@ -0,0 +1,65 @@
+// Package ci recognises the CI system a run happens in and the build it
+// belongs to, so bot commits can point back at the pipeline that made them.
+package ci
+
+import (
+	"os"
+	"strings"
+)
+
+// Info describes the build, with the same field names whatever the
+// provider calls them. Every field is empty outside CI.
+type Info struct {
+	// Provider is github, gitlab, gitea, jenkins, circleci, buildkite,
+	// azure or "ci" for a system that only sets CI=true.
+	Provider string
+	// Pipeline is the unique ID of the run (GITHUB_RUN_ID, CI_PIPELINE_ID).
+	Pipeline string
+	// Build is the number people see, counting up per project
+	// (GITHUB_RUN_NUMBER, CI_PIPELINE_IID, BUILD_NUMBER).
+	Build string
+	Job   string
+	URL   string
+}
+
+// Active reports whether the run happens in CI.
+func (i Info) Active() bool {
+	return i.Provider != ""
+}
+
+// Detect reads Info from the environment.
+func Detect() Info {
+	return detect(os.Getenv)
+}
+
+func detect(env func(string) string) Info {
+	switch {
+	case env("GITHUB_ACTIONS") == "true", env("GITEA_ACTIONS") == "true":
+		provider := "github"
+		if env("GITEA_ACTIONS") == "true" {
+			provider = "gitea"
+		}
+		info := Info{Provider: provider, Pipeline: env("GITHUB_RUN_ID"), Build: env("GITHUB_RUN_NUMBER"), Job: env("GITHUB_JOB")}
+		if server, repo := env("GITHUB_SERVER_URL"), env("GITHUB_REPOSITORY"); server != "" && repo != "" && info.Pipeline != "" {
+			info.URL = strings.TrimRight(server, "/") + "/" + repo + "/actions/runs/" + info.Pipeline
+		}
+		return info
+	case env("GITLAB_CI") == "true":
+		return Info{Provider: "gitlab", Pipeline: env("CI_PIPELINE_ID"), Build: env("CI_PIPELINE_IID"), Job: env("CI_JOB_NAME"), URL: env("CI_PIPELINE_URL")}
+	case env("JENKINS_URL") != "":
+		return Info{Provider: "jenkins", Pipeline: env("BUILD_TAG"), Build: env("BUILD_NUMBER"), Job: env("JOB_NAME"), URL: env("BUILD_URL")}
+	case env("CIRCLECI") == "true":
+		return Info{Provider: "circleci", Pipeline: env("CIRCLE_WORKFLOW_ID"), Build: env("CIRCLE_BUILD_NUM"), Job: env("CIRCLE_JOB"), URL: env("CIRCLE_BUILD_URL")}
+	case env("BUILDKITE") == "true":
+		return Info{Provider: "buildkite", Pipeline: env("BUILDKITE_BUILD_ID"), Build: env("BUILDKITE_BUILD_NUMBER"), Job: env("BUILDKITE_LABEL"), URL: env("BUILDKITE_BUILD_URL")}
+	case strings.EqualFold(env("TF_BUILD"), "true"):
+		info := Info{Provider: "azure", Pipeline: env("BUILD_BUILDID"), Build: env("BUILD_BUILDNUMBER"), Job: env("SYSTEM_JOBDISPLAYNAME")}
+		if collection, project := env("SYSTEM_COLLECTIONURI"), env("SYSTEM_TEAMPROJECT"); collection != "" && project != "" && info.Pipeline != "" {
+			info.URL = strings.TrimRight(collection, "/") + "/" + project + "/_build/results?buildId=" + info.Pipeline
+		}
+		return info
+	case env("CI") != "" && env("CI") != "false" && env("CI") != "0":
+		return Info{Provider: "ci", Build: env("BUILD_NUMBER"), Job: env("JOB_NAME"), URL: env("BUILD_URL")}
+	}
+	return Info{}
+}
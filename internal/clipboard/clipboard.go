@@ -0,0 +1,90 @@
+// Package clipboard puts text on the system clipboard through the
+// platform's own tools, falling back to the OSC 52 terminal escape
+// (which also works over SSH) when none is installed.
+package clipboard
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrUnavailable is returned when no clipboard tool is installed and
+// there is no terminal to fall back to.
+var ErrUnavailable = errors.New("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+
+// tool is a command that reads the text to copy from stdin.
+type tool struct {
+	name string
+	args []string
+	// when reports whether the tool applies to this session.
+	when func() bool
+}
+
+func always() bool { return true }
+
+// tools lists the copy commands per platform, in order of preference.
+var tools = map[string][]tool{
+	"darwin": {{name: "pbcopy", when: always}},
+	"windows": {
+		{name: "powershell.exe", args: []string{"-NoProfile", "-NonInteractive", "-Command",
+			"[Console]::InputEncoding = [Text.Encoding]::UTF8; Set-Clipboard -Value ([Console]::In.ReadToEnd())"}, when: always},
+		{name: "clip.exe", when: always},
+	},
+	"linux": {
+		{name: "wl-copy", when: func() bool { return os.Getenv("WAYLAND_DISPLAY") != "" }},
+		{name: "xclip", args: []string{"-selection", "clipboard"}, when: func() bool { return os.Getenv("DISPLAY") != "" }},
+		{name: "xsel", args: []string{"--clipboard", "--input"}, when: func() bool { return os.Getenv("DISPLAY") != "" }},
+		{name: "termux-clipboard-set", when: always},
+		// WSL: the Windows clipboard
+		{name: "clip.exe", when: always},
+	},
+}
+
+// Copy puts text on the clipboard. It uses the first available tool of
+// the platform; without one it writes the OSC 52 escape to tty, which
+// most terminals turn into a clipboard copy, when tty is not nil.
+func Copy(ctx context.Context, text string, tty io.Writer) error {
+	platform := runtime.GOOS
+	if _, ok := tools[platform]; !ok {
+		platform = "linux" // the BSDs share the X11/Wayland tools
+	}
+	for _, t := range tools[platform] {
+		if !t.when() {
+			continue
+		}
+		path, err := exec.LookPath(t.name)
+		if err != nil {
+			continue
+		}
+		cmd := exec.CommandContext(ctx, path, t.args...)
+		cmd.Stdin = strings.NewReader(text)
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %w: %s", t.name, err, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+	if tty == nil {
+		return ErrUnavailable
+	}
+	return OSC52(tty, text)
+}
+
+// OSC52 writes the escape sequence asking the terminal to copy text.
+// Inside tmux the sequence is wrapped so it reaches the outer terminal.
+func OSC52(w io.Writer, text string) error {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	_, err := io.WriteString(w, seq)
+	return err
+}
@@ -0,0 +1,129 @@
+package codeowners
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Locations lists where forges look for the CODEOWNERS file, in order.
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// Rule maps a path pattern to its owners.
+type Rule struct {
+	Pattern string
+	Owners  []string
+	re      *regexp.Regexp
+}
+
+// Ruleset is a parsed CODEOWNERS file; later rules take precedence.
+type Ruleset []Rule
+
+// Load reads the first CODEOWNERS file found under root. A missing file
+// yields an empty ruleset.
+func Load(root string) (Ruleset, error) {
+	for _, loc := range Locations {
+		f, err := os.Open(filepath.Join(root, loc))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return Parse(f)
+	}
+	return nil, nil
+}
+
+// Parse reads CODEOWNERS rules, skipping comments and GitLab section headers.
+func Parse(r io.Reader) (Ruleset, error) {
+	var rules Ruleset
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if idx := strings.Index(line, " #"); idx != -1 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			continue
+		}
+		fields := strings.Fields(line)
+		rules = append(rules, Rule{
+			Pattern: fields[0],
+			Owners:  fields[1:],
+			re:      compile(fields[0]),
+		})
+	}
+	return rules, sc.Err()
+}
+
+// Owners returns the owners of path according to the last matching rule.
+func (rs Ruleset) Owners(path string) []string {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+	for i := len(rs) - 1; i >= 0; i-- {
+		if rs[i].re.MatchString(path) {
+			return rs[i].Owners
+		}
+	}
+	return nil
+}
+
+// ByPath maps every path with owners to them, plus the sorted union of all owners.
+func (rs Ruleset) ByPath(paths []string) (map[string][]string, []string) {
+	byPath := make(map[string][]string)
+	seen := make(map[string]bool)
+	var all []string
+	for _, p := range paths {
+		owners := rs.Owners(p)
+		if len(owners) == 0 {
+			continue
+		}
+		byPath[p] = owners
+		for _, o := range owners {
+			if !seen[o] {
+				seen[o] = true
+				all = append(all, o)
+			}
+		}
+	}
+	sort.Strings(all)
+	return byPath, all
+}
+
+// compile turns a gitignore-style CODEOWNERS pattern into a regexp.
+func compile(pattern string) *regexp.Regexp {
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "*" || pattern == "" {
+		return regexp.MustCompile(".*")
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	prefix := "^(?:.*/)?"
+	if anchored {
+		prefix = "^"
+	}
+	return regexp.MustCompile(prefix + b.String() + "(?:/.*)?$")
+}
@@ -0,0 +1,222 @@
+package commit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Conventions holds the team-specific rules applied to model output. The
+// zero value uses the built-in commit types.
+type Conventions struct {
+	// Types are the canonical commit types offered to the model, in order.
+	Types []string
+	// Aliases maps alternative spellings (e.g. "hf") to a canonical type.
+	Aliases map[string]string
+	// Sections has the model write the body as separate What, Why and
+	// How to test fields, laid out under those headings.
+	Sections bool
+	// Scopes restricts the model's scope to this list; empty allows any.
+	// A scope outside it is mapped to the nearest listed one, or, with
+	// ScopeRetry, reported as a lint violation so the model regenerates.
+	Scopes     []string
+	ScopeRetry bool
+	// Style is applied to the built message; its case policy is also
+	// linted so the model gets a chance to follow it.
+	Style Style
+	// MaxDescription, MaxSummary and MaxBody are the lengths in characters
+	// the model must keep the fields to; zero means 72, 100 and 300.
+	MaxDescription int
+	MaxSummary     int
+	MaxBody        int
+	// MaxHeadline, when set, limits the whole headline, ticket and type
+	// included; the description is shortened to fit.
+	MaxHeadline int
+	// NoBody leaves the body out of the message: the headline stands
+	// alone, followed only by trailers.
+	NoBody bool
+	// DropRedundantBody leaves the body out when it only restates the
+	// headline's description, with fewer than three words of its own.
+	DropRedundantBody bool
+	// Ticket is the issue reference ("PROJ-123", "#12") the headline and
+	// the issue keyword use instead of the one in the branch name.
+	Ticket string
+}
+
+var (
+	// DefaultTypes is the built-in commit type taxonomy.
+	DefaultTypes = []string{"feat", "fix", "perf", "refactor", "docs", "test", "build", "chore", "ci"}
+
+	defaultAliases = map[string]string{
+		"feature": "feat",
+		"bugfix":  "fix",
+		"doc":     "docs",
+		"tests":   "test",
+	}
+)
+
+// NewConventions validates a custom taxonomy: every alias must point at one
+// of the types.
+func NewConventions(types []string, aliases map[string]string) (Conventions, error) {
+	c := Conventions{Aliases: map[string]string{}}
+	seen := map[string]bool{}
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		c.Types = append(c.Types, t)
+	}
+	if len(c.Types) == 0 {
+		c.Types = DefaultTypes
+		for _, t := range DefaultTypes {
+			seen[t] = true
+		}
+	}
+
+	for alias, target := range aliases {
+		alias, target = strings.ToLower(strings.TrimSpace(alias)), strings.ToLower(strings.TrimSpace(target))
+		if !seen[target] {
+			return Conventions{}, fmt.Errorf("alias %q points at unknown commit type %q", alias, target)
+		}
+		c.Aliases[alias] = target
+	}
+	return c, nil
+}
+
+// DescriptionLimit returns MaxDescription or its default.
+func (c Conventions) DescriptionLimit() int {
+	if c.MaxDescription > 0 {
+		return c.MaxDescription
+	}
+	return 72
+}
+
+// SummaryLimit returns MaxSummary or its default.
+func (c Conventions) SummaryLimit() int {
+	if c.MaxSummary > 0 {
+		return c.MaxSummary
+	}
+	return 100
+}
+
+// BodyLimit returns MaxBody or its default.
+func (c Conventions) BodyLimit() int {
+	if c.MaxBody > 0 {
+		return c.MaxBody
+	}
+	return 300
+}
+
+// minDescription is the shortest description budget MaxHeadline leaves.
+const minDescription = 20
+
+// FitHeadline lowers MaxDescription so that a headline for branch stays
+// within MaxHeadline whichever type (and scope) the model picks.
+func (c Conventions) FitHeadline(branch string) Conventions {
+	if c.MaxHeadline <= 0 {
+		return c
+	}
+	ticket := extractTicket(branch)
+	if c.Ticket != "" {
+		ticket = c.Ticket
+	}
+	prefix := len([]rune(ticket)) + len(" [] ") + longest(c.AllowedTypes())
+	if len(c.Scopes) > 0 {
+		prefix += len("()") + longest(c.Scopes)
+	}
+	if budget := max(c.MaxHeadline-prefix, minDescription); budget < c.DescriptionLimit() {
+		c.MaxDescription = budget
+	}
+	return c
+}
+
+func longest(words []string) int {
+	n := 0
+	for _, w := range words {
+		n = max(n, len([]rune(w)))
+	}
+	return n
+}
+
+// AllowedTypes returns the canonical commit types in prompt order.
+func (c Conventions) AllowedTypes() []string {
+	if len(c.Types) == 0 {
+		return DefaultTypes
+	}
+	return c.Types
+}
+
+// fallbackType is used when the model's type cannot be mapped: "chore" when
+// the taxonomy has it, otherwise its last entry.
+func (c Conventions) fallbackType() string {
+	types := c.AllowedTypes()
+	for _, t := range types {
+		if t == "chore" {
+			return t
+		}
+	}
+	return types[len(types)-1]
+}
+
+// lookup maps every accepted spelling to its canonical type. Built-in
+// aliases apply whenever their target type is allowed.
+func (c Conventions) lookup() map[string]string {
+	m := make(map[string]string, len(c.AllowedTypes())+len(defaultAliases)+len(c.Aliases))
+	for _, t := range c.AllowedTypes() {
+		m[t] = t
+	}
+	for alias, target := range defaultAliases {
+		if _, ok := m[target]; ok {
+			m[alias] = target
+		}
+	}
+	for alias, target := range c.Aliases {
+		m[alias] = target
+	}
+	return m
+}
+
+func (c Conventions) normaliseCommitType(t string) string {
+	candidate := strings.ToLower(strings.TrimSpace(t))
+	candidate = strings.Trim(candidate, "[]")
+	if candidate == "" {
+		return c.fallbackType()
+	}
+	lookup := c.lookup()
+	if mapped, ok := lookup[candidate]; ok {
+		return mapped
+	}
+	for key, value := range lookup {
+		if strings.HasPrefix(candidate, key) {
+			return value
+		}
+	}
+	return c.fallbackType()
+}
+
+// detectKeywords is the order keywords are looked for in an answer that is
+// not JSON: a fix that also mentions a feature is still a fix.
+var detectKeywords = []string{"fix", "feat", "perf", "refactor", "docs", "test", "build", "ci"}
+
+// detectCommitType guesses the type of a raw answer from the first keyword
+// it contains: the built-in ones in detectKeywords order, as far as the
+// taxonomy accepts them, then the custom types in prompt order.
+func (c Conventions) detectCommitType(raw string) string {
+	lower := strings.ToLower(raw)
+	lookup := c.lookup()
+	fallback := c.fallbackType()
+	candidates := make([]string, 0, len(detectKeywords)+len(c.AllowedTypes()))
+	for _, keyword := range detectKeywords {
+		if _, ok := lookup[keyword]; ok {
+			candidates = append(candidates, keyword)
+		}
+	}
+	candidates = append(candidates, c.AllowedTypes()...)
+	for _, candidate := range candidates {
+		if mapped := lookup[candidate]; mapped != fallback && strings.Contains(lower, candidate) {
+			return mapped
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,74 @@
+package commit
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// redundantNewWords is how many words of its own a text needs, beyond
+// those of the description, to be worth a place in the body.
+const redundantNewWords = 3
+
+// dedupeBody removes the body lines that restate the description, or an
+// earlier line, nearly verbatim. The summary only stands in for a body
+// left empty, and not when it adds nothing to the description either.
+func dedupeBody(description, summary, body string) string {
+	seen := []string{comparable(description, false)}
+	var kept []string
+	for _, line := range util.TrimLines(body) {
+		c := comparable(strings.TrimLeft(line, "-* "), false)
+		if nearlyAny(c, seen) {
+			continue
+		}
+		kept = append(kept, line)
+		seen = append(seen, c)
+	}
+	if len(kept) == 0 && !addsNothing(description, summary) {
+		return summary
+	}
+	return strings.Join(kept, "\n")
+}
+
+// nearlyAny reports whether s is RepeatThreshold-similar to any of others;
+// an empty s always is.
+func nearlyAny(s string, others []string) bool {
+	if s == "" {
+		return true
+	}
+	for _, o := range others {
+		if o != "" && Similarity(s, o) >= RepeatThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// addsNothing reports whether text uses fewer than redundantNewWords words,
+// of four letters or more, that description does not.
+func addsNothing(description, text string) bool {
+	known := map[string]bool{}
+	for _, w := range contentWords(description) {
+		known[w] = true
+	}
+	fresh := map[string]bool{}
+	for _, w := range contentWords(text) {
+		if !known[w] {
+			fresh[w] = true
+		}
+	}
+	return len(fresh) < redundantNewWords
+}
+
+func contentWords(s string) []string {
+	var out []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(w)) >= 4 {
+			out = append(out, w)
+		}
+	}
+	return out
+}
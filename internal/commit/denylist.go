@@ -0,0 +1,31 @@
+package commit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultDenylist holds the vague phrases a headline must not contain.
+var DefaultDenylist = []string{"stuff", "various changes", "minor fixes", "misc changes", "some changes", "update code", "wip"}
+
+// Denied returns the first denylist entry found in description, matched
+// case-insensitively on word boundaries, or "" when it is clean.
+func Denied(description string, denylist []string) string {
+	for _, term := range denylist {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		re := regexp.MustCompile(`(?i)(^|\W)` + regexp.QuoteMeta(term) + `($|\W)`)
+		if re.MatchString(description) {
+			return term
+		}
+	}
+	return ""
+}
+
+// DenyViolation is the lint violation reported for a denied phrase.
+func DenyViolation(term string) Violation {
+	return Violation{Rule: "denylist", Message: fmt.Sprintf("description contains the forbidden phrase %q; name the concrete change instead", term)}
+}
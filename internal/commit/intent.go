@@ -0,0 +1,35 @@
+package commit
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	branchSeparators = regexp.MustCompile(`[-_.]+`)
+	branchNoise      = regexp.MustCompile(`^(?:\d+|issues?|gh|wip|tmp)$`)
+)
+
+// BranchIntent turns the words of a descriptive branch name into a phrase
+// ("feature/PROJ-12-migrate-to-pgx" → "migrate to pgx"). Ticket ids, issue
+// numbers and the type prefix are dropped; names with fewer than two words
+// left say nothing about intent and yield "".
+func BranchIntent(branch string) string {
+	branch = strings.TrimSpace(branch)
+	if idx := strings.LastIndex(branch, "/"); idx != -1 {
+		branch = branch[idx+1:]
+	}
+	branch = ticketPattern.ReplaceAllString(branch, "")
+
+	var words []string
+	for _, w := range branchSeparators.Split(branch, -1) {
+		if w == "" || branchNoise.MatchString(strings.ToLower(w)) {
+			continue
+		}
+		words = append(words, strings.ToLower(w))
+	}
+	if len(words) < 2 {
+		return ""
+	}
+	return strings.Join(words, " ")
+}
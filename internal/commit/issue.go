@@ -0,0 +1,75 @@
+package commit
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultIssueKeywords maps commit types to the keyword put in front of the
+// issue reference: fixes close the issue, features only reference it.
+var DefaultIssueKeywords = map[string]string{
+	"fix":  "Fixes",
+	"feat": "Refs",
+}
+
+// issueNumberPattern needs "#" or an issue prefix in front of the number:
+// a bare leading number is as often a year or a version ("2024-refactor")
+// as an issue.
+var issueNumberPattern = regexp.MustCompile(`^(?:(?:issue|issues|gh)[-_]?#?|#)(\d+)(?:[-_]|$)`)
+
+var (
+	issueRefNumber = regexp.MustCompile(`^#?(\d+)$`)
+	issueRefKey    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*-\d+$`)
+)
+
+// ParseIssueRef reads an issue given on the command line: a forge issue
+// number ("#12", "12") or a tracker ticket key ("proj-123" gives
+// "PROJ-123"). ok is false for anything else.
+func ParseIssueRef(ref string) (number int, key string, ok bool) {
+	ref = strings.TrimSpace(ref)
+	if m := issueRefNumber.FindStringSubmatch(ref); m != nil {
+		number, err := strconv.Atoi(m[1])
+		return number, "", err == nil && number > 0
+	}
+	if issueRefKey.MatchString(ref) {
+		return 0, strings.ToUpper(ref), true
+	}
+	return 0, "", false
+}
+
+// IssueReference derives the issue a branch refers to: "PROJ-123" for
+// tracker tickets, "#123" for GitHub/GitLab issue branches such as
+// "issue-123-fix-login", "gh-123" or "#123". It returns "" when there is
+// none.
+func IssueReference(branch string) string {
+	branch = strings.TrimSpace(branch)
+	if idx := strings.LastIndex(branch, "/"); idx != -1 && idx < len(branch)-1 {
+		branch = branch[idx+1:]
+	}
+	if m := issueNumberPattern.FindStringSubmatch(strings.ToLower(branch)); len(m) == 2 {
+		return "#" + m[1]
+	}
+	if m := ticketPattern.FindStringSubmatch(branch); len(m) == 2 {
+		return strings.ToUpper(m[1])
+	}
+	return ""
+}
+
+// WithIssueKeyword adds a "<Keyword> <reference>" line to the body when
+// the branch (or c.Ticket) refers to an issue and the commit type has a
+// keyword. Bodies
+// already mentioning the reference are left alone.
+func (c Conventions) WithIssueKeyword(msg Message, branch, commitType string, keywords map[string]string) Message {
+	ref := IssueReference(branch)
+	if c.Ticket != "" {
+		ref = c.Ticket
+	}
+	keyword := keywords[c.normaliseCommitType(commitType)]
+	if ref == "" || keyword == "" || strings.Contains(msg.Body, ref) {
+		return msg
+	}
+
+	// before any trailers, which git only reads from the last paragraph
+	return WithSection(msg, keyword+" "+ref)
+}
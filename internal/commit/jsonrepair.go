@@ -0,0 +1,207 @@
+package commit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DecodeJSON decodes the JSON object in a model answer into v, a pointer.
+// The answer may wrap the object in prose or code fences, hold several
+// objects (the first that decodes into v wins) and carry the mistakes
+// small models make: trailing commas, raw newlines inside strings and
+// typographic quotes, which RepairJSON fixes.
+func DecodeJSON(raw string, v interface{}) error {
+	candidates, err := jsonCandidates(raw)
+	if err != nil {
+		return err
+	}
+	for _, candidate := range candidates {
+		// a failed attempt may have filled part of v
+		reflect.ValueOf(v).Elem().Set(reflect.Zero(reflect.TypeOf(v).Elem()))
+		if err = json.Unmarshal([]byte(candidate), v); err == nil {
+			return nil
+		}
+	}
+	return decodeError(candidates, err)
+}
+
+// jsonCandidates returns the answer itself when it is valid JSON, and
+// otherwise its objects, each repaired when it is not. Objects that stay
+// invalid are dropped; none left is an error.
+func jsonCandidates(raw string) ([]string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, errors.New("empty response")
+	}
+	if json.Valid([]byte(raw)) && strings.HasPrefix(raw, "{") {
+		return []string{raw}, nil
+	}
+
+	objects := JSONObjects(raw)
+	if len(objects) == 0 {
+		return nil, errors.New("response missing JSON object")
+	}
+	var candidates []string
+	var first error
+	for _, object := range objects {
+		if !json.Valid([]byte(object)) {
+			object = RepairJSON(object)
+		}
+		var syntax json.RawMessage
+		if err := json.Unmarshal([]byte(object), &syntax); err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		candidates = append(candidates, object)
+	}
+	if len(candidates) == 0 {
+		return nil, decodeError(objects, first)
+	}
+	return candidates, nil
+}
+
+func decodeError(objects []string, err error) error {
+	if len(objects) > 1 {
+		return fmt.Errorf("none of the %d JSON objects in the response decodes: %w", len(objects), err)
+	}
+	return err
+}
+
+// JSONObjects returns the balanced top-level {...} spans of s in order,
+// skipping braces inside strings. An object left open at the end, as a
+// truncated answer has, is not returned.
+func JSONObjects(s string) []string {
+	var objects []string
+	depth, start := 0, -1
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			// prose quotes outside an object are not strings
+			inString = depth > 0
+		case '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 {
+				objects = append(objects, s[start:i+1])
+			}
+		}
+	}
+	return objects
+}
+
+// RepairJSON fixes the common ways a model breaks a JSON object: a comma
+// before a closing bracket, newlines, tabs and other control characters
+// written raw inside strings, and keys or values quoted with typographic
+// quotes (“…” or ‘…’). Anything else is left for the decoder to reject.
+func RepairJSON(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	inString, escaped := false, false
+	// closing is the quote that ends the current string; typographic
+	// strings end on either of their pair, where a value can end
+	var closing []rune
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+				b.WriteRune(r)
+			case r == '\\':
+				escaped = true
+				b.WriteRune(r)
+			case containsRune(closing, r) && (r == '"' || endsValue(runes[i+1:])):
+				inString = false
+				b.WriteByte('"')
+			case r == '"':
+				// an ASCII quote inside a typographically quoted string
+				b.WriteString(`\"`)
+			case r == '\n':
+				b.WriteString(`\n`)
+			case r == '\r':
+				b.WriteString(`\r`)
+			case r == '\t':
+				b.WriteString(`\t`)
+			case r < 0x20:
+				fmt.Fprintf(&b, `\u%04x`, r)
+			default:
+				b.WriteRune(r)
+			}
+			continue
+		}
+		switch r {
+		case '"':
+			inString, closing = true, []rune{'"'}
+			b.WriteByte('"')
+		case '“', '”':
+			inString, closing = true, []rune{'“', '”'}
+			b.WriteByte('"')
+		case '‘', '’':
+			inString, closing = true, []rune{'‘', '’'}
+			b.WriteByte('"')
+		case ',':
+			j := i + 1
+			for j < len(runes) && strings.ContainsRune(" \t\r\n", runes[j]) {
+				j++
+			}
+			if j < len(runes) && (runes[j] == '}' || runes[j] == ']') {
+				continue
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// endsValue reports whether rest, what follows a typographic quote, starts
+// like the remainder of an object after a key or value; a quote followed
+// by anything else is quoting inside the string.
+func endsValue(rest []rune) bool {
+	for _, r := range rest {
+		switch r {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case ',', ':', '}', ']':
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+func containsRune(runes []rune, r rune) bool {
+	for _, c := range runes {
+		if c == r {
+			return true
+		}
+	}
+	return false
+}
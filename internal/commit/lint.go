@@ -0,0 +1,66 @@
+package commit
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Violation describes a single lint rule broken by the model output.
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Rule + ": " + v.Message
+}
+
+// Lint checks raw (not yet normalised) parts against the commit conventions
+// and returns every rule that was broken. An empty result means the parts can
+// be used as-is.
+func (c Conventions) Lint(p Parts) []Violation {
+	var out []Violation
+
+	commitType := strings.ToLower(strings.TrimSpace(p.CommitType))
+	if commitType == "" {
+		out = append(out, Violation{Rule: "type", Message: "commit_type is missing"})
+	} else if _, ok := c.lookup()[strings.Trim(commitType, "[]")]; !ok {
+		out = append(out, Violation{Rule: "type", Message: fmt.Sprintf("commit_type %q is not one of %s", p.CommitType, strings.Join(c.AllowedTypes(), ", "))})
+	}
+	out = append(out, c.lintScope(p)...)
+
+	description := strings.TrimSpace(p.Description)
+	switch {
+	case description == "":
+		out = append(out, Violation{Rule: "description", Message: "description is missing"})
+	default:
+		if n, limit := utf8.RuneCountInString(description), c.DescriptionLimit(); n > limit {
+			out = append(out, Violation{Rule: "description", Message: fmt.Sprintf("description is %d characters, limit is %d", n, limit)})
+		}
+		if strings.ContainsAny(description, "\r\n") {
+			out = append(out, Violation{Rule: "description", Message: "description must be a single line"})
+		}
+		if strings.HasSuffix(description, ".") {
+			out = append(out, Violation{Rule: "description", Message: "description must not end with a period"})
+		}
+		out = append(out, c.Style.LintCase(description)...)
+	}
+
+	if n, limit := utf8.RuneCountInString(strings.TrimSpace(p.Summary)), c.SummaryLimit(); n > limit {
+		out = append(out, Violation{Rule: "summary", Message: fmt.Sprintf("summary is %d characters, limit is %d", n, limit)})
+	}
+
+	switch {
+	case c.Sections:
+		out = append(out, lintSections(p)...)
+	case c.NoBody:
+		// the body is dropped whatever the model wrote
+	default:
+		if n := utf8.RuneCountInString(strings.TrimSpace(p.Body)); n > c.BodyLimit() {
+			out = append(out, Violation{Rule: "body", Message: fmt.Sprintf("body is %d characters, limit is %d", n, c.BodyLimit())})
+		}
+	}
+
+	return out
+}
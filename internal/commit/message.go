@@ -2,9 +2,9 @@ package commit
 
 import (
 	"encoding/json"
-	"errors"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/riskibarqy/go-commitgen/internal/util"
 )
@@ -12,94 +12,143 @@ import (
 // Parts represents the structured information returned by the model.
 type Parts struct {
 	CommitType  string `json:"commit_type"`
+	Scope       string `json:"scope,omitempty"`
 	Description string `json:"description"`
 	Summary     string `json:"summary"`
 	Body        string `json:"body"`
+	// What, Why and HowToTest replace Body when Conventions.Sections is set.
+	What      string `json:"what,omitempty"`
+	Why       string `json:"why,omitempty"`
+	HowToTest string `json:"how_to_test,omitempty"`
 }
 
 // Message holds the final headline and body to be presented or committed.
 type Message struct {
-	Headline string
-	Body     string
+	Headline string `json:"headline"`
+	Body     string `json:"body"`
 }
 
-var (
-	allowedCommitTypes = map[string]string{
-		"feat":     "feat",
-		"feature":  "feat",
-		"fix":      "fix",
-		"bugfix":   "fix",
-		"perf":     "perf",
-		"refactor": "refactor",
-		"docs":     "docs",
-		"doc":      "docs",
-		"test":     "test",
-		"tests":    "test",
-		"build":    "build",
-		"chore":    "chore",
-		"ci":       "ci",
-	}
-	ticketPattern  = regexp.MustCompile(`^([A-Za-z]+-\d+)`)
-	commitKeywords = []string{"fix", "feat", "perf", "refactor", "docs", "test", "build", "ci"}
-)
-
-// ParseParts normalises the model output into Parts enforcing length limits.
-func ParseParts(raw string) (Parts, error) {
-	raw = strings.TrimSpace(raw)
-	if raw == "" {
-		return Parts{}, errors.New("empty response")
+// String returns the message as git stores it: the headline, then a
+// blank line and the body when there is one.
+func (m Message) String() string {
+	if m.Body == "" {
+		return m.Headline
 	}
+	return m.Headline + "\n\n" + m.Body
+}
+
+var ticketPattern = regexp.MustCompile(`^([A-Za-z]+-\d+)`)
 
-	start := strings.Index(raw, "{")
-	end := strings.LastIndex(raw, "}")
-	if start == -1 || end == -1 || start > end {
-		return Parts{}, errors.New("response missing JSON object")
+// ParseParts normalises the model output into Parts enforcing length limits.
+func (c Conventions) ParseParts(raw string) (Parts, error) {
+	p, err := DecodeParts(raw)
+	if err != nil {
+		return Parts{}, err
 	}
+	return c.NormaliseParts(p), nil
+}
 
-	var p Parts
-	if err := json.Unmarshal([]byte(raw[start:end+1]), &p); err != nil {
+// DecodeParts extracts the JSON object from the model output without
+// normalising it, so callers can lint what the model actually produced.
+// Malformed objects are repaired as DecodeJSON describes. Some models
+// answer with several objects, drafts or alternatives; the most complete
+// one is used, the first of equals.
+func DecodeParts(raw string) (Parts, error) {
+	candidates, err := jsonCandidates(raw)
+	if err != nil {
 		return Parts{}, err
 	}
+	var best Parts
+	found, bestScore := false, -1
+	for _, candidate := range candidates {
+		var p Parts
+		if err = json.Unmarshal([]byte(candidate), &p); err != nil {
+			continue
+		}
+		if score := p.completeness(); score > bestScore {
+			best, bestScore, found = p, score, true
+		}
+	}
+	if !found {
+		return Parts{}, decodeError(candidates, err)
+	}
+	return best, nil
+}
 
-	return normaliseParts(p), nil
+// completeness ranks candidate answers: the type and description the
+// headline needs weigh most, then each part of the body.
+func (p Parts) completeness() int {
+	score := 0
+	for _, required := range []string{p.CommitType, p.Description} {
+		if strings.TrimSpace(required) != "" {
+			score += 3
+		}
+	}
+	for _, optional := range []string{p.Scope, p.Summary, p.Body, p.What, p.Why, p.HowToTest} {
+		if strings.TrimSpace(optional) != "" {
+			score++
+		}
+	}
+	return score
 }
 
 // FallbackParts attempts to build a meaningful Parts struct from an arbitrary string.
-func FallbackParts(raw string) Parts {
-	clean := sanitizeDescription(raw)
+func (c Conventions) FallbackParts(raw string) Parts {
+	clean := sanitizeDescription(raw, c.DescriptionLimit(), c.Style.KeepPeriod)
 	if clean == "" {
 		clean = "update project files"
 	}
 
-	summary := sanitizeSummary(raw)
+	summary := sanitizeSummary(raw, c.SummaryLimit())
 	if summary == "" {
-		summary = util.TruncateShorten(clean, 100)
+		summary = util.TruncateShorten(clean, c.SummaryLimit())
 	}
 
 	return Parts{
-		CommitType:  detectCommitType(raw),
+		CommitType:  c.detectCommitType(raw),
 		Description: clean,
 		Summary:     summary,
-		Body:        sanitizeBody(raw, summary),
+		Body:        sanitizeBody(raw, c.BodyLimit()),
 	}
 }
 
 // BuildMessage creates the final printable/committable representation.
-func BuildMessage(branch string, parts Parts) Message {
+func (c Conventions) BuildMessage(branch string, parts Parts) Message {
 	ticket := extractTicket(branch)
-	commitType := normaliseCommitType(parts.CommitType)
-	description := sanitizeDescription(parts.Description)
+	if c.Ticket != "" {
+		ticket = c.Ticket
+	}
+	commitType := c.normaliseCommitType(parts.CommitType)
+	description := sanitizeDescription(parts.Description, c.DescriptionLimit(), c.Style.KeepPeriod)
 	if description == "" {
 		description = "update project files"
 	}
 
-	summary := sanitizeSummary(parts.Summary)
+	summary := sanitizeSummary(parts.Summary, c.SummaryLimit())
 	if summary == "" {
-		summary = util.TruncateShorten(description, 100)
+		summary = util.TruncateShorten(description, c.SummaryLimit())
 	}
 
-	body := sanitizeBody(parts.Body, summary)
+	body := dedupeBody(description, summary, sanitizeBody(parts.Body, c.BodyLimit()))
+	switch {
+	case c.NoBody:
+		body = ""
+	case c.Sections:
+		if sectioned := sectionBody(parts); sectioned != "" {
+			body = sectioned
+		}
+	case c.DropRedundantBody && addsNothing(description, body):
+		body = ""
+	}
+	// only a configured scope list makes scopes part of the headline
+	if scope := c.normaliseScope(parts.Scope); scope != "" && len(c.Scopes) > 0 {
+		commitType += "(" + scope + ")"
+	}
 	headline := strings.TrimSpace(strings.Join([]string{ticket, "[" + commitType + "]", description}, " "))
+	if over := utf8.RuneCountInString(headline) - c.MaxHeadline; c.MaxHeadline > 0 && over > 0 {
+		short := strings.TrimRight(util.TruncateShorten(description, max(utf8.RuneCountInString(description)-over, 1)), ".")
+		headline = strings.TrimSuffix(headline, description) + short
+	}
 
 	return Message{
 		Headline: headline,
@@ -107,69 +156,47 @@ func BuildMessage(branch string, parts Parts) Message {
 	}
 }
 
-func normaliseParts(p Parts) Parts {
-	p.CommitType = normaliseCommitType(p.CommitType)
-	p.Description = sanitizeDescription(p.Description)
-	p.Summary = sanitizeSummary(p.Summary)
-	p.Body = sanitizeBody(p.Body, p.Summary)
-	return p
-}
-
-func normaliseCommitType(t string) string {
-	candidate := strings.ToLower(strings.TrimSpace(t))
-	candidate = strings.Trim(candidate, "[]")
-	if candidate == "" {
-		return "chore"
-	}
-	if mapped, ok := allowedCommitTypes[candidate]; ok {
-		return mapped
-	}
-	for key, value := range allowedCommitTypes {
-		if strings.HasPrefix(candidate, key) {
-			return value
-		}
-	}
-	return "chore"
-}
-
-func detectCommitType(raw string) string {
-	lower := strings.ToLower(raw)
-	for _, candidate := range commitKeywords {
-		if strings.Contains(lower, candidate) {
-			return normaliseCommitType(candidate)
-		}
+// NormaliseParts applies the commit conventions to parts, fixing up anything
+// the model got wrong (unknown types, overlong lines, trailing periods).
+func (c Conventions) NormaliseParts(p Parts) Parts {
+	p.CommitType = c.normaliseCommitType(p.CommitType)
+	p.Scope = c.normaliseScope(p.Scope)
+	p.Description = sanitizeDescription(p.Description, c.DescriptionLimit(), c.Style.KeepPeriod)
+	p.Summary = sanitizeSummary(p.Summary, c.SummaryLimit())
+	p.Body = sanitizeBody(p.Body, c.BodyLimit())
+	if c.Sections {
+		p = normaliseSections(p)
 	}
-	return "chore"
+	return p
 }
 
-func sanitizeDescription(s string) string {
+func sanitizeDescription(s string, limit int, keepPeriod bool) string {
 	s = util.CondenseSpaces(strings.TrimSpace(s))
 	if s == "" {
 		return ""
 	}
-	if len([]rune(s)) > 72 {
-		s = util.TruncateShorten(s, 72)
+	if len([]rune(s)) > limit {
+		s = util.TruncateShorten(s, limit)
+	}
+	if keepPeriod {
+		return s
 	}
 	return strings.TrimRight(s, ".")
 }
 
-func sanitizeSummary(s string) string {
+func sanitizeSummary(s string, limit int) string {
 	s = util.CondenseSpaces(strings.TrimSpace(s))
 	if s == "" {
 		return ""
 	}
-	if len([]rune(s)) > 100 {
-		s = util.TruncateShorten(s, 100)
+	if len([]rune(s)) > limit {
+		s = util.TruncateShorten(s, limit)
 	}
 	return s
 }
 
-func sanitizeBody(body, summary string) string {
-	body = strings.TrimSpace(body)
-	if body == "" {
-		body = summary
-	}
-
+// sanitizeBody condenses the body's lines and shortens any over limit.
+func sanitizeBody(body string, limit int) string {
 	lines := util.TrimLines(body)
 	if len(lines) == 0 {
 		return ""
@@ -177,8 +204,8 @@ func sanitizeBody(body, summary string) string {
 
 	for i, line := range lines {
 		line = util.CondenseSpaces(line)
-		if len([]rune(line)) > 300 {
-			line = util.TruncateShorten(line, 300)
+		if len([]rune(line)) > limit {
+			line = util.TruncateShorten(line, limit)
 		}
 		lines[i] = line
 	}
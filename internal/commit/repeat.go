@@ -0,0 +1,59 @@
+package commit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// RepeatThreshold is the similarity (0-1) above which a description counts
+// as a repeat of an earlier commit subject.
+const RepeatThreshold = 0.85
+
+// subjectPrefix matches the "TICKET [type]" or "type(scope):" head of a
+// subject so only the description itself is compared.
+var subjectPrefix = regexp.MustCompile(`^(?:\S+\s+)?\[[^\]]+\]\s*|^[a-z]+(?:\([^)]*\))?!?:\s*`)
+
+// Repeats returns the first of the previous subjects whose description is
+// nearly identical to description, or "" when it is fresh enough.
+func Repeats(description string, previous []string) string {
+	d := comparable(description, false)
+	if d == "" {
+		return ""
+	}
+	for _, subject := range previous {
+		s := comparable(subject, true)
+		if s == "" {
+			continue
+		}
+		if Similarity(d, s) >= RepeatThreshold {
+			return subject
+		}
+	}
+	return ""
+}
+
+// RepeatViolation is the lint violation reported for a repeated description.
+func RepeatViolation(subject string) Violation {
+	return Violation{Rule: "repeat", Message: fmt.Sprintf("description nearly repeats the recent commit %q; say specifically what this change does differently", subject)}
+}
+
+// Similarity scores a and b between 0 (unrelated) and 1 (identical) by edit
+// distance relative to the longer string.
+func Similarity(a, b string) float64 {
+	longest := max(len([]rune(a)), len([]rune(b)))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(util.EditDistance(a, b))/float64(longest)
+}
+
+func comparable(s string, subject bool) string {
+	s = strings.TrimSpace(s)
+	if subject {
+		s = subjectPrefix.ReplaceAllString(s, "")
+	}
+	return strings.TrimRight(strings.ToLower(util.CondenseSpaces(s)), ".")
+}
@@ -0,0 +1,41 @@
+package commit
+
+// JSONSchema describes Parts for providers with constrained decoding
+// (Ollama's structured outputs), restricting commit_type to the taxonomy.
+func (c Conventions) JSONSchema() map[string]interface{} {
+	str := func(extra map[string]interface{}) map[string]interface{} {
+		prop := map[string]interface{}{"type": "string"}
+		for k, v := range extra {
+			prop[k] = v
+		}
+		return prop
+	}
+
+	properties := map[string]interface{}{
+		"commit_type": str(map[string]interface{}{"enum": c.AllowedTypes()}),
+		"description": str(map[string]interface{}{"maxLength": c.DescriptionLimit()}),
+		"summary":     str(map[string]interface{}{"maxLength": c.SummaryLimit()}),
+	}
+	if len(c.Scopes) > 0 {
+		properties["scope"] = str(map[string]interface{}{"enum": append(append([]string{}, c.Scopes...), "")})
+	}
+	required := []string{"commit_type", "description", "summary"}
+	if c.Sections {
+		for _, s := range BodySections {
+			properties[s.Key] = str(map[string]interface{}{"maxLength": sectionLimit})
+			required = append(required, s.Key)
+		}
+	} else if c.NoBody {
+		properties["body"] = str(map[string]interface{}{"maxLength": 0})
+		required = append(required, "body")
+	} else {
+		properties["body"] = str(map[string]interface{}{"maxLength": c.BodyLimit()})
+		required = append(required, "body")
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
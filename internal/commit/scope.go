@@ -0,0 +1,72 @@
+package commit
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// scopeMatch returns the allowed scope scope stands for: an exact or
+// case-insensitive match, one containing the other (e.g. "parsers" for
+// "parser"), or the closest spelling within a third of its length. ""
+// means no allowed scope is close enough.
+func (c Conventions) scopeMatch(scope string) string {
+	scope = strings.ToLower(strings.TrimSpace(scope))
+	if scope == "" {
+		return ""
+	}
+	for _, s := range c.Scopes {
+		if strings.ToLower(s) == scope {
+			return s
+		}
+	}
+	for _, s := range c.Scopes {
+		lower := strings.ToLower(s)
+		if strings.Contains(scope, lower) || strings.Contains(lower, scope) {
+			return s
+		}
+	}
+	best, bestDistance := "", utf8.RuneCountInString(scope)/3+1
+	for _, s := range c.Scopes {
+		if d := util.EditDistance(scope, strings.ToLower(s)); d < bestDistance {
+			best, bestDistance = s, d
+		}
+	}
+	return best
+}
+
+// validScope reports whether scope may be used as-is: any scope when no
+// list is configured, otherwise "" or a listed one.
+func (c Conventions) validScope(scope string) bool {
+	scope = strings.TrimSpace(scope)
+	if len(c.Scopes) == 0 || scope == "" {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// lintScope reports a scope outside the configured list, unless
+// mismatches are mapped to the nearest scope instead of regenerated.
+func (c Conventions) lintScope(p Parts) []Violation {
+	if !c.ScopeRetry || c.validScope(p.Scope) {
+		return nil
+	}
+	return []Violation{{Rule: "scope", Message: fmt.Sprintf("scope %q is not one of %s; use one of them or leave it empty", p.Scope, strings.Join(c.Scopes, ", "))}}
+}
+
+// normaliseScope maps the scope onto the configured list, dropping it when
+// nothing is close.
+func (c Conventions) normaliseScope(scope string) string {
+	scope = strings.TrimSpace(scope)
+	if len(c.Scopes) == 0 || c.validScope(scope) {
+		return scope
+	}
+	return c.scopeMatch(scope)
+}
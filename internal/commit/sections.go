@@ -0,0 +1,71 @@
+package commit
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// Section is a fixed heading of a sectioned body and the Parts field the
+// model fills for it.
+type Section struct {
+	Heading string
+	Key     string
+	value   func(Parts) string
+}
+
+// BodySections are the headings of a body written with Conventions.Sections.
+var BodySections = []Section{
+	{Heading: "What", Key: "what", value: func(p Parts) string { return p.What }},
+	{Heading: "Why", Key: "why", value: func(p Parts) string { return p.Why }},
+	{Heading: "How to test", Key: "how_to_test", value: func(p Parts) string { return p.HowToTest }},
+}
+
+const (
+	sectionLimit = 300
+	wrapWidth    = 72
+)
+
+// lintSections reports every missing or overlong section.
+func lintSections(p Parts) []Violation {
+	var out []Violation
+	for _, s := range BodySections {
+		text := strings.TrimSpace(s.value(p))
+		if text == "" {
+			out = append(out, Violation{Rule: "section", Message: fmt.Sprintf("%s is missing", s.Key)})
+			continue
+		}
+		if n := utf8.RuneCountInString(text); n > sectionLimit {
+			out = append(out, Violation{Rule: "section", Message: fmt.Sprintf("%s is %d characters, limit is %d", s.Key, n, sectionLimit)})
+		}
+	}
+	return out
+}
+
+func normaliseSections(p Parts) Parts {
+	clean := func(s string) string {
+		s = util.CondenseSpaces(strings.TrimSpace(s))
+		if utf8.RuneCountInString(s) > sectionLimit {
+			s = util.TruncateShorten(s, sectionLimit)
+		}
+		return s
+	}
+	p.What, p.Why, p.HowToTest = clean(p.What), clean(p.Why), clean(p.HowToTest)
+	return p
+}
+
+// sectionBody lays the sections out under their headings, wrapped at 72
+// columns. It returns "" when the model filled none of them.
+func sectionBody(p Parts) string {
+	var blocks []string
+	for _, s := range BodySections {
+		text := util.CondenseSpaces(strings.TrimSpace(s.value(p)))
+		if text == "" {
+			continue
+		}
+		blocks = append(blocks, s.Heading+":\n"+util.Wrap(text, wrapWidth))
+	}
+	return strings.Join(blocks, "\n\n")
+}
@@ -0,0 +1,226 @@
+package commit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Style holds the wording and character-set rules applied to the finished
+// message. Apply fixes what it can and reports every fix, so a rewritten
+// headline never goes unnoticed.
+type Style struct {
+	// Imperative rewrites a description starting with a past, third-person
+	// or -ing form of a common verb ("added", "fixes") to the imperative.
+	// It is opt-in: an -ing form is sometimes a noun ("building").
+	Imperative bool
+	// KeepPeriod keeps punctuation ending the headline, which is dropped
+	// by default.
+	KeepPeriod bool
+	// Case is the capitalisation of the description's first letter:
+	// "lower" (also the zero value), "upper" or "any".
+	Case string
+	// NoEmoji removes emoji and :shortcode: emoji from the message.
+	NoEmoji bool
+	// BodyWidth wraps body lines longer than this many characters; zero
+	// keeps them.
+	BodyWidth int
+}
+
+// imperativeVerbs are the verbs whose inflected forms Imperative rewrites.
+var imperativeVerbs = []string{
+	"add", "adjust", "allow", "avoid", "build", "bump", "change", "clean", "convert", "create",
+	"delete", "disable", "document", "drop", "enable", "ensure", "expose", "extract", "fix",
+	"handle", "implement", "improve", "increase", "introduce", "make", "merge", "move", "prevent",
+	"reduce", "refactor", "remove", "rename", "replace", "return", "revert", "rewrite", "run",
+	"simplify", "split", "stop", "strip", "support", "switch", "update", "upgrade", "use",
+	"validate", "wrap", "write",
+}
+
+// irregularForms are the inflections the suffix rules get wrong.
+var irregularForms = map[string]string{
+	"built": "build", "made": "make", "ran": "run", "rewrote": "rewrite", "rewritten": "rewrite",
+	"wrote": "write", "written": "write",
+	"dropped": "drop", "dropping": "drop", "stopped": "stop", "stopping": "stop",
+	"stripped": "strip", "stripping": "strip", "wrapped": "wrap", "wrapping": "wrap",
+	"running": "run", "splitting": "split",
+}
+
+// imperative maps every inflected form of imperativeVerbs to its base.
+var imperative = func() map[string]string {
+	forms := map[string]string{}
+	for _, verb := range imperativeVerbs {
+		stem, last := verb[:len(verb)-1], verb[len(verb)-1]
+		switch {
+		case strings.HasSuffix(verb, "y") && !strings.ContainsRune("aeiou", rune(stem[len(stem)-1])):
+			forms[stem+"ies"], forms[stem+"ied"], forms[verb+"ing"] = verb, verb, verb
+		case last == 'e':
+			forms[verb+"s"], forms[verb+"d"], forms[stem+"ing"] = verb, verb, verb
+		case strings.HasSuffix(verb, "s") || strings.HasSuffix(verb, "x") || strings.HasSuffix(verb, "ch") || strings.HasSuffix(verb, "sh"):
+			forms[verb+"es"], forms[verb+"ed"], forms[verb+"ing"] = verb, verb, verb
+		default:
+			forms[verb+"s"], forms[verb+"ed"], forms[verb+"ing"] = verb, verb, verb
+		}
+	}
+	for form, verb := range irregularForms {
+		forms[form] = verb
+	}
+	return forms
+}()
+
+var shortcodePattern = regexp.MustCompile(`(^|\s):[a-z0-9_+-]+:`)
+
+// isEmoji reports pictographs, dingbats, flags and the joiners and
+// variation selectors that combine them.
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF, r >= 0x2600 && r <= 0x27BF, r >= 0x2B00 && r <= 0x2BFF,
+		r >= 0xFE00 && r <= 0xFE0F, r == 0x200D, r == 0x20E3:
+		return true
+	}
+	return false
+}
+
+// Apply fixes msg according to the rules and returns the fixes it made as
+// violations of the rule each fix satisfies. The description is the text
+// after the "[type]" of the headline, or all of it without one.
+func (s Style) Apply(msg Message) (Message, []Violation) {
+	var fixes []Violation
+	prefix, description := SplitHeadline(msg.Headline)
+
+	if s.NoEmoji {
+		if clean := stripEmoji(description); clean != description {
+			fixes = append(fixes, Violation{Rule: "emoji", Message: "removed emoji from the headline"})
+			description = clean
+		}
+		if clean := stripEmoji(msg.Body); clean != msg.Body {
+			fixes = append(fixes, Violation{Rule: "emoji", Message: "removed emoji from the body"})
+			msg.Body = clean
+		}
+	}
+
+	if trimmed := strings.TrimRight(description, ".;:, "); !s.KeepPeriod && trimmed != description && trimmed != "" {
+		fixes = append(fixes, Violation{Rule: "period", Message: fmt.Sprintf("removed trailing %q from the headline", strings.TrimSpace(description[len(trimmed):]))})
+		description = trimmed
+	}
+
+	if s.Imperative {
+		word, rest, _ := strings.Cut(description, " ")
+		if verb, ok := imperative[strings.ToLower(word)]; ok {
+			if r, _ := utf8.DecodeRuneInString(word); unicode.IsUpper(r) {
+				verb = strings.ToUpper(verb[:1]) + verb[1:]
+			}
+			fixes = append(fixes, Violation{Rule: "imperative", Message: fmt.Sprintf("%q rewritten as %q", word, verb)})
+			description = strings.TrimSpace(verb + " " + rest)
+		}
+	}
+
+	if fixed := s.fixCase(description); fixed != description {
+		fixes = append(fixes, Violation{Rule: "case", Message: fmt.Sprintf("description now starts with %s case", s.caseName())})
+		description = fixed
+	}
+
+	if s.BodyWidth > 0 {
+		if wrapped := wrapBody(msg.Body, s.BodyWidth); wrapped != msg.Body {
+			fixes = append(fixes, Violation{Rule: "body-width", Message: fmt.Sprintf("wrapped body lines at %d characters", s.BodyWidth)})
+			msg.Body = wrapped
+		}
+	}
+
+	msg.Headline = prefix + description
+	return msg, fixes
+}
+
+// LintCase reports a description whose first letter breaks the case policy.
+func (s Style) LintCase(description string) []Violation {
+	if s.fixCase(description) == description {
+		return nil
+	}
+	return []Violation{{Rule: "description", Message: "description must start with a " + s.caseName() + " case letter"}}
+}
+
+func (s Style) caseName() string {
+	if s.Case == "" {
+		return "lower"
+	}
+	return s.Case
+}
+
+// fixCase applies the case policy to the first letter, leaving acronyms
+// ("API", "CI") alone when lower case is wanted.
+func (s Style) fixCase(description string) string {
+	r, size := utf8.DecodeRuneInString(description)
+	word, _, _ := strings.Cut(description, " ")
+	switch s.caseName() {
+	case "lower":
+		if unicode.IsUpper(r) && strings.ToUpper(word) != word {
+			return string(unicode.ToLower(r)) + description[size:]
+		}
+	case "upper":
+		if unicode.IsLower(r) {
+			return string(unicode.ToUpper(r)) + description[size:]
+		}
+	}
+	return description
+}
+
+// SplitHeadline separates "TICKET [type] " from the description.
+func SplitHeadline(headline string) (prefix, description string) {
+	if i := strings.Index(headline, "] "); i != -1 {
+		return headline[:i+2], headline[i+2:]
+	}
+	return "", headline
+}
+
+func stripEmoji(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		clean := strings.Map(func(r rune) rune {
+			if isEmoji(r) {
+				return -1
+			}
+			return r
+		}, shortcodePattern.ReplaceAllString(line, "$1"))
+		if clean != line {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			lines[i] = indent + strings.Join(strings.Fields(clean), " ")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapBody breaks lines longer than width on spaces. Continuations keep
+// the line's indentation, so list items and code stay in place, and a
+// "- " bullet's continuation is indented under its text.
+func wrapBody(body string, width int) string {
+	lines := strings.Split(body, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if utf8.RuneCountInString(line) <= width {
+			out = append(out, line)
+			continue
+		}
+		text := strings.TrimLeft(line, " \t")
+		lead := line[:len(line)-len(text)]
+		indent := lead
+		if strings.HasPrefix(text, "- ") || strings.HasPrefix(text, "* ") {
+			indent += "  "
+		}
+		current := ""
+		for _, word := range strings.Fields(text) {
+			switch {
+			case current == "":
+				current = lead + word
+			case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width:
+				out = append(out, current)
+				current = indent + word
+			default:
+				current += " " + word
+			}
+		}
+		out = append(out, current)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,128 @@
+package commit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+var trailerLine = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*: \S`)
+
+// Trailers returns the git trailers ("Signed-off-by: ...", "Change-Id: ...")
+// of a commit body: the lines of its last paragraph, when every line of that
+// paragraph is a trailer.
+func Trailers(body string) []string {
+	paragraphs := strings.Split(strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n")), "\n\n")
+	last := strings.TrimSpace(paragraphs[len(paragraphs)-1])
+	if last == "" {
+		return nil
+	}
+	lines := strings.Split(last, "\n")
+	for _, line := range lines {
+		if !trailerLine.MatchString(line) {
+			return nil
+		}
+	}
+	return lines
+}
+
+// WithTrailers appends the trailers missing from msg's body to its trailer
+// block, or as a final paragraph when it has none.
+func WithTrailers(msg Message, trailers []string) Message {
+	var missing []string
+	for _, t := range trailers {
+		if !strings.Contains(msg.Body, t) {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) == 0 {
+		return msg
+	}
+	block := strings.Join(missing, "\n")
+	switch {
+	case strings.TrimSpace(msg.Body) == "":
+		msg.Body = block
+	case Trailers(msg.Body) != nil:
+		msg.Body = strings.TrimRight(msg.Body, "\n") + "\n" + block
+	default:
+		msg.Body = strings.TrimRight(msg.Body, "\n") + "\n\n" + block
+	}
+	return msg
+}
+
+// WithSection adds paragraph to msg's body, before its trailer block when
+// it has one.
+func WithSection(msg Message, paragraph string) Message {
+	paragraph = strings.TrimSpace(paragraph)
+	if paragraph == "" {
+		return msg
+	}
+	body := strings.TrimSpace(strings.ReplaceAll(msg.Body, "\r\n", "\n"))
+	var trailers string
+	if Trailers(body) != nil {
+		if i := strings.LastIndex(body, "\n\n"); i >= 0 {
+			body, trailers = strings.TrimSpace(body[:i]), body[i+2:]
+		} else {
+			body, trailers = "", body
+		}
+	}
+	parts := make([]string, 0, 3)
+	for _, p := range []string{body, paragraph, trailers} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	msg.Body = strings.Join(parts, "\n\n")
+	return msg
+}
+
+// Signoff returns the Developer Certificate of Origin trailer for ident
+// ("Name <email>").
+func Signoff(ident string) string {
+	return "Signed-off-by: " + ident
+}
+
+// LintSignoff reports a body whose trailers lack the Signed-off-by line of
+// ident.
+func LintSignoff(body, ident string) []Violation {
+	for _, t := range Trailers(body) {
+		if t == Signoff(ident) {
+			return nil
+		}
+	}
+	return []Violation{{Rule: "signoff", Message: "missing " + Signoff(ident) + " trailer"}}
+}
+
+var trailerKey = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*:`)
+
+// ParseTrailer parses a trailer template such as
+// "Build: {{.Env.GITHUB_RUN_ID}}". Missing map keys render empty.
+func ParseTrailer(text string) (*template.Template, error) {
+	if !trailerKey.MatchString(text) {
+		return nil, fmt.Errorf("trailer %q must start with a key such as \"Build:\"", text)
+	}
+	return template.New("trailer").Option("missingkey=zero").Parse(text)
+}
+
+// RenderTrailers executes the trailer templates with data. A trailer
+// whose value comes out empty is left out, so a template naming CI
+// variables adds nothing outside CI.
+func RenderTrailers(templates []string, data interface{}) ([]string, error) {
+	var out []string
+	for _, text := range templates {
+		t, err := ParseTrailer(text)
+		if err != nil {
+			return nil, err
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("trailer %q: %w", text, err)
+		}
+		line := strings.Join(strings.Fields(b.String()), " ")
+		if key, value, _ := strings.Cut(line, ":"); strings.TrimSpace(value) != "" {
+			out = append(out, key+": "+strings.TrimSpace(value))
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Settings lists every flag with the value in effect, marking the ones
+// changed from their default by the command line, environment or config
+// file, for `debug capture` bundles. Values of secret flags are masked;
+// pass Secrets to the redaction as well, since they may appear elsewhere.
+func (o Options) Settings() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "command: %s\nprofile: %s\n\n", stringsFallback(o.Command, "(default)"), stringsFallback(o.Profile, "(none)"))
+	if o.RawFlagSet == nil {
+		return b.String()
+	}
+	var lines []string
+	o.RawFlagSet.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if secretFlags[f.Name] && value != "" && value != "[]" && value != "map[]" {
+			value = "[REDACTED]"
+		}
+		mark := ""
+		if value != f.DefValue {
+			mark = " (set)"
+		}
+		lines = append(lines, fmt.Sprintf("%s = %s%s", f.Name, value, mark))
+	})
+	sort.Strings(lines)
+	b.WriteString(strings.Join(lines, "\n") + "\n")
+	return b.String()
+}
+
+// secretFlags hold credentials or carry them in their values; an incoming
+// webhook URL is its own credential.
+var secretFlags = map[string]bool{"api-key": true, "header": true, "webhook-url": true}
+
+// Secrets returns the credential values of o (API key, header values,
+// webhook URL) so they can be redacted wherever they show up.
+func (o Options) Secrets() []string {
+	secrets := []string{o.APIKey, o.WebhookURL}
+	for _, v := range o.Headers {
+		secrets = append(secrets, v)
+	}
+	return secrets
+}
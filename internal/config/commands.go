@@ -0,0 +1,489 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Command describes a subcommand: its own flags, help text and examples.
+type Command struct {
+	Name    string
+	Summary string
+	// Usage is the synopsis after the program name.
+	Usage       string
+	Description string
+	// Flags lists the flags the command accepts besides GlobalFlags.
+	Flags    []string
+	Examples []Example
+}
+
+// Example is a worked example shown in help output and the man page.
+type Example struct {
+	Description string
+	Command     string
+}
+
+// GlobalFlags configure the model connection and apply to every command.
+var GlobalFlags = []string{
+	"config", "profile", "model", "review-model", "endpoint", "api", "api-key", "header",
+	"ca-file", "client-cert", "client-key", "insecure-skip-verify", "format", "strip-thinking", "modelfile-defaults",
+	"timeout", "llm-idle-timeout", "git-timeout", "rate-limit", "max-concurrent", "max-idle-conns", "temperature", "top-p", "num-predict", "seed", "llm-option", "vcs",
+	"log-level", "log-format", "log-file", "otlp-endpoint", "cache", "cache-ttl", "cache-dir", "update-check",
+}
+
+// generateFlags tune how a change is described; every command that writes
+// commit messages accepts them.
+var generateFlags = []string{
+	"max-bytes", "lint-retries", "summarize-large", "ignore-whitespace", "similarity",
+	"move-min-lines", "post-process", "issue-keyword", "issue-keywords", "types", "type-aliases",
+	"denylist", "deny-action", "sections", "drop-redundant-body", "scopes", "scope-action", "few-shot", "examples-file", "repo-context",
+	"no-body", "max-headline", "max-description", "max-summary", "max-body",
+	"imperative", "keep-period", "headline-case", "no-emoji", "body-width", "polish", "polish-model", "tone", "noise", "minify-diff", "strict", "repair-json", "require-signoff",
+	"models", "judge-model", "critic", "critic-model", "critic-threshold", "critic-retries",
+	"findings-in-body", "reuse-context", "auto-models", "gpu-memory", "trailer", "blame-context",
+}
+
+// Commands lists the subcommands accepted as the first argument. The one
+// without a name is the default generate-and-commit flow.
+var Commands = []Command{
+	{
+		Summary:     "Review the staged changes and commit them with a generated message",
+		Usage:       "[flags] [-- pathspec...]",
+		Description: "Reads the staged diff, optionally reviews it, asks the model for a conventional commit message and runs git commit. Pathspecs after -- commit only those files, as they are in the working tree.",
+		Flags: append([]string{
+			"commit", "review", "hook", "hook-source", "allow-empty", "context", "intent-markers",
+			"strip-markers", "porcelain", "stdio", "keep-alive", "metrics-addr", "history", "repeat-check",
+			"include-untracked", "untracked-max-bytes", "diff-file", "stats", "stats-file",
+			"no-review-on-small-diffs", "small-diff-bytes", "small-review-model", "large-diff-bytes",
+			"escalation-model", "linters", "linter", "go-symbols", "record-examples", "force",
+			"offline-fallback", "copy", "notify", "notify-after", "review-create-issues", "issue-labels", "forge", "emit",
+			"webhook-url", "webhook-template",
+		}, generateFlags...),
+		Examples: []Example{
+			{"Review, then commit the staged changes", "go-commitgen --review"},
+			{"Only print the message, explaining why the change was made", `go-commitgen --commit=false --context "pgx is faster for COPY"`},
+			{"Commit two files as they are, leaving the rest of the index alone", "go-commitgen -- cmd/main.go internal/api"},
+			{"Describe a patch without a checkout", "git format-patch -1 --stdout | go-commitgen --diff-file -"},
+		},
+	},
+	{
+		Name:        "tui",
+		Summary:     "Full-screen interface: diff, candidates and review side by side",
+		Usage:       "tui [flags]",
+		Description: "Shows the staged diff, the generated message and the review findings in three panes. Keys: tab switches pane, arrows or j/k scroll, left/right pick a candidate, r regenerates, f regenerates with feedback, e opens the message in $GIT_EDITOR/$VISUAL/$EDITOR, t cycles the commit type, c or enter commits and q quits. Needs a Unix terminal.",
+		Flags: append([]string{
+			"review", "context", "intent-markers", "history", "repeat-check", "include-untracked",
+			"untracked-max-bytes", "linters", "linter", "go-symbols", "review-create-issues", "issue-labels", "forge",
+		}, generateFlags...),
+		Examples: []Example{
+			{"Review and pick a message interactively", "go-commitgen tui --review"},
+		},
+	},
+	{
+		Name:        "bot",
+		Summary:     "Commit unattended and report the outcome as JSON (Renovate, Dependabot)",
+		Usage:       "bot [--context TEXT] [--author \"Name <email>\"]",
+		Description: "Generates a message for the staged changes and commits them (unless --commit=false) without the review, the critic or anything that needs a terminal. Sampling defaults to temperature 0 and seed 0, so the same diff gets the same message; --temperature, --seed or --llm-option override them. --author records the commit (and the sign-off) as a bot identity without touching the git config. stdout is one JSON object: headline, body, committed, offline, violations, error and exitCode; git's own output goes to stderr. Exit status: 0 done, 1 failed, 2 invalid flags or config, 3 nothing staged, 4 model unreachable.",
+		Flags: append([]string{
+			"commit", "context", "author", "allow-empty", "include-untracked", "untracked-max-bytes",
+			"diff-file", "go-symbols", "offline-fallback", "webhook-url", "webhook-template",
+		}, generateFlags...),
+		Examples: []Example{
+			{"Renovate postUpgradeTasks", `git add -A && go-commitgen bot --context "renovate bump" --author "renovate[bot] <bot@renovateapp.com>"`},
+			{"Only get the message", `go-commitgen bot --commit=false --context "dependabot bump" | jq -r .headline`},
+		},
+	},
+	{
+		Name:        "from-issue",
+		Summary:     "Commit the staged changes against an issue, built around its description",
+		Usage:       "from-issue [flags] <#12|PROJ-123>",
+		Description: "The issue-first workflow: reads the issue from the forge of the origin remote (numbers, see --forge) or from Jira (ticket keys, see --jira-url), prints its title and link, and generates the message for the staged changes with the issue as the author's intent. The issue becomes the ticket of the headline, whatever the branch is called, and gets the issue keyword of the commit type (--issue-keywords, by default Fixes for fixes and Refs for features). --context adds to the issue's description.",
+		Flags: append([]string{
+			"commit", "review", "context", "intent-markers", "porcelain", "history", "repeat-check",
+			"include-untracked", "untracked-max-bytes", "go-symbols", "force", "offline-fallback", "copy", "notify",
+			"forge", "jira-url", "emit", "webhook-url", "webhook-template",
+		}, generateFlags...),
+		Examples: []Example{
+			{"Commit a fix for a GitHub issue", "go-commitgen from-issue 482"},
+			{"Commit against a Jira ticket", "JIRA_USER=me@example.com JIRA_API_TOKEN=... go-commitgen from-issue --jira-url https://example.atlassian.net PROJ-123"},
+		},
+	},
+	{
+		Name:        "msg",
+		Summary:     "Print only the headline, for git commit -m \"$(go-commitgen msg)\"",
+		Usage:       "msg [flags]",
+		Description: "The fast path for scripts and aliases: generates a message for the staged changes without the review, the critic or a commit, and prints the headline alone on stdout, with no decoration. Warnings and errors go to stderr and a failure exits non-zero with nothing on stdout, so a failed run cannot become a commit message.",
+		Flags: append([]string{
+			"context", "intent-markers", "history", "repeat-check", "include-untracked", "untracked-max-bytes",
+			"diff-file", "go-symbols", "offline-fallback",
+		}, generateFlags...),
+		Examples: []Example{
+			{"Commit with a generated headline", `git commit -m "$(go-commitgen msg)"`},
+			{"A git alias", `git config --global alias.cm '!git commit -m "$(go-commitgen msg)"'`},
+		},
+	},
+	{
+		Name:        "review",
+		Summary:     "Review the staged changes, or the whole branch with --against, without committing",
+		Usage:       "review [--against origin/main]",
+		Description: "Runs only the AI review. With --against it reviews everything the branch changes since its merge base with the given ref, fetching a remote branch that is not known locally, so it can be used as a pre-PR check.",
+		Flags: []string{
+			"against", "post-to-pr", "forge", "max-bytes", "ignore-whitespace", "similarity", "move-min-lines", "noise", "minify-diff", "include-untracked",
+			"untracked-max-bytes", "porcelain", "no-review-on-small-diffs", "small-diff-bytes",
+			"small-review-model", "large-diff-bytes", "escalation-model", "linters", "linter",
+		},
+		Examples: []Example{
+			{"Review what is staged", "go-commitgen review"},
+			{"Check a branch before opening a pull request", "go-commitgen review --against origin/main"},
+			{"Comment on the branch's pull request", "GITHUB_TOKEN=... go-commitgen review --post-to-pr"},
+		},
+	},
+	{
+		Name:        "push-check",
+		Summary:     "Review the commits a push sends and stop it on severe findings (pre-push hook)",
+		Usage:       "push-check [remote [url]] < refs",
+		Description: "Reads the refs being pushed from stdin as git's pre-push hook receives them, lists the outgoing commits of each and reviews their combined changes: everything after the remote's commit, or after the merge base with --trunk on the remote for a new branch. The push fails when a finding is tagged --block-on or higher (default high). A review that cannot run only prints a warning, so an unreachable model never blocks a push. Install it with install-hook --pre-push.",
+		Flags: []string{
+			"block-on", "trunk", "max-bytes", "ignore-whitespace", "similarity", "move-min-lines", "noise",
+			"minify-diff", "no-review-on-small-diffs", "small-diff-bytes", "small-review-model", "large-diff-bytes",
+			"escalation-model", "linters", "linter",
+		},
+		Examples: []Example{
+			{"Run as git runs the hook", "echo \"refs/heads/topic $(git rev-parse HEAD) refs/heads/topic $(git rev-parse origin/topic)\" | go-commitgen push-check origin"},
+			{"Only stop pushes on high and medium findings", "go-commitgen push-check --block-on medium origin"},
+		},
+	},
+	{
+		Name:        "pr",
+		Summary:     "Write the title and description of the branch's pull request, and post it with --post-to-pr",
+		Usage:       "pr [--against origin/main] [--post-to-pr] [--forge auto|github|gitlab|gitea]",
+		Description: "Describes everything the branch changes since its merge base with --against (default: the open pull request's base on origin, else origin/main). With --post-to-pr the open pull or merge request is updated, or a new one is opened.",
+		Flags:       []string{"against", "post-to-pr", "forge", "max-bytes", "summarize-large", "minify-diff", "ignore-whitespace", "similarity"},
+		Examples: []Example{
+			{"Preview the description", "go-commitgen pr"},
+			{"Open or update the merge request on GitLab", "GITLAB_TOKEN=... go-commitgen pr --post-to-pr --forge gitlab"},
+		},
+	},
+	{
+		Name:        "doctor",
+		Summary:     "Check git, the repository, hooks, endpoint, models and forge token",
+		Usage:       "doctor [--forge auto|github|gitlab|gitea]",
+		Description: "Checks that git is installed and new enough, the repository has no unresolved conflicts and a committer identity, the hooks are installed and will run, the endpoint answers with the configured credentials, the generation and review models are pulled and the forge token (when one is set) is accepted. Every failed check comes with the command or setting that fixes it; the exit status is non-zero when any check failed.",
+		Flags:       []string{"forge"},
+		Examples: []Example{
+			{"Find out why commits fail", "go-commitgen doctor"},
+			{"Check a remote endpoint and its token", "go-commitgen doctor --endpoint https://llm.acme.internal"},
+		},
+	},
+	{
+		Name:        "whoami",
+		Summary:     "Show the detected forge and project and check the configured token",
+		Usage:       "whoami [--forge auto|github|gitlab|gitea]",
+		Description: "Parses the origin remote, detects the forge from its host name (probing the API of self-hosted instances with a neutral name) and asks the forge which account the token belongs to.",
+		Flags:       []string{"forge"},
+		Examples:    []Example{{"Check the setup before --post-to-pr", "go-commitgen whoami"}},
+	},
+	{
+		Name:        "index",
+		Summary:     "Embed descriptions of the repository's modules for --repo-context",
+		Usage:       "index [--embed-model name]",
+		Description: "Describes every directory (Go package comment and exported names, README paragraph or file names), embeds the descriptions with an Ollama embedding model and stores them in the user cache directory. Rerun it after larger changes; unchanged descriptions are not embedded again.",
+		Flags:       []string{"embed-model"},
+		Examples:    []Example{{"Index the repository, then use three module notes per commit", "ollama pull nomic-embed-text && go-commitgen index && go-commitgen --repo-context 3"}},
+	},
+	{
+		Name:        "stats",
+		Summary:     "Print aggregates of recorded generations",
+		Usage:       "stats [--stats-file path]",
+		Description: "Summarises the runs recorded with --stats per model: acceptance rate, verbatim rate, latency and edit distance.",
+		Flags:       []string{"stats-file"},
+		Examples:    []Example{{"Show acceptance per model", "go-commitgen stats"}},
+	},
+	{
+		Name:        "log-summary",
+		Summary:     "Summarise a commit range (e.g. main..HEAD) for standups or release emails",
+		Usage:       "log-summary [--style bullets|paragraph] <range>",
+		Description: "Condenses the commits of a range into bullets or a narrative paragraph.",
+		Flags:       []string{"style", "max-bytes"},
+		Examples: []Example{
+			{"What the branch did, as bullets", "go-commitgen log-summary main..HEAD"},
+			{"Yesterday's work as a paragraph", `go-commitgen log-summary --style paragraph "@{yesterday}..HEAD"`},
+		},
+	},
+	{
+		Name:        "bench",
+		Summary:     "Score models against human-written messages of a corpus of diffs",
+		Usage:       "bench --dataset dir [--models a,b,c] [--bench-history file]",
+		Description: "Replays every NAME.diff of --dataset through the generation pipeline (without the review, the cache or a commit) once per model of --models (default --model) and scores the message against the human-written NAME.msg: ROUGE-L of the descriptions, cosine similarity of the --embed-model embeddings of the whole messages, and how often the commit type matches. Prints one table row per model; with --bench-history the rows are appended to that JSONL file and earlier runs, e.g. of other prompt versions, are listed above them.",
+		Flags:       append([]string{"dataset", "bench-history", "embed-model", "go-symbols"}, generateFlags...),
+		Examples: []Example{
+			{"Compare three models", "go-commitgen bench --dataset testdata/bench --models qwen2.5-coder:1.5b,qwen2.5-coder:7b,llama3.1:8b"},
+			{"Track a model across releases", "go-commitgen bench --dataset testdata/bench --bench-history bench.jsonl"},
+		},
+	},
+	{
+		Name:        "standup",
+		Summary:     "Summarise your recent commits across repositories for a standup",
+		Usage:       "standup [--since yesterday] [--author me] [--repos dir,...]",
+		Description: "Reads the commits --author (default me: the user.email configured in each repository; otherwise a `git log --author` pattern) made on any branch since --since (default yesterday, any date git understands) in every repository of --repos (default the current one) and writes a short standup update: what got done, grouped by outcome, with the repository named when there are several. List the repositories once in the config file with `repos = [\"~/src/api\", \"~/src/web\"]`.",
+		Flags:       []string{"since", "author", "repos", "max-bytes"},
+		Examples: []Example{
+			{"What you did since yesterday", "go-commitgen standup"},
+			{"Monday's standup across two repositories", `go-commitgen standup --since "last friday" --repos ~/src/api,~/src/web`},
+			{"A teammate's week", `go-commitgen standup --since "1 week ago" --author alice@example.com`},
+		},
+	},
+	{
+		Name:        "explain",
+		Summary:     "Explain in plain language what a past change did and why",
+		Usage:       "explain [--file path] [commit|range|file]",
+		Description: "For code archaeology: reads the messages and diff of a commit (default HEAD), of every commit in a range such as v1.2.0..v1.3.0, or of the recent commits touching a file, and explains what changed, why (from the messages, or marked as inferred from the code) and what a reader should watch out for. --file limits a commit or range to one path. Large changes are trimmed to --max-bytes or summarised first with --summarize-large.",
+		Flags:       []string{"file", "max-bytes", "summarize-large", "minify-diff", "ignore-whitespace", "similarity"},
+		Examples: []Example{
+			{"Explain the last commit", "go-commitgen explain"},
+			{"What a commit did to one file", "go-commitgen explain --file internal/auth/session.go 1a2b3c4"},
+			{"How a file came to be", "go-commitgen explain internal/auth/session.go"},
+		},
+	},
+	{
+		Name:        "release-notes",
+		Summary:     "Write markdown release notes for the commits since --since, for --audience users or developers",
+		Usage:       "release-notes --since <tag> [--audience users|developers]",
+		Description: "Writes release notes from the commits and the combined diff since a tag: user-facing highlights or a detailed developer change log.",
+		Flags:       []string{"since", "audience", "max-bytes", "summarize-large", "minify-diff", "ignore-whitespace", "similarity"},
+		Examples: []Example{
+			{"Notes for users since the last release", "go-commitgen release-notes --since v1.4.0"},
+			{"Internal change log", "go-commitgen release-notes --since v1.4.0 --audience developers"},
+		},
+	},
+	{
+		Name:        "stack",
+		Summary:     "Regenerate and number the messages of the commits between --trunk and HEAD",
+		Usage:       "stack [--trunk main] [--commit=false]",
+		Description: "Rewrites every commit of a stacked branch with a fresh, numbered message through amend! commits and an autosquash rebase, keeping trailers and moving stacked branches along.",
+		Flags:       append([]string{"trunk", "commit"}, generateFlags...),
+		Examples: []Example{
+			{"Preview the new messages", "go-commitgen stack --commit=false"},
+			{"Reword a stack based on develop", "go-commitgen stack --trunk develop"},
+		},
+	},
+	{
+		Name:        "fixup",
+		Summary:     "Record the staged changes as a fixup! commit for the commit they amend",
+		Usage:       "fixup [--trunk main] [--yes] [--commit=false]",
+		Description: "Ranks the commits between --trunk and HEAD by how many of the lines the staged hunks replace they last changed (git blame), then by the staged files they touched, asks for confirmation and runs `git commit --fixup` for the chosen one. Fold it in later with `git rebase -i --autosquash`. With --commit=false the candidates are only listed.",
+		Flags:       []string{"trunk", "commit", "yes"},
+		Examples: []Example{
+			{"Amend the commit a review comment was about", "git add -p && go-commitgen fixup"},
+			{"List the candidates only", "go-commitgen fixup --commit=false"},
+		},
+	},
+	{
+		Name:        "stash-pop",
+		Summary:     "Describe a stash entry, then pop and commit it in one step",
+		Usage:       "stash-pop [--yes] [--commit=false] [n]",
+		Description: "Generates a message for stash@{n} (default 0) from its diff, using the message given to `git stash push -m` as intent, asks for confirmation, pops it, stages every path it touched and commits. Refuses while other changes are staged. With --commit=false the message is only printed and the stash is left alone.",
+		Flags:       append([]string{"commit", "yes", "review", "context"}, generateFlags...),
+		Examples: []Example{
+			{"Turn the latest stash into a commit", "go-commitgen stash-pop"},
+			{"Preview the message for an older entry", "go-commitgen stash-pop --commit=false 3"},
+		},
+	},
+	{
+		Name:        "install-hook",
+		Summary:     "Install the prepare-commit-msg hook, or the pre-push check (--manager git, husky or lefthook)",
+		Usage:       "install-hook [--pre-push] [--manager git|husky|lefthook]",
+		Description: "Wires go-commitgen into prepare-commit-msg, or with --pre-push runs push-check from the pre-push hook, directly or through a hook manager. Existing hooks are never overwritten.",
+		Flags:       []string{"manager", "pre-push"},
+		Examples: []Example{
+			{"Plain git hook", "go-commitgen install-hook"},
+			{"Repository using lefthook", "go-commitgen install-hook --manager lefthook"},
+			{"Review outgoing commits before every push", "go-commitgen install-hook --pre-push"},
+		},
+	},
+	{
+		Name:        "update",
+		Summary:     "Replace this binary with the latest verified GitHub release",
+		Usage:       "update [--force] [--allow-unsigned]",
+		Description: "Downloads the newest release for this platform, verifies its checksum and the signature of the checksum file, and replaces the running executable.",
+		Flags:       []string{"force", "allow-unsigned"},
+		Examples:    []Example{{"Update to the latest release", "go-commitgen update"}},
+	},
+	{
+		Name:        "version",
+		Summary:     "Print the version, commit and build date",
+		Usage:       "version [--json]",
+		Description: "Prints the release, the commit the binary was built from, the build date, the Go version and the platform. Packagers set them with -ldflags -X on internal/version.Version, Commit and Date; builds without them (go install) report what the Go toolchain recorded. --json prints them as an object for scripts and bug reports.",
+		Flags:       []string{"json"},
+		Examples: []Example{
+			{"Check the installed build", "go-commitgen version"},
+			{"Attach to a bug report", "go-commitgen version --json"},
+		},
+	},
+	{
+		Name:        "debug",
+		Summary:     "Bundle the diff, settings, prompts and model answers of a run for a bug report",
+		Usage:       "debug capture [file.tar.gz]",
+		Description: "Generates a message for the staged changes (or --diff-file) without committing and writes the trimmed diff, branch, settings, every prompt and raw model answer and the result to a gzipped tarball (default commitgen-debug.tar.gz). The API key, header values and anything that looks like a token, password or private key are redacted; check the bundle before attaching it.",
+		Flags: append([]string{
+			"review", "context", "intent-markers", "history", "repeat-check", "include-untracked",
+			"untracked-max-bytes", "diff-file", "linters", "linter", "go-symbols",
+		}, generateFlags...),
+		Examples: []Example{
+			{"Capture a run that produced a bad message", "go-commitgen debug capture"},
+			{"Reproduce with a patch and another model", "go-commitgen debug capture --diff-file fix.patch --model llama3.1 bug.tar.gz"},
+		},
+	},
+	{
+		Name:        "cache",
+		Summary:     "Show or clear the generation cache",
+		Usage:       "cache clear|stats",
+		Description: "With --cache, model answers are stored under --cache-dir keyed by the prompt, sampling options, model digest (from /api/show) and prompt template version, so a re-pulled model or an upgrade with new prompts never reuses an old answer. stats prints the number, size, age and models of the stored answers; clear removes them all.",
+		Examples: []Example{
+			{"See what the cache holds", "go-commitgen cache stats"},
+			{"Start from scratch", "go-commitgen cache clear"},
+		},
+	},
+	{
+		Name:        "config",
+		Summary:     "Read, change and check the config files",
+		Usage:       "config get KEY | set KEY VALUE... | list | validate | doctor",
+		Description: "get prints the value a key has in effect after flags, profile, config files and environment. set writes a key into the --config file (into [profile.NAME] with --profile), checking the value first and keeping the rest of the file, comments included; repeatable keys take several values. list prints the user and repository config files. validate reports every unknown key and every value its flag would reject, such as a bad duration. doctor runs the checks of `go-commitgen doctor`. Flags such as --profile go before the action.",
+		Examples: []Example{
+			{"Use a bigger model by default", "go-commitgen config set model qwen2.5-coder:7b"},
+			{"Pin settings for work repositories", "go-commitgen config --profile work set header X-Team=platform X-Env=ci"},
+			{"Check the files after editing them by hand", "go-commitgen config validate"},
+		},
+	},
+	{
+		Name:        "help",
+		Summary:     "Show help for a command",
+		Usage:       "help [command]",
+		Description: "Prints the usage, flags and examples of a command, or the command overview.",
+		Examples:    []Example{{"Help for stack", "go-commitgen help stack"}},
+	},
+	{
+		Name:        "docs",
+		Summary:     "Generate documentation (man page)",
+		Usage:       "docs man",
+		Description: "Writes the go-commitgen(1) man page in roff format to stdout.",
+		Examples:    []Example{{"Install the man page", "go-commitgen docs man > /usr/local/share/man/man1/go-commitgen.1"}},
+	},
+}
+
+// LookupCommand returns the command called name; "" is the default flow.
+func LookupCommand(name string) (Command, bool) {
+	for _, c := range Commands {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Command{}, false
+}
+
+// accepts reports whether the command takes the flag.
+func (c Command) accepts(flag string) bool {
+	for _, f := range c.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	for _, f := range GlobalFlags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Command) title() string {
+	if c.Name == "" {
+		return "go-commitgen"
+	}
+	return "go-commitgen " + c.Name
+}
+
+// checkFlags rejects flags given on the command line that the command does
+// not use, instead of silently ignoring them.
+func (c Command) checkFlags(fs *flag.FlagSet) error {
+	var unknown []string
+	fs.Visit(func(f *flag.Flag) {
+		if !c.accepts(f.Name) {
+			unknown = append(unknown, "--"+f.Name)
+		}
+	})
+	if len(unknown) > 0 {
+		return fmt.Errorf("%s does not accept %s (see `go-commitgen help %s`)", c.title(), strings.Join(unknown, ", "), c.Name)
+	}
+	return nil
+}
+
+// Help writes the help of the named command, or the overview for "".
+func Help(w io.Writer, fs *flag.FlagSet, name string) error {
+	c, ok := LookupCommand(name)
+	if !ok {
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	fmt.Fprintf(w, "Usage: go-commitgen %s\n\n%s\n", c.Usage, c.Description)
+	if c.Name == "" {
+		fmt.Fprintln(w, "\nCommands:")
+		for _, sub := range Commands[1:] {
+			fmt.Fprintf(w, "  %-14s %s\n", sub.Name, sub.Summary)
+		}
+	}
+	if len(c.Flags) > 0 {
+		fmt.Fprintln(w, "\nFlags:")
+		printFlags(w, fs, c.Flags)
+	}
+	fmt.Fprintln(w, "\nGlobal flags:")
+	printFlags(w, fs, GlobalFlags)
+	if len(c.Examples) > 0 {
+		fmt.Fprintln(w, "\nExamples:")
+		for _, e := range c.Examples {
+			fmt.Fprintf(w, "  # %s\n  %s\n\n", e.Description, e.Command)
+		}
+	}
+	if c.Name == "" {
+		fmt.Fprintln(w, "Run `go-commitgen help <command>` for the flags and examples of a command.")
+	}
+	return nil
+}
+
+func printFlags(w io.Writer, fs *flag.FlagSet, names []string) {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		kind, usage := flagKind(f)
+		line := "  --" + f.Name
+		if kind != "" {
+			line += " " + kind
+		}
+		fmt.Fprintf(w, "%s\n      %s", line, usage)
+		switch {
+		case f.DefValue == "" || f.DefValue == "false" || f.DefValue == "0":
+		case kind == "string":
+			fmt.Fprintf(w, " (default %q)", f.DefValue)
+		default:
+			fmt.Fprintf(w, " (default %s)", f.DefValue)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// flagKind is flag.UnquoteUsage without the argument name for booleans,
+// whose usage only quotes commands in backticks.
+func flagKind(f *flag.Flag) (string, string) {
+	kind, usage := flag.UnquoteUsage(f)
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		kind = ""
+	}
+	return kind, usage
+}
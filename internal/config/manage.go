@@ -0,0 +1,364 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/git"
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// fileKeys are the config keys that are no flag of their own.
+var fileKeys = map[string]bool{"match": true, "profile": true}
+
+// configPaths returns the user config file and, inside a repository, the
+// repository's RepoFileName, in the order they are layered.
+func configPaths(user string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	paths := []string{user}
+	if root, err := git.NewCLIRepository().Root(ctx); err == nil {
+		paths = append(paths, filepath.Join(root, RepoFileName))
+	}
+	return paths
+}
+
+// ConfigGet returns the value key has in effect, after the command line,
+// profile, config files and environment are applied.
+func (o Options) ConfigGet(key string) (string, error) {
+	f, err := o.lookupKey(key)
+	if err != nil {
+		return "", err
+	}
+	return f.Value.String(), nil
+}
+
+// ConfigSet writes key into the file given by --config, inside the
+// [profile.<name>] table when --profile is set. The value is checked like
+// the flag would check it; repeatable flags take several values.
+func (o Options) ConfigSet(key string, values []string) error {
+	f, err := o.lookupKey(key)
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("%s: missing value", key)
+	}
+	if len(values) > 1 && !repeatable(f) {
+		return fmt.Errorf("%s takes one value, got %d", key, len(values))
+	}
+	if err := checkValue(f, values); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	path := o.RawFlagSet.Lookup("config").Value.String()
+	if path == "" {
+		return errors.New("no config file: set --config or COMMITGEN_CONFIG")
+	}
+	profile := o.RawFlagSet.Lookup("profile").Value.String()
+	return setKey(path, profile, f.Name+" = "+formatValue(f, values))
+}
+
+// ConfigList writes every config file with the keys it sets, secrets
+// masked, in the order the files are layered.
+func (o Options) ConfigList(w io.Writer) error {
+	for _, path := range configPaths(o.RawFlagSet.Lookup("config").Value.String()) {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			fmt.Fprintf(w, "# %s (not found)\n\n", path)
+			continue
+		}
+		file, err := LoadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "# %s\n", path)
+		writeValues(w, file.Values)
+		names := make([]string, 0, len(file.Profiles))
+		for name := range file.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "\n[profile.%s]\n", name)
+			writeValues(w, file.Profiles[name])
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+func writeValues(w io.Writer, values map[string][]string) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		value := strings.Join(values[k], ", ")
+		if secretFlags[strings.ReplaceAll(k, "_", "-")] {
+			value = "[REDACTED]"
+		}
+		fmt.Fprintf(w, "%s = %s\n", k, value)
+	}
+}
+
+// ConfigValidate checks every config file: its syntax, that each key names
+// a flag the file may set and that each value is one the flag accepts. It returns all the
+// problems found, not just the first.
+func (o Options) ConfigValidate() []error {
+	var (
+		problems []error
+		merged   File
+	)
+	for i, path := range configPaths(o.RawFlagSet.Lookup("config").Value.String()) {
+		file, err := LoadFile(path)
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		if i > 0 {
+			var dropped []string
+			file, dropped = file.repoSafe()
+			for _, key := range dropped {
+				problems = append(problems, fmt.Errorf("%s: %s can only be set in the user config", path, key))
+			}
+		}
+		merged = merged.Merge(file)
+		for _, err := range validateValues(o.RawFlagSet, file.Values) {
+			problems = append(problems, fmt.Errorf("%s: %w", path, err))
+		}
+		names := make([]string, 0, len(file.Profiles))
+		for name := range file.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, err := range validateValues(o.RawFlagSet, file.Profiles[name]) {
+				problems = append(problems, fmt.Errorf("%s: [profile.%s] %w", path, name, err))
+			}
+		}
+	}
+	if selected := merged.Values["profile"]; len(selected) > 0 {
+		if _, err := merged.Profile(selected[0], ""); err != nil {
+			problems = append(problems, fmt.Errorf("profile: %w", err))
+		}
+	}
+	return problems
+}
+
+func validateValues(fs *flag.FlagSet, values map[string][]string) []error {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var problems []error
+	for _, key := range keys {
+		name := strings.ReplaceAll(key, "_", "-")
+		if fileKeys[name] {
+			continue
+		}
+		f := fs.Lookup(name)
+		if f == nil {
+			problems = append(problems, unknownKey(fs, key))
+			continue
+		}
+		if err := checkValue(f, values[key]); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	return problems
+}
+
+func (o Options) lookupKey(key string) (*flag.Flag, error) {
+	if o.RawFlagSet == nil {
+		return nil, errors.New("options were not parsed from flags")
+	}
+	f := o.RawFlagSet.Lookup(strings.ReplaceAll(key, "_", "-"))
+	if f == nil {
+		return nil, unknownKey(o.RawFlagSet, key)
+	}
+	return f, nil
+}
+
+// unknownKey names the closest flag, since most unknown keys are typos.
+func unknownKey(fs *flag.FlagSet, key string) error {
+	name := strings.ReplaceAll(key, "_", "-")
+	best, bestDistance := "", 4
+	fs.VisitAll(func(f *flag.Flag) {
+		if d := util.EditDistance(name, f.Name); d < bestDistance {
+			best, bestDistance = f.Name, d
+		}
+	})
+	if best != "" {
+		return fmt.Errorf("unknown key %q (did you mean %q?)", key, best)
+	}
+	return fmt.Errorf("unknown key %q (see `go-commitgen --help` for the flag names)", key)
+}
+
+// repeatable reports whether the flag collects a value per occurrence.
+func repeatable(f *flag.Flag) bool {
+	switch f.Value.(type) {
+	case *stringsFlag, keyValueFlag:
+		return true
+	}
+	return false
+}
+
+// checkValue reports whether the flag would accept values, without
+// setting it.
+func checkValue(f *flag.Flag, values []string) error {
+	if _, ok := f.Value.(keyValueFlag); ok {
+		for _, v := range values {
+			if k, _, ok := strings.Cut(v, "="); !ok || strings.TrimSpace(k) == "" {
+				return fmt.Errorf("expected key=value, got %q", v)
+			}
+		}
+		return nil
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return nil
+	}
+	raw := strings.Join(values, ",")
+	switch getter.Get().(type) {
+	case bool:
+		if _, err := strconv.ParseBool(raw); err != nil {
+			return fmt.Errorf("expected true or false, got %q", raw)
+		}
+	case int:
+		if _, err := strconv.ParseInt(raw, 0, strconv.IntSize); err != nil {
+			return fmt.Errorf("expected a whole number, got %q", raw)
+		}
+	case float64:
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return fmt.Errorf("expected a number, got %q", raw)
+		}
+	case time.Duration:
+		if _, err := time.ParseDuration(raw); err != nil {
+			return fmt.Errorf("expected a duration like 30s, 5m or 1h30m, got %q", raw)
+		}
+	}
+	return nil
+}
+
+// formatValue renders values as TOML: arrays for repeatable flags, bare
+// booleans and numbers, quoted strings otherwise.
+func formatValue(f *flag.Flag, values []string) string {
+	if repeatable(f) {
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = strconv.Quote(v)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	if getter, ok := f.Value.(flag.Getter); ok {
+		switch getter.Get().(type) {
+		case bool, int, float64:
+			return values[0]
+		}
+	}
+	return strconv.Quote(values[0])
+}
+
+// setKey replaces the line of line's key in the top level (profile "") or
+// in [profile.<profile>] of the file at path, or adds it at the end of that
+// table. Comments and the rest of the file are kept as they are.
+func setKey(path, profile, line string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read config: %w", err)
+	}
+	if _, err := ParseFile(strings.NewReader(string(data))); err != nil {
+		return fmt.Errorf("%s: %w; fix it by hand first", path, err)
+	}
+	name, _, _ := strings.Cut(line, " = ")
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+	// start and end delimit the table's lines; start is -1 until found
+	start, end := -1, len(lines)
+	if profile == "" {
+		start = 0
+	}
+	for i, l := range lines {
+		l = strings.TrimSpace(stripComment(l))
+		if !strings.HasPrefix(l, "[") {
+			continue
+		}
+		if start >= 0 {
+			end = i
+			break
+		}
+		section := strings.TrimSpace(strings.Trim(l, "[]"))
+		if n, ok := strings.CutPrefix(section, "profile."); ok && strings.Trim(n, `"`) == profile {
+			start = i + 1
+		}
+	}
+
+	switch {
+	case start < 0:
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "[profile."+profile+"]", line)
+	default:
+		replaced := false
+		for i := start; i < end; i++ {
+			content := stripComment(lines[i])
+			key, _, ok := strings.Cut(content, "=")
+			if ok && strings.ReplaceAll(strings.TrimSpace(key), "_", "-") == name {
+				// keep a trailing comment
+				lines[i], replaced = line+strings.TrimRight(lines[i][len(strings.TrimRight(content, " \t")):], " \t"), true
+				break
+			}
+		}
+		if !replaced {
+			at := end
+			for at > start && strings.TrimSpace(lines[at-1]) == "" {
+				at--
+			}
+			lines = append(lines[:at], append([]string{line}, lines[at:]...)...)
+		}
+	}
+	return writeFile(path, []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// writeFile replaces path atomically, keeping the mode of an existing file;
+// a new file is private since it may hold an API key.
+func writeFile(path string, data []byte) error {
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.toml")
+	if err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
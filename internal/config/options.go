@@ -4,8 +4,21 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/cache"
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	"github.com/riskibarqy/go-commitgen/internal/examples"
+	"github.com/riskibarqy/go-commitgen/internal/git"
+	"github.com/riskibarqy/go-commitgen/internal/linter"
+	"github.com/riskibarqy/go-commitgen/internal/logging"
+	"github.com/riskibarqy/go-commitgen/internal/ollama"
+	"github.com/riskibarqy/go-commitgen/internal/prompt"
+	"github.com/riskibarqy/go-commitgen/internal/stats"
+	"github.com/riskibarqy/go-commitgen/internal/webhook"
 )
 
 const (
@@ -14,21 +27,161 @@ const (
 	defaultReviewModel = "qwen2.5-coder:1.5b"
 	defaultMaxBytes    = 32000
 	defaultTimeout     = 40 * time.Second
+	defaultIdleTimeout = 30 * time.Second
+	defaultLintRetries = 2
+	defaultHistory     = 3
+	defaultRepeatCheck = 10
+	defaultUntracked   = 4000
+	defaultMoveLines   = 6
+	defaultSmallBytes  = 400
+	defaultLargeBytes  = 16000
+	defaultCaptureFile = "commitgen-debug.tar.gz"
+	defaultCacheTTL    = 7 * 24 * time.Hour
+	defaultCriticMin   = 7
+	defaultCriticRetry = 1
 )
 
 // Options captures all user facing configuration.
 type Options struct {
-	Model        string
-	ReviewModel  string
-	Endpoint     string
-	MaxBytes     int
-	Commit       bool
-	Review       bool
-	HookPath     string
-	Timeout      time.Duration
-	Args         []string
-	RawFlagSet   *flag.FlagSet
-	DisplayUsage func()
+	// Command is the selected subcommand, empty for the default flow.
+	Command        string
+	Profile        string
+	Model          string
+	ReviewModel    string
+	Endpoint       string
+	VCS            string
+	API            string
+	Format         string
+	StripThink     bool
+	ModelDefaults  bool
+	CAFile         string
+	CertFile       string
+	KeyFile        string
+	Insecure       bool
+	APIKey         string
+	Headers        map[string]string
+	MaxBytes       int
+	Commit         bool
+	Review         bool
+	HookPath       string
+	HookSource     string
+	HookManager    string
+	PrePush        bool
+	BlockOn        string
+	PushRemote     string
+	Trunk          string
+	Force          bool
+	AllowUnsigned  bool
+	VersionJSON    bool
+	ExplainFile    string
+	Author         string
+	UpdateCheck    bool
+	Offline        bool
+	Strict         bool
+	RepairJSON     bool
+	Copy           bool
+	Notify         bool
+	WebhookURL     string
+	WebhookText    string
+	RequireSignoff bool
+	NotifyAfter    time.Duration
+	Cache          bool
+	CacheTTL       time.Duration
+	CacheDir       string
+	CacheAction    string
+	ConfigAction   string
+	Yes            bool
+	Since          string
+	Repos          []string
+	Dataset        string
+	BenchHistory   string
+	Against        string
+	PostToPR       bool
+	GitHubToken    string
+	GitHubAPI      string
+	Forge          string
+	GitLabToken    string
+	GiteaToken     string
+	JiraURL        string
+	JiraUser       string
+	JiraToken      string
+	Audience       string
+	AllowEmpty     bool
+	Context        string
+	IntentMarks    bool
+	StripMarks     bool
+	Porcelain      bool
+	Emit           []string
+	Stdio          bool
+	KeepAlive      string
+	MetricsAddr    string
+	RateLimit      float64
+	MaxConcurrent  int
+	MaxIdleConns   int
+	Log            logging.Config
+	OTLPEndpoint   string
+	Timeout        time.Duration
+	GitTimeout     time.Duration
+	IdleTimeout    time.Duration
+	LintRetries    int
+	History        int
+	RepeatCheck    int
+	Denylist       []string
+	DenyFail       bool
+	Untracked      bool
+	UntrackedMax   int
+	// LLMOptions holds provider options explicitly set by the user; they
+	// override the per-call defaults chosen by the use case.
+	LLMOptions     map[string]interface{}
+	Summarize      bool
+	IgnoreSpace    bool
+	Similarity     int
+	MoveLines      int
+	Noise          string
+	MinifyDiff     bool
+	PostProcess    []string
+	Trailers       []string
+	DiffFile       string
+	RecordStats    bool
+	StatsFile      string
+	SummaryStyle   string
+	IssueKeyword   map[string]string
+	Conventions    commit.Conventions
+	ScopesFromDirs bool
+	SkipSmall      bool
+	SmallBytes     int
+	SmallModel     string
+	LargeBytes     int
+	EscalateTo     string
+	Linters        []linter.Linter
+	GoSymbols      bool
+	BlameContext   bool
+	FewShot        int
+	RecordExamples bool
+	ExamplesFile   string
+	RepoContext    int
+	EmbedModel     string
+	Tone           string
+	Polish         bool
+	PolishModel    string
+	Models         []string
+	JudgeModel     string
+	FindingsInBody string
+	CreateIssues   bool
+	IssueLabels    []string
+	Critic         bool
+	CriticModel    string
+	CriticMin      int
+	CriticRetries  int
+	ReuseContext   bool
+	AutoModels     []string
+	GPUMemory      int64
+	Args           []string
+	Stash          int
+	CaptureFile    string
+	Paths          []string
+	RawFlagSet     *flag.FlagSet
+	DisplayUsage   func()
 }
 
 // Parse consumes CLI flags/environment variables and returns validated options.
@@ -36,36 +189,850 @@ func Parse() (Options, error) {
 	fs := flag.NewFlagSet("go-commitgen", flag.ContinueOnError)
 	fs.SetOutput(os.Stdout)
 
+	configPath := fs.String("config", DefaultFilePath(), "Config file (TOML); .commitgen.toml in the repository root is layered on top")
+	profile := fs.String("profile", os.Getenv("COMMITGEN_PROFILE"), "Config profile to use (default: matched against the origin remote URL)")
 	model := fs.String("model", envOr("OLLAMA_MODEL", defaultModel), "Ollama model used for commit generation")
 	reviewModel := fs.String("review-model", envOr("OLLAMA_REVIEW_MODEL", defaultReviewModel), "Ollama model used for code review (falls back to --model)")
 	endpoint := fs.String("endpoint", envOr("OLLAMA_ENDPOINT", defaultEndpoint), "Ollama base URL")
+	format := fs.String("format", envOr("COMMITGEN_FORMAT", "schema"), "Constrain the commit answer: schema (JSON schema), json (JSON mode) or none for providers without structured outputs")
+	stripThink := fs.Bool("strip-thinking", boolFromEnv("COMMITGEN_STRIP_THINKING", true), "Remove <think>…</think> blocks and chat-template tokens from model responses")
+	modelfileDefaults := fs.Bool("modelfile-defaults", boolFromEnv("COMMITGEN_MODELFILE_DEFAULTS", true), "Keep the sampling parameters the model's Modelfile sets instead of the built-in per-call defaults; --temperature, --top-p, --num-predict, --seed and --llm-option still override them")
+	caFile := fs.String("ca-file", os.Getenv("COMMITGEN_CA_FILE"), "PEM bundle of extra CAs trusted for the endpoint")
+	certFile := fs.String("client-cert", os.Getenv("COMMITGEN_CLIENT_CERT"), "Client certificate (PEM) for mutual TLS")
+	keyFile := fs.String("client-key", os.Getenv("COMMITGEN_CLIENT_KEY"), "Client certificate key (PEM) for mutual TLS")
+	insecure := fs.Bool("insecure-skip-verify", boolFromEnv("COMMITGEN_INSECURE_SKIP_VERIFY", false), "Skip TLS certificate verification (self-signed gateways)")
+	apiKey := fs.String("api-key", os.Getenv("OLLAMA_API_KEY"), "Bearer token sent with every request to the endpoint")
+	var headerFlags stringsFlag
+	fs.Var(&headerFlags, "header", "Extra HTTP header sent with every request as key=value (repeatable)")
+	vcs := fs.String("vcs", envOr("COMMITGEN_VCS", "auto"), "Version control system: auto (detect), git, jj or sl")
+	api := fs.String("api", envOr("OLLAMA_API", "generate"), "Ollama API to call: generate (single prompt) or chat (system/user roles)")
 	maxBytes := fs.Int("max-bytes", intFromEnv("COMMITGEN_MAX_BYTES", defaultMaxBytes), "Maximum diff bytes to send to the model")
 	commitNow := fs.Bool("commit", true, "Run `git commit -m` with the generated message")
 	runReview := fs.Bool("review", false, "Run an AI review before generating the commit message")
 	hookPath := fs.String("hook", "", "When set, write the message into the given hook file")
-	timeout := fs.Duration("timeout", durationFromEnv("COMMITGEN_TIMEOUT", defaultTimeout), "Total timeout for the command")
+	allowEmpty := fs.Bool("allow-empty", false, "With nothing staged, write the message from --context and commit with --allow-empty")
+	intent := fs.String("context", "", "Why the change was made, given to the model as author intent (required for --allow-empty)")
+	intentMarkers := fs.Bool("intent-markers", boolFromEnv("COMMITGEN_INTENT_MARKERS", false), "Read TODO(commit): / WHY: comments added by the diff and the branch name as author intent")
+	stripMarkers := fs.Bool("strip-markers", false, "Remove the TODO(commit): / WHY: markers from the staged files after generating")
+	porcelain := fs.Bool("porcelain", false, "Print the result in the stable line-oriented format for editor integrations")
+	emit := fs.String("emit", os.Getenv("COMMITGEN_EMIT"), "Print the message in each of these comma separated formats: headline, body, conventional, markdown")
+	stdio := fs.Bool("stdio", false, "Serve JSON-RPC requests (generate, review, regenerate) on stdin/stdout for editor extensions")
+	metricsAddr := fs.String("metrics-addr", envOr("COMMITGEN_METRICS_ADDR", ""), "With --stdio, serve Prometheus metrics at http://ADDR/metrics (e.g. :9464)")
+	keepAlive := fs.String("keep-alive", envOr("COMMITGEN_KEEP_ALIVE", "30m"), "How long Ollama keeps the models loaded between --stdio requests")
+	against := fs.String("against", "", "review: review the branch's changes since its merge base with this ref (e.g. origin/main) instead of the staged diff")
+	postToPR := fs.Bool("post-to-pr", false, "review: post the findings as comments on the branch's pull request; pr: create or update the pull request")
+	jiraURL := fs.String("jira-url", os.Getenv("COMMITGEN_JIRA_URL"), "Jira site `from-issue` reads PROJ-123 style tickets from, e.g. https://example.atlassian.net; credentials come from JIRA_USER and JIRA_API_TOKEN")
+	forgeKind := fs.String("forge", envOr("COMMITGEN_FORGE", "auto"), "Code host of the origin remote: auto (detect from the remote), github, gitlab or gitea (also Forgejo)")
+	since := fs.String("since", "", "Tag or revision the release-notes subcommand starts after (e.g. v1.2.0); for standup, a date such as yesterday or \"last friday\" (default yesterday)")
+	dataset := fs.String("dataset", "", "Directory of NAME.diff files with the human-written NAME.msg next to each, for the bench subcommand")
+	benchHistory := fs.String("bench-history", os.Getenv("COMMITGEN_BENCH_HISTORY"), "JSONL file bench appends its results to and compares them with")
+	repos := fs.String("repos", os.Getenv("COMMITGEN_REPOS"), "Comma separated repository directories standup reads (default: the current one)")
+	audience := fs.String("audience", envOr("COMMITGEN_AUDIENCE", "users"), "Release notes audience: users (highlights) or developers (detailed change log)")
+	yes := fs.Bool("yes", false, "fixup: use the best candidate without asking for confirmation; stash-pop: commit without asking")
+	copyMessage := fs.Bool("copy", boolFromEnv("COMMITGEN_COPY", false), "Also put the generated message on the system clipboard (pbcopy, wl-copy, xclip, xsel, clip.exe, or the OSC 52 terminal escape)")
+	requireSignoff := fs.Bool("require-signoff", boolFromEnv("COMMITGEN_REQUIRE_SIGNOFF", false), "Append a Signed-off-by trailer for the committer (user.name/user.email) to every message, for projects enforcing the DCO")
+	notify := fs.Bool("notify", boolFromEnv("COMMITGEN_NOTIFY", false), "Show a desktop notification when generation finishes (osascript, notify-send, or a PowerShell balloon on Windows)")
+	webhookURL := fs.String("webhook-url", os.Getenv("COMMITGEN_WEBHOOK_URL"), "Post each commit to this Slack, Teams or Mattermost incoming webhook")
+	webhookText := fs.String("webhook-template", envOr("COMMITGEN_WEBHOOK_TEMPLATE", webhook.DefaultTemplate), "Go template of the --webhook-url text over .Repo, .Branch, .Hash, .Short, .Author, .Headline, .Body, .Type, .Scope and .Description")
+	notifyAfter := fs.Duration("notify-after", durationFromEnv("COMMITGEN_NOTIFY_AFTER", 10*time.Second), "With --notify, only notify when generation took at least this long (0 always notifies)")
+	strict := fs.Bool("strict", boolFromEnv("COMMITGEN_STRICT", false), "Fail instead of fixing up an answer that still breaks the conventions after the lint retries or is not valid JSON")
+	repairJSON := fs.Bool("repair-json", boolFromEnv("COMMITGEN_REPAIR_JSON", false), "Ask the model once to fix an answer that is not valid JSON before counting it as a format violation")
+	offline := fs.Bool("offline-fallback", boolFromEnv("COMMITGEN_OFFLINE_FALLBACK", false), "When the model endpoint is unreachable, write a clearly marked message from file stats instead of failing (keeps hook-based commits working)")
+	author := fs.String("author", os.Getenv("COMMITGEN_AUTHOR"), "Record commits as this \"Name <email>\", author and committer alike (default: the VCS's user); also the sign-off identity")
+	explainFile := fs.String("file", "", "explain: limit the commit or range to this path")
+	versionJSON := fs.Bool("json", false, "version: print the build metadata as JSON")
+	updateCheck := fs.Bool("update-check", boolFromEnv("COMMITGEN_UPDATE_CHECK", false), "Warn on startup when a newer release is published (asks GitHub at most once a day)")
+	allowUnsigned := fs.Bool("allow-unsigned", false, "update: install a release checked against its checksums alone when this build embeds no release signing key")
+	force := fs.Bool("force", false, "Generate even while a merge, rebase, cherry-pick or revert has unresolved conflicts; update: install the latest release even when it is not newer (e.g. over a dev build)")
+	trunk := fs.String("trunk", envOr("COMMITGEN_TRUNK", "main"), "Trunk branch the stack, fixup and push-check subcommands start from")
+	prePush := fs.Bool("pre-push", false, "install-hook: install the push-check pre-push hook instead of prepare-commit-msg")
+	blockOn := fs.String("block-on", envOr("COMMITGEN_BLOCK_ON", "high"), "push-check: stop the push on review findings of this severity or higher: high, medium, low or none")
+	manager := fs.String("manager", envOr("COMMITGEN_HOOK_MANAGER", "git"), "Hook manager used by install-hook: git, husky or lefthook")
+	hookSource := fs.String("hook-source", "", "Commit source passed by prepare-commit-msg as $2 (message, template, merge, squash, commit)")
+	rateLimit := fs.Float64("rate-limit", floatFromEnv("COMMITGEN_RATE_LIMIT", 0), "Maximum requests per second sent to the endpoint; extra requests queue (0 disables)")
+	maxConcurrent := fs.Int("max-concurrent", intFromEnv("COMMITGEN_MAX_CONCURRENT", 0), "Maximum requests in flight to the endpoint; extra requests queue (0 disables)")
+	maxIdleConns := fs.Int("max-idle-conns", intFromEnv("COMMITGEN_MAX_IDLE_CONNS", ollama.DefaultMaxIdleConns), "Idle keep-alive connections to the endpoint kept for reuse across calls")
+	logLevel := fs.String("log-level", envOr("COMMITGEN_LOG_LEVEL", "warn"), "Minimum level of diagnostic log records: debug, info, warn or error")
+	logFormat := fs.String("log-format", envOr("COMMITGEN_LOG_FORMAT", "text"), "Log record format: text or json")
+	logFile := fs.String("log-file", envOr("COMMITGEN_LOG_FILE", ""), "Append log records to this file instead of stderr")
+	otlpEndpoint := fs.String("otlp-endpoint", otlpFromEnv(), "OTLP/HTTP traces URL (e.g. http://localhost:4318/v1/traces) to export pipeline spans to; empty disables tracing")
+	useCache := fs.Bool("cache", boolFromEnv("COMMITGEN_CACHE", false), "Reuse the stored answer when the same prompt is sent to the same model build again (keyed by prompt, options, model digest and prompt version)")
+	cacheTTL := fs.Duration("cache-ttl", durationFromEnv("COMMITGEN_CACHE_TTL", defaultCacheTTL), "With --cache, ignore stored answers older than this (0 keeps them until `cache clear`)")
+	cacheDir := fs.String("cache-dir", cache.DefaultDir(), "Directory of the generation cache (env COMMITGEN_CACHE_DIR)")
+	timeout := fs.Duration("timeout", durationFromEnv("COMMITGEN_TIMEOUT", defaultTimeout), "How long the model may take to start answering (loading it and reading the prompt), and the limit on calls answered in one piece such as embeddings; a streamed answer is bounded by --llm-idle-timeout instead")
+	idleTimeout := fs.Duration("llm-idle-timeout", durationFromEnv("COMMITGEN_LLM_IDLE_TIMEOUT", defaultIdleTimeout), "Abort a streaming model answer when no output arrives for this long, however long the whole answer takes (0 disables)")
+	gitTimeout := fs.Duration("git-timeout", durationFromEnv("COMMITGEN_GIT_TIMEOUT", git.DefaultTimeout), "Timeout of each git/jj/sl subprocess that needs no input (0 disables)")
+	lintRetries := fs.Int("lint-retries", intFromEnv("COMMITGEN_LINT_RETRIES", defaultLintRetries), "Re-prompt the model up to N times when its answer breaks the commit lint rules")
+	history := fs.Int("history", intFromEnv("COMMITGEN_HISTORY", defaultHistory), "Number of recent commit subjects touching the staged files to include as context (0 disables)")
+	repeatCheck := fs.Int("repeat-check", intFromEnv("COMMITGEN_REPEAT_CHECK", defaultRepeatCheck), "Re-prompt when the description nearly repeats one of the last N commit subjects on the branch (0 disables)")
+	denylist := fs.String("denylist", envOr("COMMITGEN_DENYLIST", strings.Join(commit.DefaultDenylist, ",")), "Comma separated phrases (vague wording, internal codenames) the headline must not contain; empty disables")
+	denyAction := fs.String("deny-action", envOr("COMMITGEN_DENY_ACTION", "retry"), "What a denylisted headline does after --lint-retries: retry (keep it, reported as a violation) or fail")
+	untracked := fs.Bool("include-untracked", false, "Append the content of untracked (non-ignored) files to the diff")
+	untrackedMax := fs.Int("untracked-max-bytes", intFromEnv("COMMITGEN_UNTRACKED_MAX_BYTES", defaultUntracked), "Maximum bytes of each untracked file to include")
+	temperature := fs.String("temperature", os.Getenv("COMMITGEN_TEMPERATURE"), "Sampling temperature (overrides the built-in per-call default)")
+	topP := fs.String("top-p", os.Getenv("COMMITGEN_TOP_P"), "Nucleus sampling top_p")
+	numPredict := fs.String("num-predict", os.Getenv("COMMITGEN_NUM_PREDICT"), "Maximum tokens the model may generate per call")
+	seed := fs.String("seed", os.Getenv("COMMITGEN_SEED"), "Sampling seed for reproducible output")
+	summarize := fs.Bool("summarize-large", boolFromEnv("COMMITGEN_SUMMARIZE_LARGE", true), "Summarise oversized diffs per file before writing the message instead of truncating them")
+	ignoreSpace := fs.Bool("ignore-whitespace", boolFromEnv("COMMITGEN_IGNORE_WHITESPACE", false), "Ignore whitespace-only changes (git diff -w)")
+	similarity := fs.Int("similarity", intFromEnv("COMMITGEN_SIMILARITY", 0), "Rename/copy detection threshold in percent (0 keeps git's default)")
+	moveLines := fs.Int("move-min-lines", intFromEnv("COMMITGEN_MOVE_MIN_LINES", defaultMoveLines), "Report removed+re-added blocks of at least N lines as code moves; blank and punctuation-only lines do not count (0 disables)")
+	noise := fs.String("noise", envOr("COMMITGEN_NOISE", "summarize"), "Import-only, formatting-only and comment-only hunks: summarize (one line per file), drop or keep")
+	minifyDiff := fs.Bool("minify-diff", boolFromEnv("COMMITGEN_MINIFY_DIFF", false), "Send the model a denser diff: short hunk headers, collapsed unchanged lines, common indentation removed and long lines cut")
+	var postProcess stringsFlag
+	if v := strings.TrimSpace(os.Getenv("COMMITGEN_POST_PROCESS")); v != "" {
+		postProcess = append(postProcess, v)
+	}
+	fs.Var(&postProcess, "post-process", "Shell command that rewrites the message (JSON on stdin/stdout); repeatable, applied in order")
+	var trailerTemplates stringsFlag
+	if v := strings.TrimSpace(os.Getenv("COMMITGEN_TRAILER")); v != "" {
+		trailerTemplates = append(trailerTemplates, v)
+	}
+	fs.Var(&trailerTemplates, "trailer", "Trailer added to every message, a Go template over .Env (CI build variables, no credentials), .CI (Provider, Pipeline, Build, Job, URL) and .Branch, e.g. \"Build: {{.CI.URL}}\"; left out when its value is empty; repeatable")
+	diffFile := fs.String("diff-file", "", "Describe the diff in this file (\"-\" for stdin) instead of the staged changes; never commits")
+	recordStats := fs.Bool("stats", boolFromEnv("COMMITGEN_STATS", false), "Record generation outcomes locally for `go-commitgen stats`")
+	statsFile := fs.String("stats-file", stats.DefaultPath(), "Location of the local stats store (JSONL)")
+	summaryStyle := fs.String("style", "bullets", "Output style of log-summary: bullets or paragraph")
+	issueKeyword := fs.Bool("issue-keyword", boolFromEnv("COMMITGEN_ISSUE_KEYWORD", false), "Append \"Fixes #123\"/\"Refs PROJ-1\" for the branch's issue based on the commit type")
+	issueKeywords := fs.String("issue-keywords", os.Getenv("COMMITGEN_ISSUE_KEYWORDS"), "Commit type to keyword mapping for --issue-keyword, e.g. fix=Closes,feat=Refs")
+	types := fs.String("types", os.Getenv("COMMITGEN_TYPES"), "Comma separated commit types offered to the model (default feat,fix,perf,refactor,docs,test,build,chore,ci)")
+	scopes := fs.String("scopes", envOr("COMMITGEN_SCOPES", ""), "Comma separated scopes allowed in the headline ([type(scope)]), or \"auto\" for the top-level directories; empty leaves scopes out")
+	scopeAction := fs.String("scope-action", envOr("COMMITGEN_SCOPE_ACTION", "map"), "On a scope outside --scopes: map (to the nearest allowed scope) or retry (regenerate)")
+	toneName := fs.String("tone", os.Getenv("COMMITGEN_TONE"), "Message tone: concise, detailed, casual or formal (default: the built-in balanced prompt)")
+	polish := fs.Bool("polish", boolFromEnv("COMMITGEN_POLISH", false), "Run a proofreading pass fixing typos and grammar in the generated description and body")
+	models := fs.String("models", os.Getenv("COMMITGEN_MODELS"), "Comma separated models queried at once for an ensemble; the answers are offered as candidates, or judged with --judge-model (the first replaces --model)")
+	autoModels := fs.String("auto-models", os.Getenv("COMMITGEN_AUTO_MODELS"), "Comma separated models to choose --model from: the largest that fits in the free --gpu-memory, else the smallest")
+	gpuMemory := fs.String("gpu-memory", os.Getenv("COMMITGEN_GPU_MEMORY"), "GPU memory of the endpoint for --auto-models, e.g. 24GiB or 8000MiB")
+	judgeModel := fs.String("judge-model", os.Getenv("COMMITGEN_JUDGE_MODEL"), "With --models, a model that picks or merges the best candidate instead of using the first model's answer")
+	findingsInBody := fs.String("findings-in-body", envOr("COMMITGEN_FINDINGS_IN_BODY", "none"), "With --review, carry the findings into the commit body: none, section (a \"Known issues / follow-ups\" paragraph) or trailers (TODO: trailers)")
+	createIssues := fs.Bool("review-create-issues", boolFromEnv("COMMITGEN_REVIEW_CREATE_ISSUES", false), "With --review, open an issue on the forge (see --forge) for every high-severity finding once the commit is made, and refer to it in the commit body")
+	issueLabels := fs.String("issue-labels", envOr("COMMITGEN_ISSUE_LABELS", "review"), "Comma separated labels of the issues opened by --review-create-issues")
+	critic := fs.Bool("critic", boolFromEnv("COMMITGEN_CRITIC", false), "Score the message against the diff (accuracy, specificity, conventions) in a second pass and regenerate when it scores low")
+	criticModel := fs.String("critic-model", os.Getenv("COMMITGEN_CRITIC_MODEL"), "Model used by --critic (default --model)")
+	criticMin := fs.Int("critic-threshold", intFromEnv("COMMITGEN_CRITIC_THRESHOLD", defaultCriticMin), "With --critic, regenerate while the lowest score (1-10) is below this")
+	criticRetries := fs.Int("critic-retries", intFromEnv("COMMITGEN_CRITIC_RETRIES", defaultCriticRetry), "With --critic, regenerate at most N times; the best scoring message is kept")
+	reuseContext := fs.Bool("reuse-context", boolFromEnv("COMMITGEN_REUSE_CONTEXT", true), "When the review and commit models are the same, continue the commit call from the review's context instead of sending the diff again (--api generate only)")
+	polishModel := fs.String("polish-model", os.Getenv("COMMITGEN_POLISH_MODEL"), "Model used by --polish, e.g. a tiny one (default --model)")
+	imperative := fs.Bool("imperative", boolFromEnv("COMMITGEN_IMPERATIVE", false), "Rewrite headlines starting with \"added\", \"fixes\" and the like to the imperative")
+	keepPeriod := fs.Bool("keep-period", boolFromEnv("COMMITGEN_KEEP_PERIOD", false), "Keep a period or other punctuation ending the headline instead of dropping it")
+	headlineCase := fs.String("headline-case", envOr("COMMITGEN_HEADLINE_CASE", "lower"), "Case of the headline description's first letter: lower, upper or any")
+	noEmoji := fs.Bool("no-emoji", boolFromEnv("COMMITGEN_NO_EMOJI", false), "Remove emoji and :shortcode: emoji from the message")
+	bodyWidth := fs.Int("body-width", intFromEnv("COMMITGEN_BODY_WIDTH", 0), "Wrap body lines longer than this many characters (e.g. 72); 0 keeps them")
+	sections := fs.Bool("sections", boolFromEnv("COMMITGEN_SECTIONS", false), "Write the body under What, Why and How to test headings, each generated and validated separately")
+	dropRedundantBody := fs.Bool("drop-redundant-body", boolFromEnv("COMMITGEN_DROP_REDUNDANT_BODY", false), "Leave the body out when it only restates the headline")
+	noBody := fs.Bool("no-body", boolFromEnv("COMMITGEN_NO_BODY", false), "Write the headline alone, without a body (trailers are still added)")
+	maxHeadline := fs.Int("max-headline", intFromEnv("COMMITGEN_MAX_HEADLINE", 0), "Limit the whole headline, ticket and type included, to N characters, e.g. 50 (0 limits only the description)")
+	maxDescription := fs.Int("max-description", intFromEnv("COMMITGEN_MAX_DESCRIPTION", 72), "Limit the headline's description to N characters")
+	maxSummary := fs.Int("max-summary", intFromEnv("COMMITGEN_MAX_SUMMARY", 100), "Limit the model's summary, the body when it writes none, to N characters")
+	maxBody := fs.Int("max-body", intFromEnv("COMMITGEN_MAX_BODY", 0), "Limit the body to N characters (default 300, or the --tone's limit)")
+	typeAliases := fs.String("type-aliases", os.Getenv("COMMITGEN_TYPE_ALIASES"), "Comma separated alias=type mappings, e.g. hf=hotfix,sec=security")
+	skipSmall := fs.Bool("no-review-on-small-diffs", boolFromEnv("COMMITGEN_NO_REVIEW_ON_SMALL_DIFFS", false), "Skip the review for diffs under --small-diff-bytes")
+	smallBytes := fs.Int("small-diff-bytes", intFromEnv("COMMITGEN_SMALL_DIFF_BYTES", defaultSmallBytes), "Diffs under this size count as small for adaptive review")
+	smallModel := fs.String("small-review-model", os.Getenv("COMMITGEN_SMALL_REVIEW_MODEL"), "Cheaper model used to review small diffs instead of skipping them")
+	largeBytes := fs.Int("large-diff-bytes", intFromEnv("COMMITGEN_LARGE_DIFF_BYTES", defaultLargeBytes), "Diffs of at least this size are reviewed by --escalation-model")
+	escalateTo := fs.String("escalation-model", os.Getenv("COMMITGEN_ESCALATION_MODEL"), "Bigger model used to review large or security-sensitive diffs (auth, crypto, SQL, ...)")
+	linterNames := fs.String("linters", os.Getenv("COMMITGEN_LINTERS"), "Comma separated linter presets run during review (go-vet, golangci-lint, eslint); they read the working-tree copies of the staged files, unstaged edits included")
+	var customLinters stringsFlag
+	fs.Var(&customLinters, "linter", "Custom linter run during review as name:.ext1,.ext2:command ({files}/{pkgs} expand to the staged files, read from the working tree); repeatable")
+	fewShot := fs.Int("few-shot", intFromEnv("COMMITGEN_FEW_SHOT", 0), "Add up to N accepted messages of similar past changes in this repository to the prompt (0 disables)")
+	recordExamples := fs.Bool("record-examples", boolFromEnv("COMMITGEN_RECORD_EXAMPLES", false), "Store each committed message with a summary of its diff in the local few-shot library")
+	examplesFile := fs.String("examples-file", examples.DefaultPath(), "Location of the local few-shot library (JSONL)")
+	repoContext := fs.Int("repo-context", intFromEnv("COMMITGEN_REPO_CONTEXT", 0), "Add up to N module descriptions from the `go-commitgen index` embeddings to the prompt (0 disables)")
+	embedModel := fs.String("embed-model", envOr("COMMITGEN_EMBED_MODEL", "nomic-embed-text"), "Ollama embedding model used by the index subcommand")
+	goSymbols := fs.Bool("go-symbols", boolFromEnv("COMMITGEN_GO_SYMBOLS", true), "List the Go functions/types touched by the diff in the prompt")
+	blameContext := fs.Bool("blame-context", boolFromEnv("COMMITGEN_BLAME_CONTEXT", false), "Tell the model which commits wrote the lines the staged hunks replace (git blame)")
+	llmOptions := keyValueFlag{}
+	fs.Var(llmOptions, "llm-option", "Extra provider option as key=value (repeatable)")
+
+	args := os.Args[1:]
+	var command string
+	if len(args) > 0 && args[0] != "" {
+		if _, ok := LookupCommand(args[0]); ok {
+			command, args = args[0], args[1:]
+		}
+	}
+	cmd, _ := LookupCommand(command)
+	fs.Usage = func() { _ = Help(fs.Output(), fs, command) }
 
-	if err := fs.Parse(os.Args[1:]); err != nil {
+	if err := fs.Parse(args); err != nil {
 		return Options{}, fmt.Errorf("parse flags: %w", err)
 	}
+	// only the command line is checked: a config file may set flags for
+	// every command
+	if err := cmd.checkFlags(fs); err != nil {
+		return Options{}, err
+	}
+	switch command {
+	case "help":
+		if fs.NArg() > 0 {
+			if _, ok := LookupCommand(fs.Arg(0)); !ok || fs.Arg(0) == "" {
+				return Options{}, fmt.Errorf("unknown command %q", fs.Arg(0))
+			}
+		}
+	case "docs":
+		if fs.NArg() != 1 || fs.Arg(0) != "man" {
+			return Options{}, fmt.Errorf("docs: expected `docs man`")
+		}
+	case "explain":
+		if fs.NArg() > 1 {
+			return Options{}, fmt.Errorf("explain: expected `explain [commit|range|file] [--file path]`; put flags before the target")
+		}
+	case "from-issue":
+		_, key, ok := commit.ParseIssueRef(fs.Arg(0))
+		if fs.NArg() != 1 || !ok {
+			return Options{}, fmt.Errorf("from-issue: expected `from-issue [flags] <#12|PROJ-123>`; put flags before the issue")
+		}
+		if key != "" && strings.TrimSpace(*jiraURL) == "" {
+			return Options{}, fmt.Errorf("from-issue %s needs --jira-url (env COMMITGEN_JIRA_URL) to read the ticket", fs.Arg(0))
+		}
+	case "bench":
+		if fs.NArg() > 0 || strings.TrimSpace(*dataset) == "" {
+			return Options{}, fmt.Errorf("bench: expected `bench --dataset dir [--models a,b]`")
+		}
+	case "standup":
+		if fs.NArg() > 0 {
+			return Options{}, fmt.Errorf("standup: expected `standup [--since yesterday] [--author me] [--repos dir,...]`")
+		}
+	case "bot":
+		if fs.NArg() > 0 {
+			return Options{}, fmt.Errorf("bot: expected `bot [flags]`; stage the changes to describe first")
+		}
+	case "msg":
+		if fs.NArg() > 0 {
+			return Options{}, fmt.Errorf("msg: expected `msg [flags]`; stage the changes to describe first")
+		}
+	case "version":
+		if fs.NArg() > 0 {
+			return Options{}, fmt.Errorf("version: expected `version [--json]`")
+		}
+	case "push-check":
+		// git passes the remote name and its URL
+		if fs.NArg() > 2 {
+			return Options{}, fmt.Errorf("push-check: expected `push-check [remote [url]]`")
+		}
+	case "config":
+		switch action := fs.Arg(0); {
+		case action == "get" && fs.NArg() == 2, action == "set" && fs.NArg() >= 3:
+		case (action == "list" || action == "validate" || action == "doctor") && fs.NArg() == 1:
+		default:
+			return Options{}, fmt.Errorf("config: expected `config get KEY`, `config set KEY VALUE...`, `config list`, `config validate` or `config doctor`")
+		}
+	case "cache":
+		if fs.NArg() != 1 || (fs.Arg(0) != "clear" && fs.Arg(0) != "stats") {
+			return Options{}, fmt.Errorf("cache: expected `cache clear` or `cache stats`")
+		}
+	case "debug":
+		if fs.NArg() < 1 || fs.NArg() > 2 || fs.Arg(0) != "capture" {
+			return Options{}, fmt.Errorf("debug: expected `debug capture [file.tar.gz]`")
+		}
+	}
+	// config set, list and validate read the files themselves, so a broken
+	// file can still be inspected and fixed
+	var (
+		selectedProfile string
+		err             error
+	)
+	if command != "config" || fs.Arg(0) == "get" || fs.Arg(0) == "doctor" {
+		if selectedProfile, err = loadConfig(fs, *configPath, strings.TrimSpace(*profile)); err != nil {
+			return Options{}, fmt.Errorf("load config: %w (check it with `go-commitgen config validate`)", err)
+		}
+	}
+	sampling := []struct {
+		key, value string
+		integer    bool
+	}{
+		{key: "temperature", value: *temperature},
+		{key: "top_p", value: *topP},
+		{key: "num_predict", value: *numPredict, integer: true},
+		{key: "seed", value: *seed, integer: true},
+	}
+	for _, opt := range sampling {
+		if strings.TrimSpace(opt.value) == "" {
+			continue
+		}
+		v, err := parseNumber(opt.value, opt.integer)
+		if err != nil {
+			return Options{}, fmt.Errorf("invalid %s %q: %w", opt.key, opt.value, err)
+		}
+		llmOptions[opt.key] = v
+	}
+	var keywords map[string]string
+	if *issueKeyword {
+		var err error
+		if keywords, err = parseKeywords(*issueKeywords); err != nil {
+			return Options{}, fmt.Errorf("invalid --issue-keywords: %w", err)
+		}
+	}
+	aliases, err := parsePairs(*typeAliases)
+	if err != nil {
+		return Options{}, fmt.Errorf("invalid --type-aliases: %w", err)
+	}
+	conventions, err := commit.NewConventions(splitList(*types), aliases)
+	if err != nil {
+		return Options{}, fmt.Errorf("invalid commit types: %w", err)
+	}
+	conventions.Sections = *sections
+	conventions.DropRedundantBody = *dropRedundantBody
+	if *noBody && *sections {
+		return Options{}, fmt.Errorf("--no-body and --sections cannot be combined: sections are the body")
+	}
+	if *maxHeadline != 0 && *maxHeadline < 20 {
+		return Options{}, fmt.Errorf("--max-headline must be 0 or at least 20, got %d", *maxHeadline)
+	}
+	if *maxDescription < 10 {
+		return Options{}, fmt.Errorf("--max-description must be at least 10, got %d", *maxDescription)
+	}
+	if *maxSummary < 10 {
+		return Options{}, fmt.Errorf("--max-summary must be at least 10, got %d", *maxSummary)
+	}
+	if *maxBody < 0 {
+		return Options{}, fmt.Errorf("--max-body must not be negative, got %d", *maxBody)
+	}
+	conventions.NoBody = *noBody
+	conventions.MaxHeadline = *maxHeadline
+	conventions.MaxDescription = *maxDescription
+	conventions.MaxSummary = *maxSummary
+	conventions.MaxBody = *maxBody
+	scopesFromDirs := strings.TrimSpace(*scopes) == "auto"
+	if !scopesFromDirs {
+		conventions.Scopes = splitList(*scopes)
+	}
+	switch *scopeAction {
+	case "map", "retry":
+		conventions.ScopeRetry = *scopeAction == "retry"
+	default:
+		return Options{}, fmt.Errorf("--scope-action must be map or retry, got %q", *scopeAction)
+	}
+	switch {
+	case *headlineCase != "lower" && *headlineCase != "upper" && *headlineCase != "any":
+		return Options{}, fmt.Errorf("--headline-case must be lower, upper or any, got %q", *headlineCase)
+	case *bodyWidth < 0:
+		return Options{}, fmt.Errorf("--body-width must be >= 0, got %d", *bodyWidth)
+	}
+	switch *noise {
+	case "summarize", "drop", "keep":
+	default:
+		return Options{}, fmt.Errorf("--noise must be summarize, drop or keep, got %q", *noise)
+	}
+	if *notifyAfter < 0 {
+		return Options{}, fmt.Errorf("--notify-after must be >= 0, got %s", *notifyAfter)
+	}
+	if *cacheTTL < 0 {
+		return Options{}, fmt.Errorf("--cache-ttl must be >= 0, got %s", *cacheTTL)
+	}
+	if *createIssues && !*runReview {
+		return Options{}, fmt.Errorf("--review-create-issues needs --review: the issues come from its findings")
+	}
+	switch *findingsInBody {
+	case "none", "section", "trailers":
+	default:
+		return Options{}, fmt.Errorf("--findings-in-body must be none, section or trailers, got %q", *findingsInBody)
+	}
+	if *criticMin < 1 || *criticMin > 10 {
+		return Options{}, fmt.Errorf("--critic-threshold must be between 1 and 10, got %d", *criticMin)
+	}
+	if *criticRetries < 0 {
+		return Options{}, fmt.Errorf("--critic-retries must be >= 0, got %d", *criticRetries)
+	}
+	if strings.TrimSpace(*judgeModel) != "" && len(splitList(*models)) < 2 {
+		return Options{}, fmt.Errorf("--judge-model needs at least two --models to judge")
+	}
+	gpuBytes, err := parseSize(*gpuMemory)
+	if err != nil {
+		return Options{}, fmt.Errorf("--gpu-memory must be a size like 24GiB or 8000MiB, got %q", *gpuMemory)
+	}
+	if u := strings.TrimSpace(*webhookURL); u != "" && !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
+		return Options{}, fmt.Errorf("--webhook-url must be an http(s) URL")
+	}
+	if _, err := webhook.Parse(*webhookText); err != nil {
+		return Options{}, fmt.Errorf("invalid --webhook-template: %w", err)
+	}
+	for _, format := range splitList(*emit) {
+		switch format {
+		case "headline", "body", "conventional", "markdown":
+		default:
+			return Options{}, fmt.Errorf("--emit formats must be headline, body, conventional or markdown, got %q", format)
+		}
+	}
+	if *emit != "" && *porcelain {
+		return Options{}, fmt.Errorf("--emit cannot be combined with --porcelain")
+	}
+	if len(splitList(*autoModels)) > 0 && gpuBytes == 0 {
+		return Options{}, fmt.Errorf("--auto-models needs --gpu-memory to know what fits")
+	}
+	for _, t := range trailerTemplates {
+		if _, err := commit.ParseTrailer(t); err != nil {
+			return Options{}, fmt.Errorf("--trailer: %w", err)
+		}
+	}
+	// standup takes a `git log --author` pattern instead
+	if a := strings.TrimSpace(*author); command != "standup" && a != "" && (!strings.Contains(a, "<") || !strings.HasSuffix(a, ">") || strings.Contains(a, "<>")) {
+		return Options{}, fmt.Errorf("--author must be \"Name <email>\", got %q", a)
+	}
+	if *strict && *offline {
+		return Options{}, fmt.Errorf("--strict and --offline-fallback cannot be combined: strict refuses the file-stats message the fallback writes")
+	}
+	if _, ok := prompt.LookupTone(*toneName); *toneName != "" && !ok {
+		return Options{}, fmt.Errorf("--tone must be concise, detailed, casual or formal, got %q", *toneName)
+	}
+	conventions.Style = commit.Style{Imperative: *imperative, KeepPeriod: *keepPeriod, Case: *headlineCase, NoEmoji: *noEmoji, BodyWidth: *bodyWidth}
+	linters, err := buildLinters(splitList(*linterNames), customLinters)
+	if err != nil {
+		return Options{}, err
+	}
+	headers := map[string]string{}
+	for _, h := range headerFlags {
+		key, value, ok := strings.Cut(h, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return Options{}, fmt.Errorf("invalid --header %q, expected key=value", h)
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if *format != "schema" && *format != "json" && *format != "none" {
+		return Options{}, fmt.Errorf("--format must be schema, json or none, got %q", *format)
+	}
+	if *api != "generate" && *api != "chat" {
+		return Options{}, fmt.Errorf("--api must be generate or chat, got %q", *api)
+	}
+	switch *vcs {
+	case "auto", "git", "jj", "sl":
+	default:
+		return Options{}, fmt.Errorf("--vcs must be auto, git, jj or sl, got %q", *vcs)
+	}
+	blockOnSeverity := *blockOn
+	switch *blockOn {
+	case "high", "medium", "low":
+	case "none":
+		blockOnSeverity = ""
+	default:
+		return Options{}, fmt.Errorf("--block-on must be high, medium, low or none, got %q", *blockOn)
+	}
+	switch *manager {
+	case "git", "husky", "lefthook":
+	default:
+		return Options{}, fmt.Errorf("--manager must be git, husky or lefthook, got %q", *manager)
+	}
+	if *allowEmpty && strings.TrimSpace(*intent) == "" {
+		return Options{}, fmt.Errorf("--allow-empty requires --context")
+	}
+	if *audience != "users" && *audience != "developers" {
+		return Options{}, fmt.Errorf("--audience must be users or developers, got %q", *audience)
+	}
+	if *summaryStyle != "bullets" && *summaryStyle != "paragraph" {
+		return Options{}, fmt.Errorf("--style must be bullets or paragraph, got %q", *summaryStyle)
+	}
+	if *similarity < 0 || *similarity > 100 {
+		return Options{}, fmt.Errorf("--similarity must be between 0 and 100, got %d", *similarity)
+	}
+	if *history < 0 {
+		return Options{}, fmt.Errorf("--history must be >= 0, got %d", *history)
+	}
+	githubToken := envOr("GITHUB_TOKEN", os.Getenv("GH_TOKEN"))
+	switch *forgeKind {
+	case "auto":
+	case "github":
+		if *postToPR && githubToken == "" {
+			return Options{}, fmt.Errorf("--post-to-pr needs a token in GITHUB_TOKEN or GH_TOKEN")
+		}
+	case "gitlab":
+		if *postToPR && os.Getenv("GITLAB_TOKEN") == "" {
+			return Options{}, fmt.Errorf("--post-to-pr needs a token in GITLAB_TOKEN")
+		}
+	case "gitea":
+		if *postToPR && envOr("GITEA_TOKEN", os.Getenv("FORGEJO_TOKEN")) == "" {
+			return Options{}, fmt.Errorf("--post-to-pr needs a token in GITEA_TOKEN or FORGEJO_TOKEN")
+		}
+	default:
+		return Options{}, fmt.Errorf("--forge must be auto, github, gitlab or gitea, got %q", *forgeKind)
+	}
+	if _, err := logging.ParseLevel(*logLevel); err != nil {
+		return Options{}, fmt.Errorf("--log-level must be debug, info, warn or error, got %q", *logLevel)
+	}
+	switch *logFormat {
+	case "text", "json":
+	default:
+		return Options{}, fmt.Errorf("--log-format must be text or json, got %q", *logFormat)
+	}
+	if *fewShot < 0 {
+		return Options{}, fmt.Errorf("--few-shot must be >= 0, got %d", *fewShot)
+	}
+	if *repoContext < 0 {
+		return Options{}, fmt.Errorf("--repo-context must be >= 0, got %d", *repoContext)
+	}
+	if *rateLimit < 0 || *maxConcurrent < 0 {
+		return Options{}, fmt.Errorf("--rate-limit and --max-concurrent must be >= 0")
+	}
+	if *maxIdleConns < 1 {
+		return Options{}, fmt.Errorf("--max-idle-conns must be >= 1, got %d", *maxIdleConns)
+	}
+	switch *denyAction {
+	case "retry", "fail":
+	default:
+		return Options{}, fmt.Errorf("--deny-action must be retry or fail, got %q", *denyAction)
+	}
+	if *repeatCheck < 0 {
+		return Options{}, fmt.Errorf("--repeat-check must be >= 0, got %d", *repeatCheck)
+	}
+	if *lintRetries < 0 {
+		return Options{}, fmt.Errorf("--lint-retries must be >= 0, got %d", *lintRetries)
+	}
+	if *idleTimeout < 0 {
+		return Options{}, fmt.Errorf("--llm-idle-timeout must be >= 0, got %s", *idleTimeout)
+	}
+	if *gitTimeout < 0 {
+		return Options{}, fmt.Errorf("--git-timeout must be >= 0, got %s", *gitTimeout)
+	}
 
 	opts := Options{
-		Model:        stringsFallback(*model, defaultModel),
-		ReviewModel:  stringsFallback(*reviewModel, *model),
-		Endpoint:     stringsFallback(*endpoint, defaultEndpoint),
-		MaxBytes:     *maxBytes,
-		Commit:       *commitNow,
-		Review:       *runReview,
-		HookPath:     *hookPath,
-		Timeout:      *timeout,
-		Args:         fs.Args(),
-		RawFlagSet:   fs,
-		DisplayUsage: fs.Usage,
+		Command:        command,
+		Profile:        selectedProfile,
+		Model:          stringsFallback(*model, defaultModel),
+		ReviewModel:    stringsFallback(*reviewModel, *model),
+		Endpoint:       stringsFallback(*endpoint, defaultEndpoint),
+		VCS:            *vcs,
+		API:            *api,
+		Format:         *format,
+		StripThink:     *stripThink,
+		ModelDefaults:  *modelfileDefaults,
+		CAFile:         strings.TrimSpace(*caFile),
+		CertFile:       strings.TrimSpace(*certFile),
+		KeyFile:        strings.TrimSpace(*keyFile),
+		Insecure:       *insecure,
+		APIKey:         strings.TrimSpace(*apiKey),
+		Headers:        headers,
+		MaxBytes:       *maxBytes,
+		Commit:         *commitNow,
+		Review:         *runReview,
+		HookPath:       *hookPath,
+		HookSource:     strings.TrimSpace(*hookSource),
+		HookManager:    *manager,
+		PrePush:        *prePush,
+		BlockOn:        blockOnSeverity,
+		Trunk:          strings.TrimSpace(*trunk),
+		Force:          *force,
+		AllowUnsigned:  *allowUnsigned,
+		VersionJSON:    *versionJSON,
+		ExplainFile:    strings.TrimSpace(*explainFile),
+		Author:         strings.TrimSpace(*author),
+		UpdateCheck:    *updateCheck,
+		Offline:        *offline,
+		Strict:         *strict,
+		RepairJSON:     *repairJSON,
+		Copy:           *copyMessage,
+		Notify:         *notify,
+		WebhookURL:     strings.TrimSpace(*webhookURL),
+		WebhookText:    *webhookText,
+		RequireSignoff: *requireSignoff,
+		NotifyAfter:    *notifyAfter,
+		Cache:          *useCache,
+		CacheTTL:       *cacheTTL,
+		CacheDir:       strings.TrimSpace(*cacheDir),
+		Yes:            *yes,
+		Since:          strings.TrimSpace(*since),
+		Repos:          expandHome(splitList(*repos)),
+		Dataset:        strings.TrimSpace(*dataset),
+		BenchHistory:   strings.TrimSpace(*benchHistory),
+		Against:        strings.TrimSpace(*against),
+		PostToPR:       *postToPR,
+		GitHubToken:    githubToken,
+		GitHubAPI:      os.Getenv("GITHUB_API_URL"),
+		Forge:          *forgeKind,
+		GitLabToken:    os.Getenv("GITLAB_TOKEN"),
+		GiteaToken:     envOr("GITEA_TOKEN", os.Getenv("FORGEJO_TOKEN")),
+		JiraURL:        strings.TrimRight(strings.TrimSpace(*jiraURL), "/"),
+		JiraUser:       os.Getenv("JIRA_USER"),
+		JiraToken:      os.Getenv("JIRA_API_TOKEN"),
+		Audience:       *audience,
+		AllowEmpty:     *allowEmpty,
+		Context:        strings.TrimSpace(*intent),
+		IntentMarks:    *intentMarkers || *stripMarkers,
+		StripMarks:     *stripMarkers,
+		Porcelain:      *porcelain,
+		Emit:           splitList(*emit),
+		Stdio:          *stdio,
+		RateLimit:      *rateLimit,
+		MaxConcurrent:  *maxConcurrent,
+		MaxIdleConns:   *maxIdleConns,
+		OTLPEndpoint:   strings.TrimSpace(*otlpEndpoint),
+		Log:            logging.Config{Level: *logLevel, Format: *logFormat, File: strings.TrimSpace(*logFile)},
+		KeepAlive:      strings.TrimSpace(*keepAlive),
+		MetricsAddr:    strings.TrimSpace(*metricsAddr),
+		Timeout:        *timeout,
+		GitTimeout:     *gitTimeout,
+		IdleTimeout:    *idleTimeout,
+		LintRetries:    *lintRetries,
+		History:        *history,
+		RepeatCheck:    *repeatCheck,
+		Denylist:       splitList(*denylist),
+		DenyFail:       *denyAction == "fail",
+		Untracked:      *untracked,
+		UntrackedMax:   *untrackedMax,
+		LLMOptions:     llmOptions,
+		Summarize:      *summarize,
+		IgnoreSpace:    *ignoreSpace,
+		Similarity:     *similarity,
+		MoveLines:      *moveLines,
+		Noise:          *noise,
+		MinifyDiff:     *minifyDiff,
+		PostProcess:    postProcess,
+		Trailers:       trailerTemplates,
+		DiffFile:       strings.TrimSpace(*diffFile),
+		RecordStats:    *recordStats,
+		StatsFile:      *statsFile,
+		SummaryStyle:   *summaryStyle,
+		IssueKeyword:   keywords,
+		Conventions:    conventions,
+		ScopesFromDirs: scopesFromDirs,
+		SkipSmall:      *skipSmall,
+		SmallBytes:     *smallBytes,
+		SmallModel:     strings.TrimSpace(*smallModel),
+		LargeBytes:     *largeBytes,
+		EscalateTo:     strings.TrimSpace(*escalateTo),
+		Linters:        linters,
+		GoSymbols:      *goSymbols,
+		BlameContext:   *blameContext,
+		FewShot:        *fewShot,
+		RecordExamples: *recordExamples,
+		ExamplesFile:   *examplesFile,
+		RepoContext:    *repoContext,
+		EmbedModel:     strings.TrimSpace(*embedModel),
+		Tone:           *toneName,
+		Polish:         *polish,
+		PolishModel:    *polishModel,
+		Models:         splitList(*models),
+		JudgeModel:     strings.TrimSpace(*judgeModel),
+		FindingsInBody: *findingsInBody,
+		CreateIssues:   *createIssues,
+		IssueLabels:    splitList(*issueLabels),
+		Critic:         *critic,
+		CriticModel:    strings.TrimSpace(*criticModel),
+		CriticMin:      *criticMin,
+		CriticRetries:  *criticRetries,
+		ReuseContext:   *reuseContext,
+		AutoModels:     splitList(*autoModels),
+		GPUMemory:      gpuBytes,
+		Args:           fs.Args(),
+		RawFlagSet:     fs,
+		DisplayUsage:   fs.Usage,
+	}
+
+	if opts.DiffFile != "" {
+		opts.Commit = false
+	}
+	if len(opts.Models) > 0 {
+		opts.Model = opts.Models[0]
+	}
+	// positional arguments of the default flow are pathspecs
+	// (go-commitgen -- a.go b.go); subcommands interpret them themselves
+	if command == "" {
+		opts.Paths = opts.Args
+	}
+	// msg is for command substitution: nothing but the headline may reach
+	// stdout, so everything that prints, waits or commits is off
+	if command == "msg" {
+		opts.Commit, opts.Review, opts.Critic = false, false, false
+		opts.Porcelain, opts.Copy, opts.Notify = false, false, false
+	}
+	// bot runs unattended: no prompts, no terminal, and the same answer for
+	// the same diff unless the sampling is set explicitly
+	if command == "bot" {
+		opts.Review, opts.Critic, opts.Porcelain, opts.Copy, opts.Notify = false, false, false, false, false
+		for key, value := range map[string]interface{}{"temperature": 0.0, "seed": int64(0)} {
+			if _, ok := opts.LLMOptions[key]; !ok {
+				opts.LLMOptions[key] = value
+			}
+		}
+	}
+	if command == "bench" {
+		// every model must answer every sample itself
+		opts.Commit, opts.Review, opts.Cache = false, false, false
+	}
+	if command == "standup" {
+		opts.Since = stringsFallback(opts.Since, "yesterday")
+		// the configured user.email of each repository
+		if opts.Author == "me" {
+			opts.Author = ""
+		}
+	}
+	if command == "debug" {
+		opts.CaptureFile = stringsFallback(fs.Arg(1), defaultCaptureFile)
+		opts.Commit = false
+	}
+	if command == "push-check" {
+		opts.PushRemote = stringsFallback(fs.Arg(0), "origin")
+	}
+	if command == "cache" {
+		opts.CacheAction = fs.Arg(0)
+	}
+	if command == "config" {
+		opts.ConfigAction, opts.Args = fs.Arg(0), fs.Args()[1:]
+	}
+	if command == "stash-pop" {
+		if opts.Stash, err = stashIndex(opts.Args); err != nil {
+			return Options{}, err
+		}
 	}
 
 	return opts, nil
 }
 
+// stashIndex reads the stash entry of "stash-pop [n]": a number or
+// stash@{n}, the latest entry by default.
+func stashIndex(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	arg := strings.TrimSuffix(strings.TrimPrefix(args[0], "stash@{"), "}")
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 || len(args) > 1 {
+		return 0, fmt.Errorf("stash-pop takes one stash index, e.g. stash-pop 2 or stash-pop stash@{2}, got %q", strings.Join(args, " "))
+	}
+	return n, nil
+}
+
+// stringsFlag collects repeated string flags.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// keyValueFlag collects repeated key=value flags, inferring numeric and
+// boolean values so they reach the provider with the right JSON type.
+type keyValueFlag map[string]interface{}
+
+func (f keyValueFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for k, v := range f {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, v))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f keyValueFlag) Set(value string) error {
+	key, raw, ok := strings.Cut(value, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	raw = strings.TrimSpace(raw)
+
+	switch {
+	case raw == "true" || raw == "false":
+		f[key] = raw == "true"
+	default:
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			f[key] = n
+		} else if x, err := strconv.ParseFloat(raw, 64); err == nil {
+			f[key] = x
+		} else {
+			f[key] = raw
+		}
+	}
+	return nil
+}
+
+func buildLinters(presets, custom []string) ([]linter.Linter, error) {
+	var out []linter.Linter
+	for _, name := range presets {
+		l, ok := linter.Presets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown linter preset %q", name)
+		}
+		out = append(out, l)
+	}
+	for _, spec := range custom {
+		l, err := linter.Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --linter: %w", err)
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+// parseKeywords reads "type=Keyword,..." pairs, defaulting to the built-in
+// mapping when value is empty.
+func parseKeywords(value string) (map[string]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return commit.DefaultIssueKeywords, nil
+	}
+	return parsePairs(value)
+}
+
+// parsePairs reads comma separated key=value pairs; keys are lower cased.
+func parsePairs(value string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, pair := range splitList(value) {
+		key, v, ok := strings.Cut(pair, "=")
+		key, v = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(v)
+		if !ok || key == "" || v == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		out[key] = v
+	}
+	return out, nil
+}
+
+// expandHome replaces a leading ~/ of each path with the home directory,
+// which the shell does not do after a comma or in the config file.
+func expandHome(paths []string) []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return paths
+	}
+	for i, p := range paths {
+		if rest, ok := strings.CutPrefix(p, "~/"); ok {
+			paths[i] = filepath.Join(home, rest)
+		}
+	}
+	return paths
+}
+
+// splitList splits a comma separated value, dropping empty items.
+func splitList(value string) []string {
+	var out []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// sizeUnits are the suffixes parseSize accepts; GB and GiB alike are
+// powers of 1024, as GPU memory is quoted.
+var sizeUnits = map[string]int64{"": 1 << 20, "b": 1, "k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10, "m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20, "g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30, "t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40}
+
+// parseSize reads a byte size such as 24GiB, 8000MiB or 12.5G; a bare
+// number is MiB. Empty is zero.
+func parseSize(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	end := strings.LastIndexAny(value, "0123456789.") + 1
+	n, err := strconv.ParseFloat(value[:end], 64)
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(value[end:]))]
+	if err != nil || !ok || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+func parseNumber(value string, integer bool) (interface{}, error) {
+	value = strings.TrimSpace(value)
+	if integer {
+		return strconv.ParseInt(value, 10, 64)
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
 func envOr(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -83,6 +1050,36 @@ func intFromEnv(key string, fallback int) int {
 	return fallback
 }
 
+func boolFromEnv(key string, fallback bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+func floatFromEnv(key string, fallback float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+// otlpFromEnv honours the standard OpenTelemetry exporter variables: the
+// traces endpoint is used as-is, the base endpoint gets /v1/traces.
+func otlpFromEnv() string {
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); v != "" {
+		return v
+	}
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); v != "" {
+		return strings.TrimRight(v, "/") + "/v1/traces"
+	}
+	return ""
+}
+
 func durationFromEnv(key string, fallback time.Duration) time.Duration {
 	if v := os.Getenv(key); v != "" {
 		var d time.Duration
@@ -0,0 +1,193 @@
+package diff
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// File is the part of a unified diff that belongs to a single path.
+type File struct {
+	Path string
+	Text string
+}
+
+// SplitFiles breaks a git unified diff into per-file sections.
+func SplitFiles(d string) []File {
+	var (
+		files   []File
+		current *File
+		text    strings.Builder
+	)
+	flush := func() {
+		if current != nil {
+			current.Text = text.String()
+			files = append(files, *current)
+			text.Reset()
+		}
+	}
+
+	for _, line := range strings.SplitAfter(d, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			current = &File{Path: pathFromHeader(line)}
+		}
+		if current == nil {
+			// preamble before the first header (e.g. mail headers of a patch)
+			current = &File{}
+		}
+		text.WriteString(line)
+	}
+	flush()
+
+	return files
+}
+
+// Cut reports what keeping only the first n bytes of d leaves out: the
+// files that start past the cut, and the one cut short, if any.
+func Cut(d string, n int) (partial string, dropped []string) {
+	offset := 0
+	for _, f := range SplitFiles(d) {
+		start, end := offset, offset+len(f.Text)
+		offset = end
+		switch {
+		case f.Path == "" || end <= n:
+		case start >= n:
+			dropped = append(dropped, f.Path)
+		default:
+			partial = f.Path
+		}
+	}
+	return partial, dropped
+}
+
+// Chunk packs files into groups whose combined text stays within max bytes.
+// A single file larger than max is trimmed and gets a chunk of its own.
+func Chunk(files []File, max int) [][]File {
+	var (
+		chunks [][]File
+		group  []File
+		size   int
+	)
+	for _, f := range files {
+		if max > 0 && len(f.Text) > max {
+			f.Text = util.TrimTo(f.Text, max)
+		}
+		if len(group) > 0 && max > 0 && size+len(f.Text) > max {
+			chunks = append(chunks, group)
+			group, size = nil, 0
+		}
+		group = append(group, f)
+		size += len(f.Text)
+	}
+	if len(group) > 0 {
+		chunks = append(chunks, group)
+	}
+	return chunks
+}
+
+// Join concatenates file sections back into a single diff.
+func Join(files []File) string {
+	var b strings.Builder
+	for _, f := range files {
+		b.WriteString(f.Text)
+	}
+	return b.String()
+}
+
+func pathFromHeader(line string) string {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "diff --git "))
+	if idx := strings.LastIndex(line, " b/"); idx != -1 {
+		return line[idx+3:]
+	}
+	if fields := strings.Fields(line); len(fields) > 0 {
+		return strings.TrimPrefix(fields[len(fields)-1], "b/")
+	}
+	return line
+}
+
+// LineRange is an inclusive range of lines in the post-image of a file.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+var (
+	hunkHeader    = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+	oldHunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+\d+(?:,\d+)? @@`)
+)
+
+// ChangedRanges returns the post-image line ranges touched by each hunk of a
+// file diff. Pure deletions yield a one-line range at the deletion point.
+func ChangedRanges(fileDiff string) []LineRange {
+	return ranges(fileDiff, hunkHeader)
+}
+
+// OriginalRanges returns the pre-image line ranges each hunk of a file diff
+// replaces. Pure additions yield the line they follow; additions at the
+// top of the file yield nothing.
+func OriginalRanges(fileDiff string) []LineRange {
+	var out []LineRange
+	for _, r := range ranges(fileDiff, oldHunkHeader) {
+		if r.Start > 0 {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// RemovedRanges returns the pre-image line ranges a file diff removes or
+// rewrites, one per run of "-" lines. Context lines and pure additions are
+// left out.
+func RemovedRanges(fileDiff string) []LineRange {
+	var (
+		out    []LineRange
+		old    int
+		inHunk bool
+	)
+	for _, line := range strings.Split(fileDiff, "\n") {
+		if m := oldHunkHeader.FindStringSubmatch(line); m != nil {
+			old, _ = strconv.Atoi(m[1])
+			inHunk = true
+			continue
+		}
+		if !inHunk || line == "" {
+			continue
+		}
+		switch line[0] {
+		case '-':
+			if n := len(out); n > 0 && out[n-1].End == old-1 {
+				out[n-1].End = old
+			} else {
+				out = append(out, LineRange{Start: old, End: old})
+			}
+			old++
+		case ' ':
+			old++
+		}
+	}
+	return out
+}
+
+func ranges(fileDiff string, header *regexp.Regexp) []LineRange {
+	var out []LineRange
+	for _, line := range strings.Split(fileDiff, "\n") {
+		m := header.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		start, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+		end := start + count - 1
+		if count == 0 {
+			end = start
+		}
+		out = append(out, LineRange{Start: start, End: end})
+	}
+	return out
+}
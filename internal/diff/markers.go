@@ -0,0 +1,85 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Marker is a "TODO(commit): ..." or "WHY: ..." comment added by the diff
+// to tell the commit message generator why the change was made.
+type Marker struct {
+	File string
+	// Line is the line number in the staged version of File.
+	Line int
+	Kind string
+	Text string
+	// Source is the whole added line, used to strip the marker again.
+	Source string
+	// Comment is the comment part of Source ("// WHY: ...").
+	Comment string
+}
+
+func (m Marker) String() string {
+	return fmt.Sprintf("%s (%s:%d)", m.Text, m.File, m.Line)
+}
+
+// markerComment matches the marker in the common line comment syntaxes
+// (//, #, --, ;) and in single-line block comments.
+var markerComment = regexp.MustCompile(`(?://+|#+|--|;+|/\*+)\s*(TODO\(commit\)|WHY):\s*(.*?)\s*(?:\*+/)?\s*$`)
+
+// Markers collects the intent markers on the lines added by d.
+func Markers(d string) []Marker {
+	var out []Marker
+	for _, f := range SplitFiles(d) {
+		line := 0
+		for _, text := range strings.Split(f.Text, "\n") {
+			if m := hunkHeader.FindStringSubmatch(text); m != nil {
+				line, _ = strconv.Atoi(m[1])
+				continue
+			}
+			switch {
+			case strings.HasPrefix(text, "+++"), strings.HasPrefix(text, "---"), strings.HasPrefix(text, "-"):
+				continue
+			case strings.HasPrefix(text, "+"):
+				src := text[1:]
+				if loc := markerComment.FindStringSubmatchIndex(src); loc != nil && line > 0 {
+					if what := strings.TrimSpace(src[loc[4]:loc[5]]); what != "" {
+						out = append(out, Marker{
+							File:    f.Path,
+							Line:    line,
+							Kind:    src[loc[2]:loc[3]],
+							Text:    what,
+							Source:  src,
+							Comment: src[loc[0]:],
+						})
+					}
+				}
+				line++
+			case strings.HasPrefix(text, " "):
+				line++
+			}
+		}
+	}
+	return out
+}
+
+// StripMarker removes m from content: a line holding only the marker is
+// deleted, a trailing marker comment is cut off its code line. It reports
+// false when line m.Line no longer holds the marker.
+func StripMarker(content string, m Marker) (string, bool) {
+	lines := strings.SplitAfter(content, "\n")
+	i := m.Line - 1
+	if i < 0 || i >= len(lines) || strings.TrimRight(lines[i], "\r\n") != m.Source {
+		return content, false
+	}
+
+	code := strings.TrimRight(strings.TrimSuffix(m.Source, m.Comment), " \t")
+	if strings.TrimSpace(code) == "" {
+		lines = append(lines[:i], lines[i+1:]...)
+	} else {
+		lines[i] = code + lines[i][len(m.Source):]
+	}
+	return strings.Join(lines, ""), true
+}
@@ -0,0 +1,141 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxContextRun is the longest run of unchanged lines Minify keeps whole.
+const maxContextRun = 3
+
+var minifyHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@ ?(.*)$`)
+
+// Minify rewrites d into a denser form that only the model reads:
+//   - hunk headers keep the post-image start line and the enclosing
+//     declaration, dropping the pre-image range and the line counts;
+//   - index lines, whose blob ids mean nothing to the model, are dropped;
+//   - runs of more than three unchanged lines keep their first and last
+//     line around a note of how many were left out;
+//   - the indentation every line of a hunk shares is removed;
+//   - lines longer than maxLine bytes are cut (0 keeps them whole).
+//
+// The result no longer applies as a patch nor parses with ChangedRanges.
+func Minify(d string, maxLine int) string {
+	var b strings.Builder
+	for _, f := range SplitFiles(d) {
+		var (
+			hunk   []string
+			inHunk bool
+		)
+		for _, line := range strings.SplitAfter(f.Text, "\n") {
+			switch {
+			case line == "":
+			case strings.HasPrefix(line, "@@"):
+				writeHunk(&b, hunk, maxLine)
+				hunk, inHunk = nil, true
+				b.WriteString(minifyHunkHeader(line))
+			case inHunk:
+				hunk = append(hunk, line)
+			case strings.HasPrefix(line, "index "):
+			default:
+				b.WriteString(line)
+			}
+		}
+		writeHunk(&b, hunk, maxLine)
+	}
+	return b.String()
+}
+
+func minifyHunkHeader(line string) string {
+	m := minifyHeader.FindStringSubmatch(strings.TrimRight(line, "\n"))
+	if m == nil {
+		// combined diffs (@@@) keep their header
+		return line
+	}
+	if m[2] == "" {
+		return "@@ +" + m[1] + " @@\n"
+	}
+	return "@@ +" + m[1] + " @@ " + m[2] + "\n"
+}
+
+// writeHunk writes the body lines of one hunk, each starting with its
+// marker (' ', '+', '-' or '\').
+func writeHunk(b *strings.Builder, lines []string, maxLine int) {
+	indent := sharedIndent(lines)
+	for i := 0; i < len(lines); {
+		run := i
+		for run < len(lines) && unchanged(lines[run]) {
+			run++
+		}
+		if run-i > maxContextRun {
+			writeHunkLine(b, lines[i], indent, maxLine)
+			fmt.Fprintf(b, " … %d unchanged lines\n", run-i-2)
+			writeHunkLine(b, lines[run-1], indent, maxLine)
+			i = run
+			continue
+		}
+		if run == i {
+			run++
+		}
+		for ; i < run; i++ {
+			writeHunkLine(b, lines[i], indent, maxLine)
+		}
+	}
+}
+
+func writeHunkLine(b *strings.Builder, line, indent string, maxLine int) {
+	content := strings.TrimRight(line, "\n")
+	if content == "" {
+		// a blank context line whose marker an editor stripped
+		b.WriteString(" \n")
+		return
+	}
+	marker, code := content[:1], content[1:]
+	if marker != `\` {
+		code = strings.TrimPrefix(code, indent)
+	}
+	if maxLine > 0 && len(code) > maxLine {
+		cut := maxLine
+		for cut > 0 && !utf8.RuneStart(code[cut]) {
+			cut--
+		}
+		code = code[:cut] + "…"
+	}
+	b.WriteString(marker + code + "\n")
+}
+
+// sharedIndent returns the leading whitespace every non-blank line of the
+// hunk starts with.
+func sharedIndent(lines []string) string {
+	var (
+		indent string
+		found  bool
+	)
+	for _, line := range lines {
+		content := strings.TrimRight(line, "\n")
+		if content == "" || content[0] == '\\' || strings.TrimSpace(content[1:]) == "" {
+			continue
+		}
+		code := content[1:]
+		lead := code[:len(code)-len(strings.TrimLeft(code, " \t"))]
+		if !found {
+			indent, found = lead, true
+			continue
+		}
+		n := 0
+		for n < len(indent) && n < len(lead) && indent[n] == lead[n] {
+			n++
+		}
+		indent = indent[:n]
+		if indent == "" {
+			break
+		}
+	}
+	return indent
+}
+
+func unchanged(line string) bool {
+	return line == "\n" || strings.HasPrefix(line, " ")
+}
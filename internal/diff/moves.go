@@ -0,0 +1,197 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Move records a block of code removed in one place and added verbatim
+// (ignoring indentation) in another.
+type Move struct {
+	From  string
+	To    string
+	Name  string
+	Lines int
+}
+
+func (m Move) String() string {
+	if m.From == m.To {
+		return fmt.Sprintf("moved %s within %s", m.Name, m.From)
+	}
+	return fmt.Sprintf("moved %s from %s to %s", m.Name, m.From, m.To)
+}
+
+var declPattern = regexp.MustCompile(`^\s*(?:func|type|class|def|fn|function)\s+(?:\([^)]*\)\s*)?([A-Za-z_][A-Za-z0-9_]*)`)
+
+type block struct {
+	file  int
+	start int
+	end   int
+	key   string
+	first string
+	size  int
+}
+
+// DetectMoves finds removed blocks of at least minLines significant lines
+// (blank and punctuation-only lines do not count) that reappear as added
+// blocks, strips both sides from the diff, along with hunks left without a
+// changed line, and returns the moves so they can be described in one line
+// instead of duplicated hunks.
+func DetectMoves(d string, minLines int) (string, []Move) {
+	files := SplitFiles(d)
+	lines := make([][]string, len(files))
+	var removed, added []block
+	for i, f := range files {
+		lines[i] = strings.SplitAfter(f.Text, "\n")
+		removed = append(removed, blocks(lines[i], i, '-', minLines)...)
+		added = append(added, blocks(lines[i], i, '+', minLines)...)
+	}
+
+	drop := make([]map[int]bool, len(files))
+	for i := range drop {
+		drop[i] = map[int]bool{}
+	}
+	used := make([]bool, len(added))
+
+	var moves []Move
+	for _, r := range removed {
+		for j, a := range added {
+			if used[j] || a.key != r.key {
+				continue
+			}
+			if a.file == r.file && a.start == r.end {
+				// replaced in place, only the indentation changed
+				continue
+			}
+			used[j] = true
+			for n := r.start; n < r.end; n++ {
+				drop[r.file][n] = true
+			}
+			for n := a.start; n < a.end; n++ {
+				drop[a.file][n] = true
+			}
+			moves = append(moves, Move{
+				From:  files[r.file].Path,
+				To:    files[a.file].Path,
+				Name:  blockName(r),
+				Lines: r.size,
+			})
+			break
+		}
+	}
+	if len(moves) == 0 {
+		return d, nil
+	}
+
+	var b strings.Builder
+	for i := range files {
+		var kept []string
+		for n, line := range lines[i] {
+			if !drop[i][n] {
+				kept = append(kept, line)
+			}
+		}
+		for _, line := range withoutEmptyHunks(kept) {
+			b.WriteString(line)
+		}
+	}
+	return b.String(), moves
+}
+
+// significant reports whether a line says something of its own: blank and
+// punctuation-only lines (closing braces, "});") appear in every block and
+// would make unrelated code look moved.
+func significant(content string) bool {
+	for _, r := range content {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutEmptyHunks drops the hunks of a file diff that have no changed
+// line left, and the whole file when none has.
+func withoutEmptyHunks(lines []string) []string {
+	var (
+		out     []string
+		hunk    []string
+		changed bool
+		hunks   int
+	)
+	flush := func() {
+		if len(hunk) > 0 && changed {
+			out = append(out, hunk...)
+			hunks++
+		}
+		hunk, changed = nil, false
+	}
+	inHunk := false
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			inHunk = true
+			hunk = append(hunk, line)
+		case !inHunk:
+			out = append(out, line)
+		default:
+			hunk = append(hunk, line)
+			if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+				changed = true
+			}
+		}
+	}
+	flush()
+	if inHunk && hunks == 0 {
+		return nil
+	}
+	return out
+}
+
+func blocks(lines []string, file int, marker byte, minLines int) []block {
+	var (
+		out   []block
+		cur   *block
+		parts []string
+	)
+	closeBlock := func() {
+		if cur != nil && cur.size >= minLines {
+			cur.key = strings.Join(parts, "\n")
+			out = append(out, *cur)
+		}
+		cur, parts = nil, nil
+	}
+
+	for n, line := range lines {
+		isHeader := strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---")
+		if len(line) == 0 || line[0] != marker || isHeader {
+			closeBlock()
+			continue
+		}
+		if cur == nil {
+			cur = &block{file: file, start: n}
+		}
+		cur.end = n + 1
+		content := strings.TrimSpace(line[1:])
+		if !significant(content) {
+			continue
+		}
+		if cur.first == "" {
+			cur.first = content
+		}
+		parts = append(parts, content)
+		cur.size++
+	}
+	closeBlock()
+	return out
+}
+
+func blockName(b block) string {
+	if m := declPattern.FindStringSubmatch(b.first); len(m) == 2 {
+		return m[1]
+	}
+	return fmt.Sprintf("%d lines", b.size)
+}
@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectMoves(t *testing.T) {
+	tests := []struct {
+		name  string
+		diff  string
+		moves []string
+		kept  []string
+		gone  []string
+	}{
+		{
+			name: "error checks are no move",
+			diff: `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -10,6 +10,0 @@ func load() error {
+-	if err != nil {
+-		return err
+-	}
+-	if err != nil {
+-		return err
+-	}
+@@ -40,0 +34,6 @@ func save() error {
++	if err != nil {
++		return err
++	}
++	if err != nil {
++		return err
++	}
+`,
+			kept: []string{"@@ -10,6 +10,0 @@", "@@ -40,0 +34,6 @@"},
+		},
+		{
+			name: "closing braces are no move",
+			diff: `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -10,8 +10,0 @@
+-			}
+-		}
+-	}
+-}
+-		})
+-	})
+-})
+-}
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -3,0 +3,8 @@
++			}
++		}
++	}
++}
++		})
++	})
++})
++}
+`,
+			kept: []string{"diff --git a/a.go b/a.go", "diff --git a/b.go b/b.go"},
+		},
+		{
+			name: "moved function drops the emptied hunks",
+			diff: `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -10,8 +10,0 @@
+-func parse(s string) (int, error) {
+-	s = strings.TrimSpace(s)
+-	if s == "" {
+-		return 0, errEmpty
+-	}
+-	n, err := strconv.Atoi(s)
+-	return n, err
+-}
+@@ -30 +22 @@
+-const limit = 1
++const limit = 2
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -3,0 +3,8 @@
++func parse(s string) (int, error) {
++	s = strings.TrimSpace(s)
++	if s == "" {
++		return 0, errEmpty
++	}
++	n, err := strconv.Atoi(s)
++	return n, err
++}
+`,
+			moves: []string{"moved parse from a.go to b.go"},
+			kept:  []string{"@@ -30 +22 @@", "+const limit = 2"},
+			gone:  []string{"@@ -10,8 +10,0 @@", "diff --git a/b.go b/b.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, moves := DetectMoves(tt.diff, 6)
+			var got []string
+			for _, m := range moves {
+				got = append(got, m.String())
+			}
+			if strings.Join(got, "; ") != strings.Join(tt.moves, "; ") {
+				t.Errorf("moves = %q, want %q", got, tt.moves)
+			}
+			for _, s := range tt.kept {
+				if !strings.Contains(out, s) {
+					t.Errorf("diff lost %q:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.gone {
+				if strings.Contains(out, s) {
+					t.Errorf("diff still has %q:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
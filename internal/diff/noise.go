@@ -0,0 +1,221 @@
+package diff
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Noise kinds: hunks that change no behaviour and only cost prompt budget.
+const (
+	NoiseImports    = "imports"
+	NoiseWhitespace = "formatting"
+	NoiseComments   = "comments"
+)
+
+// Noise counts the hunks of one kind collapsed out of a file.
+type Noise struct {
+	Path  string
+	Kind  string
+	Hunks int
+}
+
+func (n Noise) String() string {
+	what := map[string]string{
+		NoiseImports:    "import-only",
+		NoiseWhitespace: "whitespace/formatting-only",
+		NoiseComments:   "comment-only",
+	}[n.Kind]
+	if n.Hunks == 1 {
+		return fmt.Sprintf("%s: 1 %s hunk", n.Path, what)
+	}
+	return fmt.Sprintf("%s: %d %s hunks", n.Path, n.Hunks, what)
+}
+
+var (
+	goImportLine  = regexp.MustCompile(`^\s*(?:import\s+)?(?:([\w.]+)\s+)?"[^"]*"\s*$`)
+	jsImportLine  = regexp.MustCompile(`^\s*(?:import\s.*|export\s.+\sfrom\s.*|(?:const|let|var)\s+.+=\s*require\(.*\);?)\s*$`)
+	pyImportLine  = regexp.MustCompile(`^\s*(?:import\s+\S.*|from\s+\S+\s+import\s+.*)$`)
+	useImportLine = regexp.MustCompile(`^\s*(?:import\s+(?:static\s+)?[\w.*]+;|use\s+.+;|#include\s+[<"].+[>"])\s*$`)
+)
+
+// goKeywords may precede a string literal in code, unlike an import alias.
+var goKeywords = map[string]bool{"return": true, "case": true, "go": true, "defer": true, "else": true, "var": true, "const": true}
+
+// importLine reports whether line of a file named p only imports names.
+func importLine(p, line string) bool {
+	switch path.Ext(p) {
+	case ".go":
+		if trimmed := strings.TrimSpace(line); trimmed == "import (" || trimmed == ")" {
+			return true
+		}
+		m := goImportLine.FindStringSubmatch(line)
+		return m != nil && !goKeywords[m[1]]
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs", ".vue", ".svelte":
+		return jsImportLine.MatchString(line)
+	case ".py":
+		return pyImportLine.MatchString(line)
+	case ".java", ".kt", ".scala", ".rs", ".php", ".c", ".h", ".cc", ".cpp", ".hpp":
+		return useImportLine.MatchString(line)
+	}
+	return false
+}
+
+// commentLine reports whether line of a file named p is a comment.
+func commentLine(p, line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return true
+	}
+	switch path.Ext(p) {
+	case ".py", ".sh", ".bash", ".rb", ".yaml", ".yml", ".toml", ".pl", ".r":
+		return strings.HasPrefix(line, "#")
+	case ".sql", ".lua", ".hs":
+		return strings.HasPrefix(line, "--")
+	case ".html", ".xml", ".md", ".markdown":
+		return false // prose is the content
+	}
+	return strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/*") || strings.HasPrefix(line, "*/") ||
+		line == "*" || strings.HasPrefix(line, "* ")
+}
+
+// indentSensitive are the file types where whitespace changes meaning.
+var indentSensitive = map[string]bool{".py": true, ".yaml": true, ".yml": true, ".mk": true, ".haml": true, ".pug": true, ".nim": true}
+
+// classify returns the noise kind of a hunk's changed lines, or "" when
+// the hunk changes code.
+func classify(p string, removed, added []string) string {
+	changed := append(append([]string{}, removed...), added...)
+	if len(changed) == 0 {
+		return ""
+	}
+	if squash(removed) == squash(added) && !indentSensitive[path.Ext(p)] && path.Base(p) != "Makefile" {
+		return NoiseWhitespace
+	}
+	all := func(match func(p, line string) bool) bool {
+		for _, line := range changed {
+			if strings.TrimSpace(line) != "" && !match(p, line) {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case all(importLine):
+		return NoiseImports
+	case all(commentLine):
+		return NoiseComments
+	}
+	return ""
+}
+
+// squash joins lines without the whitespace outside string and character
+// literals, so reindented, rewrapped or realigned code compares equal while
+// a changed literal does not. Quoted literals end with their line; a
+// backquoted one may span lines, whose breaks are then part of it.
+func squash(lines []string) string {
+	var b strings.Builder
+	raw := false
+	for _, line := range lines {
+		var quote rune
+		if raw {
+			quote = '`'
+		}
+		escaped := false
+		for _, r := range line {
+			switch {
+			case quote != 0:
+				b.WriteRune(r)
+				switch {
+				case escaped:
+					escaped = false
+				case r == '\\' && quote != '`':
+					escaped = true
+				case r == quote:
+					quote = 0
+				}
+			case r == '"' || r == '\'' || r == '`':
+				quote = r
+				b.WriteRune(r)
+			case !unicode.IsSpace(r):
+				b.WriteRune(r)
+			}
+		}
+		if raw = quote == '`'; raw {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// CollapseNoise removes import-only, whitespace-only and comment-only
+// hunks from d and reports what it removed per file; a file left without
+// hunks is removed entirely. When every hunk is noise d is returned
+// unchanged, since the noise is then the change to describe.
+func CollapseNoise(d string) (string, []Noise) {
+	files := SplitFiles(d)
+	var (
+		out      []File
+		noise    []Noise
+		semantic bool
+	)
+	for _, f := range files {
+		lines := strings.SplitAfter(f.Text, "\n")
+		first := len(lines)
+		for i, line := range lines {
+			if strings.HasPrefix(line, "@@") {
+				first = i
+				break
+			}
+		}
+		if first == len(lines) {
+			// no hunks: mode changes, binaries, renames
+			out = append(out, f)
+			continue
+		}
+
+		var (
+			kept   strings.Builder
+			counts = map[string]int{}
+			hunks  int
+		)
+		kept.WriteString(strings.Join(lines[:first], ""))
+		for start := first; start < len(lines); {
+			end := start + 1
+			for end < len(lines) && !strings.HasPrefix(lines[end], "@@") {
+				end++
+			}
+			var removed, added []string
+			for _, line := range lines[start+1 : end] {
+				switch {
+				case strings.HasPrefix(line, "-"):
+					removed = append(removed, strings.TrimSuffix(line[1:], "\n"))
+				case strings.HasPrefix(line, "+"):
+					added = append(added, strings.TrimSuffix(line[1:], "\n"))
+				}
+			}
+			if kind := classify(f.Path, removed, added); kind != "" {
+				counts[kind]++
+			} else {
+				kept.WriteString(strings.Join(lines[start:end], ""))
+				hunks++
+			}
+			start = end
+		}
+		for _, kind := range []string{NoiseImports, NoiseWhitespace, NoiseComments} {
+			if counts[kind] > 0 {
+				noise = append(noise, Noise{Path: f.Path, Kind: kind, Hunks: counts[kind]})
+			}
+		}
+		if hunks > 0 {
+			semantic = true
+			out = append(out, File{Path: f.Path, Text: kept.String()})
+		}
+	}
+	if !semantic || len(noise) == 0 {
+		return d, nil
+	}
+	return Join(out), noise
+}
@@ -0,0 +1,40 @@
+package diff
+
+import "strings"
+
+// Bump is a submodule pointer change in a diff. Old is empty for an added
+// submodule and New for a removed one.
+type Bump struct {
+	Path string
+	Old  string
+	New  string
+}
+
+// SubmoduleBumps returns the submodule pointer changes of a git diff, which
+// show up as "Subproject commit <sha>" lines instead of file content.
+func SubmoduleBumps(d string) []Bump {
+	var out []Bump
+	for _, f := range SplitFiles(d) {
+		var b Bump
+		for _, line := range strings.Split(f.Text, "\n") {
+			switch {
+			case strings.HasPrefix(line, "-Subproject commit "):
+				b.Old = subprojectCommit(line)
+			case strings.HasPrefix(line, "+Subproject commit "):
+				b.New = subprojectCommit(line)
+			}
+		}
+		if b.Old != "" || b.New != "" {
+			b.Path = f.Path
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// subprojectCommit strips the prefix and the "-dirty" suffix git adds for
+// a submodule with local changes.
+func subprojectCommit(line string) string {
+	sha := strings.TrimSpace(line[len("+Subproject commit "):])
+	return strings.TrimSuffix(sha, "-dirty")
+}
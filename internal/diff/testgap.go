@@ -0,0 +1,117 @@
+package diff
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Gap is a changed exported symbol with no matching test change in the diff.
+type Gap struct {
+	File   string
+	Symbol string
+}
+
+func (g Gap) String() string {
+	return fmt.Sprintf("no tests updated for %s (%s)", g.Symbol, g.File)
+}
+
+var (
+	goExported  = regexp.MustCompile(`^func\s+(?:\([^)]*\)\s*)?([A-Z][A-Za-z0-9_]*)\s*[\[(]`)
+	jsExported  = regexp.MustCompile(`^export\s+(?:default\s+)?(?:async\s+)?(?:function\*?|class|const|let)\s+([A-Za-z_$][A-Za-z0-9_$]*)`)
+	hunkContext = regexp.MustCompile(`^@@[^@]*@@\s*(.*)$`)
+)
+
+// TestGaps is a cheap heuristic: it collects exported Go and JS/TS symbols
+// whose definitions or bodies changed and reports those not mentioned by any
+// changed test file (a _test.go in the same directory for Go, any
+// .test./.spec. file for JS/TS). The review model confirms the candidates.
+func TestGaps(d string) []Gap {
+	files := SplitFiles(d)
+
+	var goTests, jsTests []File
+	for _, f := range files {
+		switch {
+		case strings.HasSuffix(f.Path, "_test.go"):
+			goTests = append(goTests, f)
+		case isJSTest(f.Path):
+			jsTests = append(jsTests, f)
+		}
+	}
+
+	var gaps []Gap
+	for _, f := range files {
+		var (
+			pattern *regexp.Regexp
+			tests   []File
+		)
+		switch {
+		case strings.HasSuffix(f.Path, ".go") && !strings.HasSuffix(f.Path, "_test.go"):
+			pattern = goExported
+			for _, t := range goTests {
+				if path.Dir(t.Path) == path.Dir(f.Path) {
+					tests = append(tests, t)
+				}
+			}
+		case isJS(f.Path) && !isJSTest(f.Path):
+			pattern, tests = jsExported, jsTests
+		default:
+			continue
+		}
+
+		for _, symbol := range changedSymbols(f.Text, pattern) {
+			if !mentioned(tests, symbol) {
+				gaps = append(gaps, Gap{File: f.Path, Symbol: symbol})
+			}
+		}
+	}
+	return gaps
+}
+
+// changedSymbols returns exported symbols defined on added lines or named
+// in the hunk header context (the enclosing function git reports).
+func changedSymbols(text string, pattern *regexp.Regexp) []string {
+	seen := map[string]bool{}
+	var out []string
+	add := func(line string) {
+		if m := pattern.FindStringSubmatch(strings.TrimSpace(line)); len(m) == 2 && !seen[m[1]] {
+			seen[m[1]] = true
+			out = append(out, m[1])
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			if m := hunkContext.FindStringSubmatch(line); len(m) == 2 {
+				add(m[1])
+			}
+		case strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++"):
+			add(line[1:])
+		}
+	}
+	return out
+}
+
+func mentioned(tests []File, symbol string) bool {
+	for _, t := range tests {
+		if strings.Contains(t.Text, symbol) {
+			return true
+		}
+	}
+	return false
+}
+
+func isJS(p string) bool {
+	switch path.Ext(p) {
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		return true
+	}
+	return false
+}
+
+func isJSTest(p string) bool {
+	base := path.Base(p)
+	return isJS(p) && (strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") || strings.Contains(p, "__tests__/"))
+}
@@ -0,0 +1,274 @@
+// Package doctor diagnoses the environment go-commitgen depends on and
+// says how to fix what is missing, before a commit runs into it.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/forge"
+	"github.com/riskibarqy/go-commitgen/internal/git"
+	"github.com/riskibarqy/go-commitgen/internal/hook"
+	"github.com/riskibarqy/go-commitgen/internal/ollama"
+	"github.com/riskibarqy/go-commitgen/internal/usecase"
+)
+
+// Status is the outcome of a check.
+type Status int
+
+const (
+	OK Status = iota
+	// Warn marks something that works but is likely not what the user
+	// wants, such as a hook that is not installed.
+	Warn
+	Fail
+	// Skip marks a check that does not apply, or could not run because an
+	// earlier one failed.
+	Skip
+)
+
+func (s Status) String() string {
+	return [...]string{"ok  ", "warn", "FAIL", "skip"}[s]
+}
+
+// Check is the outcome of one diagnosis.
+type Check struct {
+	Name   string
+	Status Status
+	Detail string
+	// Fix tells the user what to do about a failed or warning check.
+	Fix string
+}
+
+func (c Check) String() string {
+	out := fmt.Sprintf("%s %s: %s", c.Status, c.Name, c.Detail)
+	if c.Fix != "" && (c.Status == Fail || c.Status == Warn) {
+		out += "\n     fix: " + c.Fix
+	}
+	return out
+}
+
+// Failed reports whether any check failed.
+func Failed(checks []Check) bool {
+	for _, c := range checks {
+		if c.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+// Config is what Run checks.
+type Config struct {
+	Client   *ollama.Client
+	Endpoint string
+	// Models are the models the configuration uses; each must be pulled.
+	Models []string
+	Repo   *git.CLIRepository
+	// Forge authenticates the code host; checks are skipped without any
+	// token.
+	Forge usecase.ForgeConfig
+}
+
+// Run runs every check in the order a commit depends on them. Checks that
+// need a working git or endpoint are skipped when those fail.
+func Run(ctx context.Context, cfg Config) []Check {
+	checks := []Check{Git(ctx)}
+	if checks[0].Status == Fail {
+		checks = append(checks, Check{Name: "repository", Status: Skip, Detail: "needs git"})
+	} else {
+		checks = append(checks, Repo(ctx, cfg.Repo)...)
+	}
+
+	endpoint := Endpoint(ctx, cfg.Client, cfg.Endpoint)
+	checks = append(checks, endpoint)
+	seen := map[string]bool{}
+	for _, model := range cfg.Models {
+		if model == "" || seen[model] {
+			continue
+		}
+		seen[model] = true
+		if endpoint.Status == Fail {
+			checks = append(checks, Check{Name: "model " + model, Status: Skip, Detail: "needs the endpoint"})
+			continue
+		}
+		checks = append(checks, Model(ctx, cfg.Client, cfg.Endpoint, model))
+	}
+	return append(checks, Forge(ctx, cfg.Repo, cfg.Forge))
+}
+
+// minGit is the oldest git that supports every command go-commitgen runs:
+// `git rev-parse --path-format=absolute` came with 2.31.
+var minGit = [2]int{2, 31}
+
+var gitVersion = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// Git checks that git is on PATH and new enough.
+func Git(ctx context.Context) Check {
+	check := Check{Name: "git"}
+	out, err := exec.CommandContext(ctx, "git", "--version").Output()
+	if err != nil {
+		check.Status, check.Detail = Fail, err.Error()
+		check.Fix = "install git and make sure it is on PATH"
+		return check
+	}
+	check.Detail = strings.TrimSpace(string(out))
+	m := gitVersion.FindStringSubmatch(check.Detail)
+	if m == nil {
+		check.Status = Warn
+		check.Fix = fmt.Sprintf("cannot read the version; go-commitgen needs git %d.%d or newer", minGit[0], minGit[1])
+		return check
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	if major < minGit[0] || major == minGit[0] && minor < minGit[1] {
+		check.Status = Fail
+		check.Fix = fmt.Sprintf("upgrade to git %d.%d or newer", minGit[0], minGit[1])
+	}
+	return check
+}
+
+// Repo checks that the working directory is a repository go-commitgen can
+// commit in: no unresolved conflicts, a committer identity and installed
+// hooks.
+func Repo(ctx context.Context, repo *git.CLIRepository) []Check {
+	root, err := repo.Root(ctx)
+	if err != nil {
+		return []Check{{Name: "repository", Status: Fail, Detail: err.Error(), Fix: "run go-commitgen inside a git work tree"}}
+	}
+	checks := []Check{{Name: "repository", Detail: root}}
+
+	state, err := repo.MergeState(ctx)
+	switch {
+	case err != nil:
+		checks[0].Status, checks[0].Detail = Warn, fmt.Sprintf("%s: cannot read the merge state: %v", root, err)
+	case len(state.Conflicts) > 0:
+		checks[0].Status = Fail
+		checks[0].Detail = fmt.Sprintf("%s: unresolved conflicts in %s", root, strings.Join(state.Conflicts, ", "))
+		operation := state.Operation
+		if operation == "" {
+			operation = "merge"
+		}
+		checks[0].Fix = "resolve the conflicts and git add the files, or abort the " + operation
+	case state.Operation != "":
+		checks[0].Detail = fmt.Sprintf("%s: %s in progress", root, state.Operation)
+	case state.InProgress:
+		checks[0].Detail = root + ": merge in progress"
+	}
+
+	identity := Check{Name: "committer"}
+	if identity.Detail, err = repo.Identity(ctx); err != nil {
+		// git explains at length; its last line names the problem
+		lines := strings.Split(strings.TrimSpace(err.Error()), "\n")
+		identity.Status, identity.Detail = Fail, lines[len(lines)-1]
+		identity.Fix = "git config --global user.name \"Your Name\" && git config --global user.email you@example.com"
+	}
+	checks = append(checks, identity)
+
+	dir, err := repo.HooksDir(ctx)
+	if err != nil {
+		return append(checks, Check{Name: "hooks", Status: Warn, Detail: "cannot find git's hooks directory: " + err.Error()})
+	}
+	installer := hook.Installer{Root: root, HooksDir: dir}
+	for _, h := range []hook.Hook{hook.PrepareCommitMsg, hook.PrePush} {
+		check := Check{Name: h.Name + " hook"}
+		status, ok := installer.Installed(h)
+		switch {
+		case !ok && h.Name == hook.PrePush.Name:
+			check.Status, check.Detail = Skip, "not installed (optional: go-commitgen install-hook --pre-push)"
+		case !ok:
+			check.Status, check.Detail = Warn, "not installed; go-commitgen only runs when called by hand"
+			check.Fix = "go-commitgen install-hook"
+		case status.Inactive != "":
+			check.Status, check.Detail = Fail, fmt.Sprintf("%s (%s) will not run", status.Path, status.Manager)
+			check.Fix = status.Inactive
+		default:
+			check.Detail = fmt.Sprintf("%s (%s)", status.Path, status.Manager)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// Endpoint checks that the model endpoint answers and accepts the
+// client's credentials.
+func Endpoint(ctx context.Context, client *ollama.Client, endpoint string) Check {
+	check := Check{Name: "endpoint"}
+	version, err := client.Version(ctx, endpoint)
+	switch {
+	case err == nil:
+		check.Detail = fmt.Sprintf("%s (ollama %s)", endpoint, version)
+	case ollama.Unreachable(err):
+		check.Status, check.Detail = Fail, fmt.Sprintf("%s is unreachable: %v", endpoint, err)
+		check.Fix = "start it with `ollama serve`, or point --endpoint (env OLLAMA_ENDPOINT) at the right host"
+	case refused(err):
+		check.Status, check.Detail = Fail, fmt.Sprintf("%s refused the credentials: %v", endpoint, err)
+		check.Fix = "check --api-key (env OLLAMA_API_KEY) and --header"
+	default:
+		check.Status, check.Detail = Fail, fmt.Sprintf("%s: %v", endpoint, err)
+		check.Fix = "make sure --endpoint is an Ollama server (or a gateway in front of one)"
+	}
+	return check
+}
+
+// Model checks that the endpoint has model.
+func Model(ctx context.Context, client *ollama.Client, endpoint, model string) Check {
+	check := Check{Name: "model " + model}
+	info, err := client.Show(ctx, endpoint, model)
+	switch {
+	case err == nil:
+		check.Detail = "available"
+		if info.ModifiedAt != "" {
+			check.Detail += ", modified " + info.ModifiedAt
+		}
+	case strings.HasPrefix(err.Error(), "ollama error 404"):
+		check.Status, check.Detail = Fail, "not pulled"
+		check.Fix = "ollama pull " + model + ", or pick an installed model (`ollama list`) with --model / --review-model"
+	default:
+		check.Status, check.Detail = Fail, err.Error()
+	}
+	return check
+}
+
+// Forge checks the token of the forge hosting the origin remote.
+func Forge(ctx context.Context, repo *git.CLIRepository, cfg usecase.ForgeConfig) Check {
+	check := Check{Name: "forge"}
+	configured := false
+	for _, token := range cfg.Tokens {
+		configured = configured || token != ""
+	}
+	if !configured {
+		check.Status = Skip
+		check.Detail = "no token set; only needed for pr --post-to-pr, review --post-to-pr and --review-create-issues"
+		return check
+	}
+
+	id, err := (&usecase.Service{Repo: repo}).WhoAmI(ctx, cfg)
+	switch {
+	case err == nil:
+		check.Detail = fmt.Sprintf("%s as %s (%s)", id.Kind, id.User, id.Project)
+	case id.Kind != "" && cfg.Tokens[id.Kind] == "":
+		check.Status = Skip
+		check.Detail = fmt.Sprintf("origin is on %s, which has no token", id.Kind)
+	case id.Kind == "":
+		check.Status, check.Detail = Warn, err.Error()
+		check.Fix = "pass --forge github|gitlab|gitea (env COMMITGEN_FORGE)"
+	default:
+		check.Status, check.Detail = Fail, err.Error()
+		check.Fix = fmt.Sprintf("create a new %s token with API access and set %s", id.Kind, tokenEnv[id.Kind])
+	}
+	return check
+}
+
+// tokenEnv names the variable each forge's token is read from.
+var tokenEnv = map[forge.Kind]string{forge.GitHub: "GITHUB_TOKEN", forge.GitLab: "GITLAB_TOKEN", forge.Gitea: "GITEA_TOKEN"}
+
+// refused reports whether err is the endpoint rejecting the credentials.
+func refused(err error) bool {
+	msg := err.Error()
+	return strings.HasPrefix(msg, "ollama error 401") || strings.HasPrefix(msg, "ollama error 403")
+}
@@ -0,0 +1,72 @@
+package enrich
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+
+	"github.com/riskibarqy/go-commitgen/internal/diff"
+)
+
+// GoSymbols parses the post-image of a Go file and returns the functions,
+// methods and types whose declarations overlap the changed line ranges,
+// e.g. "func Parse", "method (*Client).Generate", "type Options".
+func GoSymbols(src []byte, ranges []diff.LineRange) ([]string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	overlaps := func(node ast.Node) bool {
+		start, end := fset.Position(node.Pos()).Line, fset.Position(node.End()).Line
+		for _, r := range ranges {
+			if r.Start <= end && r.End >= start {
+				return true
+			}
+		}
+		return false
+	}
+
+	var out []string
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if overlaps(d) {
+				out = append(out, funcName(d))
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok && overlaps(ts) {
+					out = append(out, "type "+ts.Name.Name)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+func funcName(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return "func " + d.Name.Name
+	}
+	return fmt.Sprintf("method (%s).%s", receiverType(d.Recv.List[0].Type), d.Name.Name)
+}
+
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return "?"
+}
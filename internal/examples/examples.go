@@ -0,0 +1,200 @@
+// Package examples keeps a local library of accepted commit messages with a
+// summary of the diff they describe, and picks the past examples most
+// similar to a new change as few-shot context.
+package examples
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	difftext "github.com/riskibarqy/go-commitgen/internal/diff"
+)
+
+// Example is one accepted (diff summary, final message) pair.
+type Example struct {
+	Time time.Time `json:"time"`
+	// Repo identifies the repository the example was recorded in (git's
+	// common directory, shared by its worktrees; the work tree root in
+	// older libraries); examples are only offered within the same
+	// repository.
+	Repo    string `json:"repo"`
+	Summary string `json:"summary"`
+	Message string `json:"message"`
+}
+
+// summaryTerms caps the identifiers kept from the changed lines.
+const summaryTerms = 40
+
+// DefaultPath returns the library location, honouring COMMITGEN_EXAMPLES_FILE.
+func DefaultPath() string {
+	if v := os.Getenv("COMMITGEN_EXAMPLES_FILE"); v != "" {
+		return v
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(".", ".commitgen-examples.jsonl")
+	}
+	return filepath.Join(dir, "go-commitgen", "examples.jsonl")
+}
+
+// Summarize reduces a diff to its paths and the most frequent identifiers
+// of its changed lines: enough to compare changes without storing code.
+func Summarize(diff string) string {
+	var paths []string
+	counts := map[string]int{}
+	for _, f := range difftext.SplitFiles(diff) {
+		if f.Path != "" {
+			paths = append(paths, f.Path)
+		}
+		for _, line := range strings.Split(f.Text, "\n") {
+			if !strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "-") ||
+				strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+				continue
+			}
+			for _, term := range terms(line[1:]) {
+				counts[term]++
+			}
+		}
+	}
+	words := make([]string, 0, len(counts))
+	for w := range counts {
+		words = append(words, w)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	if len(words) > summaryTerms {
+		words = words[:summaryTerms]
+	}
+	return "files: " + strings.Join(paths, ", ") + "\nterms: " + strings.Join(words, " ")
+}
+
+// terms splits text into lower-cased words of three or more letters,
+// breaking paths and identifiers apart on punctuation.
+func terms(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := fields[:0]
+	for _, f := range fields {
+		if len(f) >= 3 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Similar returns up to n examples whose summaries are closest to summary
+// by cosine similarity of their terms, best first; unrelated examples are
+// never returned.
+func Similar(examples []Example, summary string, n int) []Example {
+	query := vector(summary)
+	type scored struct {
+		Example
+		score float64
+	}
+	var candidates []scored
+	for _, ex := range examples {
+		if score := cosine(query, vector(ex.Summary)); score > 0 {
+			candidates = append(candidates, scored{ex, score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].Time.After(candidates[j].Time)
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	out := make([]Example, len(candidates))
+	for i, c := range candidates {
+		out[i] = c.Example
+	}
+	return out
+}
+
+func vector(text string) map[string]float64 {
+	v := map[string]float64{}
+	for _, t := range terms(text) {
+		if t != "files" && t != "terms" { // Summarize's labels
+			v[t]++
+		}
+	}
+	return v
+}
+
+func cosine(a, b map[string]float64) float64 {
+	var dot, na, nb float64
+	for k, x := range a {
+		dot += x * b[k]
+		na += x * x
+	}
+	for _, y := range b {
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// Append adds ex to the library at path, creating it if needed.
+func Append(path string, ex Example) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create examples dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open examples file: %w", err)
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(ex)
+	if err != nil {
+		return fmt.Errorf("marshal example: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Load reads the examples recorded under any of the repos keys from path;
+// a missing library is empty.
+func Load(path string, repos ...string) ([]Example, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open examples file: %w", err)
+	}
+	defer f.Close()
+
+	keys := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		keys[repo] = true
+	}
+	var out []Example
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		var ex Example
+		if err := json.Unmarshal(sc.Bytes(), &ex); err != nil || !keys[ex.Repo] {
+			continue
+		}
+		out = append(out, ex)
+	}
+	return out, sc.Err()
+}
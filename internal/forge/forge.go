@@ -0,0 +1,85 @@
+// Package forge is the abstraction over the code hosts (GitHub, GitLab,
+// Gitea/Forgejo) that pull request descriptions, review comments and
+// follow-up issues are posted to. The clients live in their own packages
+// and implement Forge.
+package forge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Kind names a code host API.
+type Kind string
+
+const (
+	GitHub Kind = "github"
+	GitLab Kind = "gitlab"
+	// Gitea also covers Forgejo, which serves the same API.
+	Gitea Kind = "gitea"
+)
+
+// Kinds lists the supported code hosts.
+var Kinds = []Kind{GitHub, GitLab, Gitea}
+
+// Forge creates and updates pull requests, posts reviews on them and opens
+// issues.
+type Forge interface {
+	// User returns the account the token authenticates as.
+	User(ctx context.Context) (string, error)
+	// FindPullRequest returns the open pull request whose head is branch,
+	// or an error wrapping ErrNoPullRequest.
+	FindPullRequest(ctx context.Context, branch string) (PullRequest, error)
+	CreatePullRequest(ctx context.Context, branch, base, title, body string) (PullRequest, error)
+	UpdatePullRequest(ctx context.Context, pr PullRequest, title, body string) error
+	// PostReview posts body and the line comments, returning a URL to
+	// show the user.
+	PostReview(ctx context.Context, pr PullRequest, body string, comments []Comment) (string, error)
+	// CreateIssue opens an issue; labels the project does not have are
+	// dropped or created, as the host does it.
+	CreateIssue(ctx context.Context, title, body string, labels []string) (Issue, error)
+	// Issue reads the issue with the given number.
+	Issue(ctx context.Context, number int) (Issue, error)
+}
+
+// ErrNoPullRequest is returned when the branch has no open pull request.
+var ErrNoPullRequest = errors.New("no open pull request for the branch")
+
+// PullRequest is an open pull (or merge) request.
+type PullRequest struct {
+	Number  int
+	URL     string
+	HeadSHA string
+	BaseRef string
+	// BaseSHA and StartSHA are GitLab's diff refs, needed to anchor
+	// discussions to lines.
+	BaseSHA  string
+	StartSHA string
+}
+
+// Issue is an issue on the forge, or a ticket of a tracker such as Jira.
+// Title and Body are only filled when the issue was read.
+type Issue struct {
+	Number int
+	// Key is the tracker's ID, e.g. "PROJ-123"; empty for forge issues.
+	Key   string
+	URL   string
+	Title string
+	Body  string
+}
+
+// Ref is how commit messages refer to the issue, e.g. "#12" or "PROJ-123".
+func (i Issue) Ref() string {
+	if i.Key != "" {
+		return i.Key
+	}
+	return fmt.Sprintf("#%d", i.Number)
+}
+
+// Comment is a review comment on a line of the pull request's new side.
+type Comment struct {
+	Path string
+	Line int
+	Body string
+}
@@ -0,0 +1,115 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Remote is a git remote URL broken into the parts the forge APIs need.
+type Remote struct {
+	// Host is the bare host name, e.g. gitlab.example.com.
+	Host string
+	// Instance is the web base URL, e.g. https://gitlab.example.com:8443.
+	Instance string
+	// Path is the full project path: owner/repo, or group/sub/repo on
+	// GitLab.
+	Path string
+}
+
+// Owner is everything before the repository name: the user, organisation
+// or (sub)group.
+func (r Remote) Owner() string {
+	owner, _ := r.split()
+	return owner
+}
+
+// Name is the repository name.
+func (r Remote) Name() string {
+	_, name := r.split()
+	return name
+}
+
+func (r Remote) split() (string, string) {
+	i := strings.LastIndex(r.Path, "/")
+	return r.Path[:i], r.Path[i+1:]
+}
+
+var remotePattern = regexp.MustCompile(`^(?:([a-z][a-z0-9+.-]*)://)?(?:[^@/]+@)?([^:/]+)(?::(\d+))?[:/](.+?)(?:\.git)?/*$`)
+
+// ParseRemote parses scp-like (git@host:owner/repo.git), ssh:// and
+// http(s):// remotes, keeping GitLab subgroups in Path. SSH remotes are
+// assumed to serve the web UI over https on the same host; their port is
+// dropped since it is the SSH port.
+func ParseRemote(remote string) (Remote, error) {
+	m := remotePattern.FindStringSubmatch(strings.TrimSpace(remote))
+	if m == nil || !strings.Contains(m[4], "/") {
+		return Remote{}, fmt.Errorf("cannot parse remote URL %q", remote)
+	}
+	scheme, host, port, path := m[1], strings.ToLower(m[2]), m[3], strings.Trim(m[4], "/")
+	instance := "https://" + host
+	if scheme == "http" || scheme == "https" {
+		instance = scheme + "://" + host
+		if port != "" {
+			instance += ":" + port
+		}
+	}
+	return Remote{Host: host, Instance: instance, Path: path}, nil
+}
+
+// hostHints maps host name fragments to the forge they usually run.
+var hostHints = []struct {
+	fragment string
+	kind     Kind
+}{
+	{"github", GitHub},
+	{"gitlab", GitLab},
+	{"gitea", Gitea},
+	{"forgejo", Gitea},
+	{"codeberg", Gitea},
+}
+
+// Detect guesses the forge from the remote's host name; "" means unknown.
+func Detect(r Remote) Kind {
+	for _, h := range hostHints {
+		if strings.Contains(r.Host, h.fragment) {
+			return h.kind
+		}
+	}
+	return ""
+}
+
+// Probe identifies a self-hosted forge with a neutral host name by the
+// version endpoints only Gitea/Forgejo and GitLab serve; "" means unknown.
+// GitLab answers 401 without a token, which still identifies it.
+func Probe(ctx context.Context, client *http.Client, r Remote) Kind {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	probes := []struct {
+		path string
+		kind Kind
+		ok   func(status int) bool
+	}{
+		{"/api/v1/version", Gitea, func(status int) bool { return status == http.StatusOK }},
+		{"/api/v4/version", GitLab, func(status int) bool { return status == http.StatusOK || status == http.StatusUnauthorized }},
+		{"/api/v3/meta", GitHub, func(status int) bool { return status == http.StatusOK }},
+	}
+	for _, p := range probes {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.Instance+p.path, nil)
+		if err != nil {
+			return ""
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return ""
+		}
+		resp.Body.Close()
+		if p.ok(resp.StatusCode) {
+			return p.kind
+		}
+	}
+	return ""
+}
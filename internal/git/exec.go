@@ -0,0 +1,86 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a single non-interactive subprocess, so a wedged
+// command fails on its own instead of silently eating the whole budget.
+const DefaultTimeout = 15 * time.Second
+
+// waitDelay is how long Run waits for the output pipes after the process
+// was killed; a grandchild (credential helper, ssh) may still hold them.
+const waitDelay = 2 * time.Second
+
+// TimeoutError reports a subprocess killed for running longer than its
+// own timeout.
+type TimeoutError struct {
+	Command string
+	After   time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s (waiting for a credential prompt or a lock?)", e.Command, e.After)
+}
+
+func (e *TimeoutError) Unwrap() error { return context.DeadlineExceeded }
+
+// boundedCmd is a non-interactive subprocess running under its own timeout
+// in its own process group, killed as a whole on cancellation.
+type boundedCmd struct {
+	*exec.Cmd
+	parent  context.Context
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+}
+
+// command prepares name with args to run under timeout; zero disables the
+// timeout but keeps the cancellation handling. Terminal prompts are
+// disabled as nobody is there to answer them.
+func command(ctx context.Context, execFn execFunc, timeout time.Duration, name string, args ...string) *boundedCmd {
+	c := &boundedCmd{parent: ctx, ctx: ctx, cancel: func() {}, timeout: timeout}
+	if timeout > 0 {
+		c.ctx, c.cancel = context.WithTimeout(ctx, timeout)
+	}
+	cmd := execFn(c.ctx, name, args...)
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0")
+	cmd.WaitDelay = waitDelay
+	killGroup(cmd)
+	c.Cmd = cmd
+	return c
+}
+
+// Run runs the command, naming it in the error when it was cancelled or
+// timed out.
+func (c *boundedCmd) Run() error {
+	defer c.cancel()
+	err := c.Cmd.Run()
+	switch {
+	case err == nil:
+		return nil
+	case c.parent.Err() != nil:
+		return fmt.Errorf("%s: %w", c.describe(), c.parent.Err())
+	case errors.Is(c.ctx.Err(), context.DeadlineExceeded):
+		return &TimeoutError{Command: c.describe(), After: c.timeout}
+	}
+	return err
+}
+
+// describe names the command for errors, shortening long pathspecs.
+func (c *boundedCmd) describe() string {
+	s := strings.Join(c.Args, " ")
+	if len(s) > 80 {
+		s = s[:77] + "..."
+	}
+	return s
+}
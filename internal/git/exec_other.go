@@ -0,0 +1,8 @@
+//go:build !unix
+
+package git
+
+import "os/exec"
+
+// killGroup keeps the default cancellation, which kills the process only.
+func killGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package git
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killGroup starts cmd in a process group of its own and makes
+// cancellation kill the whole group, so helpers spawned by git (ssh,
+// credential helpers, hooks) do not outlive it.
+func killGroup(cmd *exec.Cmd) {
+	if cmd.Cancel == nil {
+		return // not created with a context
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
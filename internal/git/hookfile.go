@@ -0,0 +1,92 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// scissors follows the comment prefix on the line git puts above the
+// verbose diff (commit.verbose, `git commit -v`); nothing below it is part
+// of the message.
+const scissors = " ------------------------ >8 ------------------------"
+
+// autoCommentChars are the prefixes core.commentChar=auto picks from.
+const autoCommentChars = "#;@!$%^&|:"
+
+// WriteHook puts message at the top of the commit message file git handed
+// to the hook, keeping the trailing comment block, the scissors line and
+// the verbose diff git wrote below it; only the message text above them
+// is replaced.
+func (r *CLIRepository) WriteHook(path, message string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	content := message + "\n"
+	if tail := hookTail(string(existing), r.commentPrefix(string(existing))); tail != "" {
+		content += "\n" + tail
+	}
+	return os.WriteFile(path, []byte(content), 0o644)
+}
+
+// commentPrefix returns the comment prefix git uses in the message file:
+// core.commentString or core.commentChar, detected from the file for "auto".
+func (r *CLIRepository) commentPrefix(content string) string {
+	prefix := "#"
+	for _, key := range []string{"core.commentString", "core.commentChar"} {
+		if out, err := r.output(context.Background(), "config", "--get", key); err == nil && strings.TrimSpace(out) != "" {
+			prefix = strings.TrimSpace(out)
+			break
+		}
+	}
+	if prefix != "auto" {
+		return prefix
+	}
+	lines := strings.Split(content, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if before, ok := strings.CutSuffix(lines[i], scissors); ok && before != "" {
+			return before
+		}
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if lines[i] != "" {
+			if strings.ContainsRune(autoCommentChars, rune(lines[i][0])) {
+				return lines[i][:1]
+			}
+			break
+		}
+	}
+	return "#"
+}
+
+// hookTail returns what git wrote below the message: the comment lines
+// ending the file, or ending it above the scissors line, followed by the
+// scissors line and everything under it.
+func hookTail(content, prefix string) string {
+	lines := strings.Split(content, "\n")
+	end := len(lines)
+	for i, line := range lines {
+		if line == prefix+scissors {
+			end = i
+			break
+		}
+	}
+	start := end
+	for start > 0 && (lines[start-1] == "" || strings.HasPrefix(lines[start-1], prefix)) {
+		start--
+	}
+	for start < end && lines[start] == "" {
+		start++
+	}
+	tail := strings.Join(lines[start:], "\n")
+	if strings.TrimSpace(tail) == "" {
+		return ""
+	}
+	if !strings.HasSuffix(tail, "\n") {
+		tail += "\n"
+	}
+	return tail
+}
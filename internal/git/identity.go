@@ -0,0 +1,67 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Identity returns the committer as "Name <email>", the form of a
+// Signed-off-by trailer. It honours GIT_COMMITTER_NAME/EMAIL like `git
+// commit -s` does, and fails when user.name or user.email is not set.
+// Scope.Author, when set, is the identity.
+func (r *CLIRepository) Identity(ctx context.Context) (string, error) {
+	if r.Author != "" {
+		return r.Author, nil
+	}
+	out, err := r.output(ctx, "var", "GIT_COMMITTER_IDENT")
+	if err != nil {
+		return "", err
+	}
+	// "Name <email> 1700000000 +0100"
+	ident := strings.TrimSpace(out)
+	if end := strings.LastIndex(ident, ">"); end != -1 {
+		ident = ident[:end+1]
+	}
+	if !strings.Contains(ident, "<") || strings.Contains(ident, "<>") {
+		return "", fmt.Errorf("git has no committer email; set user.email")
+	}
+	return ident, nil
+}
+
+// Identity returns jj's user.name and user.email as "Name <email>", or
+// Scope.Author.
+func (r *JJRepository) Identity(ctx context.Context) (string, error) {
+	if r.Author != "" {
+		return r.Author, nil
+	}
+	name, err := r.output(ctx, "config", "get", "user.name")
+	if err != nil {
+		return "", err
+	}
+	email, err := r.output(ctx, "config", "get", "user.email")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(email) == "" {
+		return "", fmt.Errorf("jj has no user.email; set it with jj config set --user user.email")
+	}
+	return strings.TrimSpace(name) + " <" + strings.TrimSpace(email) + ">", nil
+}
+
+// Identity returns Sapling's ui.username, which is already "Name <email>",
+// or Scope.Author.
+func (r *SaplingRepository) Identity(ctx context.Context) (string, error) {
+	if r.Author != "" {
+		return r.Author, nil
+	}
+	out, err := r.output(ctx, "config", "ui.username")
+	if err != nil {
+		return "", err
+	}
+	ident := strings.TrimSpace(out)
+	if !strings.Contains(ident, "<") {
+		return "", fmt.Errorf("sl ui.username %q has no email; set it to \"Name <email>\"", ident)
+	}
+	return ident, nil
+}
@@ -0,0 +1,149 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// jjLogTemplate renders commits as the records parseLog understands.
+const jjLogTemplate = `commit_id ++ "` + "\x1f" + `" ++ author.name() ++ "` + "\x1f" +
+	`" ++ author.timestamp().format("%Y-%m-%dT%H:%M:%S%:z") ++ "` + "\x1f" +
+	`" ++ description.first_line() ++ "` + "\x1f" + `" ++ description ++ "` + "\x1e" + `"`
+
+// JJRepository drives a Jujutsu repository. jj has no staging area: the
+// working-copy commit (@) is the change being described, and committing
+// describes it and starts a new empty change on top.
+type JJRepository struct {
+	Exec execFunc
+	// Scope.Paths are filesets; committing them splits the rest of @ into
+	// the next change. jj commits empty changes without being asked.
+	Scope
+}
+
+// NewJJRepository returns a Repository backed by the jj binary.
+func NewJJRepository() *JJRepository {
+	return &JJRepository{Exec: defaultExec, Scope: Scope{Timeout: DefaultTimeout}}
+}
+
+func (r *JJRepository) output(ctx context.Context, args ...string) (string, error) {
+	return run(ctx, r.Exec, r.Timeout, "jj", args...)
+}
+
+// StagedDiff returns the changes in the working-copy commit. The rename
+// similarity threshold has no jj equivalent and is ignored.
+func (r *JJRepository) StagedDiff(ctx context.Context, opts DiffOptions) (string, error) {
+	args := []string{"diff", "--git", "--context", "0", "-r", "@"}
+	if opts.IgnoreWhitespace {
+		args = append(args, "--ignore-all-space")
+	}
+	return r.output(ctx, append(args, r.Paths...)...)
+}
+
+func (r *JJRepository) StagedFiles(ctx context.Context) ([]string, error) {
+	out, err := r.output(ctx, append([]string{"diff", "--name-only", "-r", "@"}, r.Paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	return util.TrimLines(out), nil
+}
+
+func (r *JJRepository) RecentCommits(ctx context.Context, files []string, n int) ([]string, error) {
+	if n <= 0 || len(files) == 0 {
+		return nil, nil
+	}
+	args := append([]string{"log", "--no-graph", "-r", "::@- & ~root()", "-n", strconv.Itoa(n), "-T", `description.first_line() ++ "\n"`}, files...)
+	out, err := r.output(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return util.TrimLines(out), nil
+}
+
+// UntrackedDiff is always empty: jj snapshots new files into @ automatically.
+func (r *JJRepository) UntrackedDiff(ctx context.Context, maxPerFile int) (string, error) {
+	return "", nil
+}
+
+// MergeState reports no pending merge; jj records merges as commits with
+// several parents instead of a half-finished state.
+func (r *JJRepository) MergeState(ctx context.Context) (MergeState, error) {
+	return MergeState{}, nil
+}
+
+func (r *JJRepository) Root(ctx context.Context) (string, error) {
+	out, err := r.output(ctx, "root")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Log lists commits in revRange, a jj revset ("A..B" means the same as in
+// git); an empty range lists the ancestors of @.
+func (r *JJRepository) Log(ctx context.Context, revRange string, limit int) ([]LogEntry, error) {
+	if revRange == "" {
+		revRange = "::@- & ~root()"
+	}
+	args := []string{"log", "--no-graph", "-r", revRange, "-T", jjLogTemplate}
+	if limit > 0 {
+		args = append(args, "-n", strconv.Itoa(limit))
+	}
+	out, err := r.output(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return splitEntries(out), nil
+}
+
+// StagedContent reads path from the working copy, which is what @ holds.
+func (r *JJRepository) StagedContent(ctx context.Context, path string) ([]byte, error) {
+	root, err := r.Root(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(root, path))
+}
+
+// UpdateStaged rewrites the working-copy file, which is what gets committed.
+func (r *JJRepository) UpdateStaged(ctx context.Context, path string, content []byte) error {
+	return writeWorktree(ctx, r, path, content)
+}
+
+// CurrentBranch returns the closest bookmark at or below @, falling back to
+// the short change id.
+func (r *JJRepository) CurrentBranch(ctx context.Context) (string, error) {
+	out, err := r.output(ctx, "log", "--no-graph", "-r", "latest(::@ & bookmarks())", "-T", `local_bookmarks.map(|b| b.name()).join(" ")`)
+	if err == nil {
+		if names := strings.Fields(out); len(names) > 0 {
+			return names[0], nil
+		}
+	}
+	out, err = r.output(ctx, "log", "--no-graph", "-r", "@", "-T", "change_id.shortest(8)")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Commit describes @ with the message and starts a new change on top.
+func (r *JJRepository) Commit(ctx context.Context, headline, body string) error {
+	msg, err := fullMessage(headline, body)
+	if err != nil {
+		return err
+	}
+	var env []string
+	if r.Author != "" {
+		name, email := splitIdent(r.Author)
+		env = []string{"JJ_USER=" + name, "JJ_EMAIL=" + email}
+	}
+	return commitInteractive(ctx, r.Exec, r.Scope, env, "jj", append([]string{"commit", "-m", msg}, r.Paths...)...)
+}
+
+func (r *JJRepository) WriteHook(path, message string) error {
+	return os.WriteFile(path, []byte(message+"\n"), 0o644)
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/riskibarqy/go-commitgen/internal/diff"
+)
+
+// ErrNoCheckout is returned by operations that need a real repository when
+// the diff was supplied as a file or on stdin.
+var ErrNoCheckout = errors.New("diff was read from a file; no git checkout to operate on")
+
+// PatchRepository serves a fixed diff (a patch file, stdin, a CI artifact)
+// through the Repository interface without touching git at all.
+type PatchRepository struct {
+	Diff   string
+	Branch string
+}
+
+// LoadPatch reads a diff from path, or from stdin when path is "-".
+func LoadPatch(path string) (*PatchRepository, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read diff %s: %w", path, err)
+	}
+	return &PatchRepository{Diff: string(data)}, nil
+}
+
+func (p *PatchRepository) StagedDiff(ctx context.Context, opts DiffOptions) (string, error) {
+	return p.Diff, nil
+}
+
+func (p *PatchRepository) StagedFiles(ctx context.Context) ([]string, error) {
+	var files []string
+	for _, f := range diff.SplitFiles(p.Diff) {
+		if f.Path != "" {
+			files = append(files, f.Path)
+		}
+	}
+	return files, nil
+}
+
+func (p *PatchRepository) RecentCommits(ctx context.Context, files []string, n int) ([]string, error) {
+	return nil, nil
+}
+
+func (p *PatchRepository) UntrackedDiff(ctx context.Context, maxPerFile int) (string, error) {
+	return "", nil
+}
+
+func (p *PatchRepository) MergeState(ctx context.Context) (MergeState, error) {
+	return MergeState{}, nil
+}
+
+func (p *PatchRepository) Root(ctx context.Context) (string, error) {
+	return "", ErrNoCheckout
+}
+
+func (p *PatchRepository) Log(ctx context.Context, revRange string, limit int) ([]LogEntry, error) {
+	return nil, ErrNoCheckout
+}
+
+func (p *PatchRepository) StagedContent(ctx context.Context, path string) ([]byte, error) {
+	return nil, ErrNoCheckout
+}
+
+func (p *PatchRepository) UpdateStaged(ctx context.Context, path string, content []byte) error {
+	return ErrNoCheckout
+}
+
+func (p *PatchRepository) CurrentBranch(ctx context.Context) (string, error) {
+	return p.Branch, nil
+}
+
+func (p *PatchRepository) Commit(ctx context.Context, headline, body string) error {
+	return ErrNoCheckout
+}
+
+func (p *PatchRepository) WriteHook(path, message string) error {
+	return os.WriteFile(path, []byte(message+"\n"), 0o644)
+}
@@ -3,15 +3,77 @@ package git
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/util"
 )
 
+// DiffOptions tunes how the staged diff is produced.
+type DiffOptions struct {
+	// IgnoreWhitespace drops whitespace-only changes (git diff -w).
+	IgnoreWhitespace bool
+	// Similarity is the rename/copy detection threshold in percent; zero
+	// keeps git's default.
+	Similarity int
+}
+
+func (o DiffOptions) args() []string {
+	rename, cp := "-M", "-C"
+	if o.Similarity > 0 {
+		rename = fmt.Sprintf("-M%d%%", o.Similarity)
+		cp = fmt.Sprintf("-C%d%%", o.Similarity)
+	}
+	args := []string{rename, cp}
+	if o.IgnoreWhitespace {
+		args = append(args, "-w")
+	}
+	return args
+}
+
+// MergeState describes a merge that is waiting to be committed, or the
+// rebase, cherry-pick or revert the repository stopped in.
+type MergeState struct {
+	InProgress bool
+	// Message is git's prepared MERGE_MSG without comment lines.
+	Message string
+	// Incoming lists subjects of the commits brought in by MERGE_HEAD.
+	Incoming []string
+	// Operation is "rebase", "cherry-pick" or "revert" while one is
+	// stopped, and Replaying the subject of the commit it is applying.
+	Operation string
+	Replaying string
+	// Conflicts lists the paths that are still unmerged.
+	Conflicts []string
+}
+
+// LogEntry is one commit read from history.
+type LogEntry struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Subject string
+	Body    string
+}
+
 // Repository exposes git operations required by the application.
 type Repository interface {
-	StagedDiff(ctx context.Context) (string, error)
+	StagedDiff(ctx context.Context, opts DiffOptions) (string, error)
+	StagedFiles(ctx context.Context) ([]string, error)
+	RecentCommits(ctx context.Context, files []string, n int) ([]string, error)
+	UntrackedDiff(ctx context.Context, maxPerFile int) (string, error)
+	MergeState(ctx context.Context) (MergeState, error)
+	Root(ctx context.Context) (string, error)
+	Log(ctx context.Context, revRange string, limit int) ([]LogEntry, error)
+	StagedContent(ctx context.Context, path string) ([]byte, error)
+	UpdateStaged(ctx context.Context, path string, content []byte) error
 	CurrentBranch(ctx context.Context) (string, error)
 	Commit(ctx context.Context, headline, body string) error
 	WriteHook(path, message string) error
@@ -20,6 +82,10 @@ type Repository interface {
 // CLIRepository executes git commands through the local CLI.
 type CLIRepository struct {
 	Exec func(ctx context.Context, name string, args ...string) *exec.Cmd
+	// Scope.Paths makes the diff compare the working tree with HEAD and
+	// Commit run `git commit -- <paths>`, so the files are committed as
+	// they are without restaging.
+	Scope
 }
 
 // NewCLIRepository returns a concrete Repository backed by the system git binary.
@@ -28,11 +94,20 @@ func NewCLIRepository() *CLIRepository {
 		Exec: func(ctx context.Context, name string, args ...string) *exec.Cmd {
 			return exec.CommandContext(ctx, name, args...)
 		},
+		Scope: Scope{Timeout: DefaultTimeout},
 	}
 }
 
-func (r *CLIRepository) StagedDiff(ctx context.Context) (string, error) {
-	cmd := r.Exec(ctx, "git", "diff", "--staged", "-U0", "-M")
+// command prepares a non-interactive git command bounded by Scope.Timeout.
+// Commands that may open an editor or prompt (commit, rebase) use Exec
+// directly and only stop on cancellation.
+func (r *CLIRepository) command(ctx context.Context, args ...string) *boundedCmd {
+	return command(ctx, r.Exec, r.Timeout, "git", args...)
+}
+
+func (r *CLIRepository) StagedDiff(ctx context.Context, opts DiffOptions) (string, error) {
+	args := append(r.diffArgs(ctx, "-U0"), opts.args()...)
+	cmd := r.command(ctx, append(args, r.pathspec()...)...)
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	cmd.Stderr = &out
@@ -42,8 +117,412 @@ func (r *CLIRepository) StagedDiff(ctx context.Context) (string, error) {
 	return out.String(), nil
 }
 
+func (r *CLIRepository) StagedFiles(ctx context.Context) ([]string, error) {
+	cmd := r.command(ctx, append(r.diffArgs(ctx, "--name-only", "-z"), r.pathspec()...)...)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git diff --name-only failed: %v\n%s", err, stderr.String())
+	}
+
+	var files []string
+	for _, name := range strings.Split(out.String(), "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+func (r *CLIRepository) RecentCommits(ctx context.Context, files []string, n int) ([]string, error) {
+	if n <= 0 || len(files) == 0 {
+		return nil, nil
+	}
+
+	args := append([]string{"log", "-n", strconv.Itoa(n), "--format=%s", "--"}, files...)
+	cmd := r.command(ctx, args...)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// a fresh repository has no HEAD yet; that simply means no history
+		if strings.Contains(stderr.String(), "does not have any commits") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("git log failed: %v\n%s", err, stderr.String())
+	}
+
+	return util.TrimLines(out.String()), nil
+}
+
+// UntrackedDiff renders untracked, non-ignored files as new-file diffs so the
+// model can see modules that have not been staged yet. Binary files are
+// skipped and each file is cut to maxPerFile bytes.
+func (r *CLIRepository) UntrackedDiff(ctx context.Context, maxPerFile int) (string, error) {
+	cmd := r.command(ctx, append([]string{"ls-files", "--others", "--exclude-standard", "-z"}, r.pathspec()...)...)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git ls-files failed: %v\n%s", err, stderr.String())
+	}
+
+	var names []string
+	for _, name := range strings.Split(out.String(), "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return untrackedAsDiff("", names, maxPerFile), nil
+}
+
+func (r *CLIRepository) MergeState(ctx context.Context) (MergeState, error) {
+	// absolute, so the paths are right from any subdirectory and in linked
+	// worktrees, whose state lives in .git/worktrees/<name>
+	names := []string{"MERGE_HEAD", "MERGE_MSG", "rebase-merge", "rebase-apply", "REBASE_HEAD", "CHERRY_PICK_HEAD", "REVERT_HEAD"}
+	args := []string{"rev-parse", "--path-format=absolute"}
+	for _, name := range names {
+		args = append(args, "--git-path", name)
+	}
+	out, err := r.output(ctx, args...)
+	if err != nil {
+		return MergeState{}, err
+	}
+	paths := map[string]string{}
+	for i, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if i < len(names) {
+			paths[names[i]] = line
+		}
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(paths[name])
+		return err == nil
+	}
+
+	var state MergeState
+	head := ""
+	switch {
+	case exists("rebase-merge") || exists("rebase-apply"):
+		state.Operation, head = "rebase", "REBASE_HEAD"
+	case exists("CHERRY_PICK_HEAD"):
+		state.Operation, head = "cherry-pick", "CHERRY_PICK_HEAD"
+	case exists("REVERT_HEAD"):
+		state.Operation, head = "revert", "REVERT_HEAD"
+	}
+	if head != "" && exists(head) {
+		if subject, err := r.output(ctx, "log", "-n", "1", "--format=%s", head); err == nil {
+			state.Replaying = strings.TrimSpace(subject)
+		}
+	}
+
+	// MERGE_MSG outlives the merge (it is left behind by commits and
+	// aborted merges, and a stopped cherry-pick or revert prepares one
+	// too); only MERGE_HEAD means a merge is waiting to be committed
+	state.InProgress = exists("MERGE_HEAD")
+	if state.InProgress {
+		data, err := os.ReadFile(paths["MERGE_MSG"])
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return MergeState{}, fmt.Errorf("read MERGE_MSG: %w", err)
+		default:
+			state.Message = stripComments(string(data))
+		}
+		if log, err := r.output(ctx, "log", "-n", "50", "--format=%s", "HEAD..MERGE_HEAD"); err == nil {
+			state.Incoming = util.TrimLines(log)
+		}
+	}
+	if state.InProgress || state.Operation != "" {
+		if out, err := r.output(ctx, "diff", "--name-only", "--diff-filter=U", "-z"); err == nil {
+			for _, name := range strings.Split(out, "\x00") {
+				if name != "" {
+					state.Conflicts = append(state.Conflicts, name)
+				}
+			}
+		}
+	}
+	return state, nil
+}
+
+func (r *CLIRepository) Root(ctx context.Context) (string, error) {
+	out, err := r.output(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Log lists the commits in revRange (anything git log accepts, e.g. "A..B"),
+// newest first. A limit of zero means no limit.
+func (r *CLIRepository) Log(ctx context.Context, revRange string, limit int) ([]LogEntry, error) {
+	return r.PathLog(ctx, revRange, nil, limit)
+}
+
+// PathLog is Log limited to the commits touching paths. A single path is
+// followed across renames.
+func (r *CLIRepository) PathLog(ctx context.Context, revRange string, paths []string, limit int) ([]LogEntry, error) {
+	args := []string{"log", "--format=%H%x1f%an%x1f%aI%x1f%s%x1f%b%x1e"}
+	if limit > 0 {
+		args = append(args, "-n", strconv.Itoa(limit))
+	}
+	if len(paths) == 1 {
+		args = append(args, "--follow")
+	}
+	if revRange != "" {
+		args = append(args, revRange)
+	}
+	args = append(append(args, "--"), paths...)
+
+	out, err := r.output(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(out), nil
+}
+
+func parseLog(out string) []LogEntry {
+	var entries []LogEntry
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x1f")
+		if len(fields) < 5 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[2])
+		entries = append(entries, LogEntry{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Date:    date,
+			Subject: fields[3],
+			Body:    strings.TrimSpace(fields[4]),
+		})
+	}
+	return entries
+}
+
+// IsRevision reports whether rev names a commit, or both ends of a range
+// ("A..B", "A...B").
+func (r *CLIRepository) IsRevision(ctx context.Context, rev string) bool {
+	ends := strings.SplitN(strings.Replace(rev, "...", "..", 1), "..", 2)
+	for _, end := range ends {
+		if end == "" {
+			end = "HEAD"
+		}
+		if _, err := r.output(ctx, "rev-parse", "--verify", "--quiet", end+"^{commit}"); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ChangeDiff returns the changes of a single commit or of a range, limited
+// to paths when any are given, with a few lines of context for readers.
+func (r *CLIRepository) ChangeDiff(ctx context.Context, rev string, paths []string, opts DiffOptions) (string, error) {
+	args := append([]string{"show", "--format=", "-U3"}, opts.args()...)
+	if strings.Contains(rev, "..") {
+		args = append([]string{"diff", "-U3"}, opts.args()...)
+	}
+	return r.output(ctx, append(append(args, rev, "--"), paths...)...)
+}
+
+// CommitDiff returns the changes introduced by the commit hash.
+func (r *CLIRepository) CommitDiff(ctx context.Context, hash string, opts DiffOptions) (string, error) {
+	args := append([]string{"show", "--format=", "-U0"}, opts.args()...)
+	return r.output(ctx, append(args, hash, "--")...)
+}
+
+// Amend records an "amend! <hash>" commit on top of HEAD that replaces the
+// message of hash once Autosquash folds it in. It reuses HEAD's tree, so the
+// index and working tree are left alone.
+func (r *CLIRepository) Amend(ctx context.Context, hash, message string) error {
+	head, err := r.output(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	head = strings.TrimSpace(head)
+	created, err := r.output(ctx, "commit-tree", head+"^{tree}", "-p", head, "-m", "amend! "+hash, "-m", message)
+	if err != nil {
+		return err
+	}
+	_, err = r.output(ctx, "update-ref", "-m", "go-commitgen: reword "+hash, "HEAD", strings.TrimSpace(created), head)
+	return err
+}
+
+// Reword replaces the message of HEAD, leaving its tree, the index and
+// the working tree alone. The hooks already ran for the commit itself.
+func (r *CLIRepository) Reword(ctx context.Context, headline, body string) error {
+	args := []string{"commit", "--amend", "--only", "--no-verify", "--allow-empty", "-m", headline}
+	if strings.TrimSpace(body) != "" {
+		args = append(args, "-m", body)
+	}
+	_, err := r.output(ctx, args...)
+	return err
+}
+
+// Autosquash rebases onto base non-interactively, folding fixup!/amend!
+// commits into their targets. Local changes are stashed around the rebase
+// and branches pointing into the rewritten range (the layers of a stack)
+// are moved along.
+func (r *CLIRepository) Autosquash(ctx context.Context, base string) error {
+	cmd := r.Exec(ctx, "git", "rebase", "--interactive", "--autosquash", "--autostash", "--update-refs", base)
+	cmd.Env = append(os.Environ(), "GIT_SEQUENCE_EDITOR=:")
+	var stderr bytes.Buffer
+	cmd.Stdout = &stderr
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git rebase failed: %v\n%s", err, stderr.String())
+	}
+	return nil
+}
+
+// RangeDiff returns the combined diff of revRange ("A..B").
+func (r *CLIRepository) RangeDiff(ctx context.Context, revRange string, opts DiffOptions) (string, error) {
+	args := append([]string{"diff", "-U0"}, opts.args()...)
+	return r.output(ctx, append(args, revRange, "--")...)
+}
+
+// RangeFiles lists the files changed in revRange.
+func (r *CLIRepository) RangeFiles(ctx context.Context, revRange string) ([]string, error) {
+	out, err := r.output(ctx, "diff", "--name-only", "-z", revRange, "--")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// EnsureRef makes ref resolvable, fetching it first when it names a branch
+// of a configured remote ("origin/main") that is not known locally.
+func (r *CLIRepository) EnsureRef(ctx context.Context, ref string) error {
+	if _, err := r.output(ctx, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err == nil {
+		return nil
+	}
+	remote, branch, ok := strings.Cut(ref, "/")
+	if !ok || branch == "" {
+		return fmt.Errorf("unknown revision %q", ref)
+	}
+	if _, err := r.output(ctx, "remote", "get-url", remote); err != nil {
+		return fmt.Errorf("unknown revision %q", ref)
+	}
+	_, err := r.output(ctx, "fetch", "--quiet", remote, "refs/heads/"+branch+":refs/remotes/"+remote+"/"+branch)
+	return err
+}
+
+// MergeBase returns the best common ancestor of a and b.
+func (r *CLIRepository) MergeBase(ctx context.Context, a, b string) (string, error) {
+	out, err := r.output(ctx, "merge-base", a, b)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// HooksDir returns the directory git runs hooks from, honouring
+// core.hooksPath and linked worktrees.
+func (r *CLIRepository) HooksDir(ctx context.Context) (string, error) {
+	out, err := r.output(ctx, "rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// RemoteURL returns the URL of the named remote.
+func (r *CLIRepository) RemoteURL(ctx context.Context, name string) (string, error) {
+	out, err := r.output(ctx, "remote", "get-url", name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// StagedContent returns the staged (index) version of path, or the working
+// tree version when the change is limited to Paths.
+func (r *CLIRepository) StagedContent(ctx context.Context, path string) ([]byte, error) {
+	if len(r.Paths) > 0 {
+		root, err := r.Root(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return os.ReadFile(filepath.Join(root, path))
+	}
+	out, err := r.output(ctx, "show", ":"+path)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// UpdateStaged replaces the staged version of path with content, leaving
+// the working tree alone; with Paths, where the working tree is what gets
+// committed, the file itself is rewritten.
+func (r *CLIRepository) UpdateStaged(ctx context.Context, path string, content []byte) error {
+	if len(r.Paths) > 0 {
+		return writeWorktree(ctx, r, path, content)
+	}
+
+	entry, err := r.output(ctx, "ls-files", "-s", "--", path)
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(entry)
+	if len(fields) == 0 {
+		return fmt.Errorf("%s is not staged", path)
+	}
+
+	cmd := r.command(ctx, "hash-object", "-w", "--stdin", "--path", path)
+	var out, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git hash-object failed: %v\n%s", err, stderr.String())
+	}
+
+	_, err = r.output(ctx, "update-index", "--cacheinfo", fields[0]+","+strings.TrimSpace(out.String())+","+path)
+	return err
+}
+
+// emptyTree is the hash of git's empty tree, the base of the first commit.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// diffArgs starts a diff of what will be committed: the index, or the
+// working tree against HEAD for Paths (the empty tree before the first
+// commit, when HEAD does not resolve).
+func (r *CLIRepository) diffArgs(ctx context.Context, extra ...string) []string {
+	if len(r.Paths) > 0 {
+		base := "HEAD"
+		if _, err := r.output(ctx, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+			base = emptyTree
+		}
+		return append([]string{"diff", base}, extra...)
+	}
+	return append([]string{"diff", "--staged"}, extra...)
+}
+
+// output runs git with args and returns stdout, folding stderr into the error.
+func (r *CLIRepository) output(ctx context.Context, args ...string) (string, error) {
+	return r.outputIn(ctx, "", args...)
+}
+
+func stripComments(msg string) string {
+	lines := strings.Split(msg, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
 func (r *CLIRepository) CurrentBranch(ctx context.Context) (string, error) {
-	cmd := r.Exec(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd := r.command(ctx, "rev-parse", "--abbrev-ref", "HEAD")
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	cmd.Stderr = &out
@@ -57,7 +536,7 @@ func (r *CLIRepository) CurrentBranch(ctx context.Context) (string, error) {
 	}
 
 	out.Reset()
-	cmd = r.Exec(ctx, "git", "rev-parse", "--short", "HEAD")
+	cmd = r.command(ctx, "rev-parse", "--short", "HEAD")
 	cmd.Stdout = &out
 	cmd.Stderr = &out
 	if err := cmd.Run(); err != nil {
@@ -76,13 +555,157 @@ func (r *CLIRepository) Commit(ctx context.Context, headline, body string) error
 	if strings.TrimSpace(body) != "" {
 		args = append(args, "-m", body)
 	}
+	if r.AllowEmpty {
+		args = append(args, "--allow-empty")
+	}
+	args = append(args, r.pathspec()...)
 
 	cmd := r.Exec(ctx, "git", args...)
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = r.commitOutput()
 	cmd.Stderr = os.Stderr
+	if r.Author != "" {
+		name, email := splitIdent(r.Author)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME="+name, "GIT_AUTHOR_EMAIL="+email, "GIT_COMMITTER_NAME="+name, "GIT_COMMITTER_EMAIL="+email)
+	}
 	return cmd.Run()
 }
 
-func (r *CLIRepository) WriteHook(path, message string) error {
-	return os.WriteFile(path, []byte(message+"\n"), 0o644)
+// CommonDir returns the git directory shared by all worktrees of the
+// repository, which identifies it regardless of the worktree in use.
+func (r *CLIRepository) CommonDir(ctx context.Context) (string, error) {
+	out, err := r.output(ctx, "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// SubmoduleLog returns the subjects of the commits between old and new in
+// the submodule checked out at path (relative to the root), newest first.
+// It fails when the submodule is not checked out or lacks the commits.
+func (r *CLIRepository) SubmoduleLog(ctx context.Context, path, old, new string, limit int) ([]string, error) {
+	root, err := r.Root(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cmd := r.command(ctx, "log", "-n", strconv.Itoa(limit), "--format=%s", old+".."+new, "--")
+	cmd.Dir = filepath.Join(root, path)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git log in submodule %s failed: %v\n%s", path, err, stderr.String())
+	}
+	return util.TrimLines(out.String()), nil
+}
+
+// AuthorLog returns the commits author made since since (a date git
+// understands: "yesterday", "last monday", "2024-05-01") on any branch
+// of the repository in dir, newest first and without merges. dir "" is
+// the current repository and author "" its configured user.email.
+func (r *CLIRepository) AuthorLog(ctx context.Context, dir, since, author string, limit int) ([]LogEntry, error) {
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+	}
+	if author == "" {
+		email, err := r.outputIn(ctx, dir, "config", "user.email")
+		if err != nil {
+			return nil, fmt.Errorf("no author given and no user.email configured in %s", stringsOr(dir, "the repository"))
+		}
+		author = strings.TrimSpace(email)
+	}
+	args := []string{"log", "--all", "--no-merges", "--format=%H%x1f%an%x1f%aI%x1f%s%x1f%b%x1e", "--author=" + author}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	if limit > 0 {
+		args = append(args, "-n", strconv.Itoa(limit))
+	}
+	out, err := r.outputIn(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(out), nil
+}
+
+// outputIn is output run in dir; "" is the working directory.
+func (r *CLIRepository) outputIn(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := r.command(ctx, args...)
+	cmd.Dir = dir
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if dir != "" {
+			return "", fmt.Errorf("git %s in %s failed: %w\n%s", args[0], dir, err, stderr.String())
+		}
+		return "", fmt.Errorf("git %s failed: %w\n%s", args[0], err, stderr.String())
+	}
+	return out.String(), nil
+}
+
+// Fixup records the staged changes as a "fixup! <subject>" commit for hash,
+// to be folded into it by an autosquash rebase.
+func (r *CLIRepository) Fixup(ctx context.Context, hash string) error {
+	args := append([]string{"commit", "--fixup=" + hash}, r.pathspec()...)
+	cmd := r.Exec(ctx, "git", args...)
+	cmd.Stdout = r.commitOutput()
+	cmd.Stderr = os.Stderr
+	if r.Author != "" {
+		name, email := splitIdent(r.Author)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME="+name, "GIT_AUTHOR_EMAIL="+email, "GIT_COMMITTER_NAME="+name, "GIT_COMMITTER_EMAIL="+email)
+	}
+	return cmd.Run()
+}
+
+// Blame returns the commit that last changed each line from start to end
+// of path in HEAD.
+func (r *CLIRepository) Blame(ctx context.Context, path string, start, end int) ([]string, error) {
+	lines, err := r.BlameLines(ctx, path, start, end)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(lines))
+	for i, l := range lines {
+		hashes[i] = l.Hash
+	}
+	return hashes, nil
+}
+
+// BlameLine is the commit that last changed a line.
+type BlameLine struct {
+	Hash    string
+	Subject string
+}
+
+// BlameLines is Blame with the subject of each commit.
+func (r *CLIRepository) BlameLines(ctx context.Context, path string, start, end int) ([]BlameLine, error) {
+	out, err := r.output(ctx, "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", start, end), "HEAD", "--", path)
+	if err != nil {
+		return nil, err
+	}
+	var lines []BlameLine
+	// the commit headers, "summary" among them, follow only the first line
+	// blamed on each commit
+	subjects := map[string]string{}
+	current := ""
+	for _, line := range strings.Split(out, "\n") {
+		if subject, ok := strings.CutPrefix(line, "summary "); ok && current != "" {
+			subjects[current] = subject
+			continue
+		}
+		// every blamed line starts with "<hash> <orig line> <final line>";
+		// its content follows on a line starting with a tab
+		fields := strings.Fields(line)
+		if !strings.HasPrefix(line, "\t") && len(fields) >= 3 && len(fields[0]) == 40 && strings.Trim(fields[0], "0123456789abcdef") == "" {
+			current = fields[0]
+			lines = append(lines, BlameLine{Hash: current})
+		}
+	}
+	for i := range lines {
+		lines[i].Subject = subjects[lines[i].Hash]
+	}
+	return lines, nil
 }
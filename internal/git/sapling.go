@@ -0,0 +1,187 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// slLogTemplate renders commits as the records parseLog understands.
+const slLogTemplate = "{node}\x1f{author|person}\x1f{date|rfc3339date}\x1f{desc|firstline}\x1f{desc}\x1e"
+
+// SaplingRepository drives a Sapling repository. Like Mercurial it has no
+// staging area, so the pending changes of the working copy are described.
+type SaplingRepository struct {
+	Exec execFunc
+	Scope
+}
+
+// NewSaplingRepository returns a Repository backed by the sl binary.
+func NewSaplingRepository() *SaplingRepository {
+	return &SaplingRepository{Exec: defaultExec, Scope: Scope{Timeout: DefaultTimeout}}
+}
+
+func (r *SaplingRepository) output(ctx context.Context, args ...string) (string, error) {
+	return run(ctx, r.Exec, r.Timeout, "sl", args...)
+}
+
+func (r *SaplingRepository) StagedDiff(ctx context.Context, opts DiffOptions) (string, error) {
+	args := []string{"diff", "--git", "-U0"}
+	if opts.IgnoreWhitespace {
+		args = append(args, "-w")
+	}
+	return r.output(ctx, append(args, r.pathspec()...)...)
+}
+
+func (r *SaplingRepository) StagedFiles(ctx context.Context) ([]string, error) {
+	return r.status(ctx, "-mard")
+}
+
+// status lists root-relative paths in the given status classes.
+func (r *SaplingRepository) status(ctx context.Context, classes string) ([]string, error) {
+	out, err := r.output(ctx, append([]string{"status", classes, "--no-status", "--root-relative", "-0"}, r.pathspec()...)...)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+func (r *SaplingRepository) RecentCommits(ctx context.Context, files []string, n int) ([]string, error) {
+	if n <= 0 || len(files) == 0 {
+		return nil, nil
+	}
+	root, err := r.Root(ctx)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"log", "-l", strconv.Itoa(n), "-T", "{desc|firstline}\n", "--"}
+	for _, f := range files {
+		args = append(args, filepath.Join(root, f))
+	}
+	out, err := r.output(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return util.TrimLines(out), nil
+}
+
+func (r *SaplingRepository) UntrackedDiff(ctx context.Context, maxPerFile int) (string, error) {
+	names, err := r.status(ctx, "-u")
+	if err != nil {
+		return "", err
+	}
+	root, err := r.Root(ctx)
+	if err != nil {
+		return "", err
+	}
+	return untrackedAsDiff(root, names, maxPerFile), nil
+}
+
+// MergeState reports an uncommitted merge when the working copy has a
+// second parent, and the files `sl resolve` still lists as unresolved.
+func (r *SaplingRepository) MergeState(ctx context.Context) (MergeState, error) {
+	var state MergeState
+	if out, err := r.output(ctx, "resolve", "--list"); err == nil {
+		for _, line := range util.TrimLines(out) {
+			if path, ok := strings.CutPrefix(line, "U "); ok {
+				state.Conflicts = append(state.Conflicts, path)
+			}
+		}
+	}
+	out, err := r.output(ctx, "log", "-r", "p2()", "-T", "{node}")
+	if err != nil || strings.TrimSpace(out) == "" {
+		return state, nil
+	}
+	state.InProgress = true
+	if log, err := r.output(ctx, "log", "-l", "50", "-r", "reverse(only(p2(), p1()))", "-T", "{desc|firstline}\n"); err == nil {
+		state.Incoming = util.TrimLines(log)
+	}
+	return state, nil
+}
+
+func (r *SaplingRepository) Root(ctx context.Context) (string, error) {
+	out, err := r.output(ctx, "root")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Log lists commits in revRange, newest first. A git-style "A..B" range is
+// translated to only(B, A); anything else is passed through as a revset.
+func (r *SaplingRepository) Log(ctx context.Context, revRange string, limit int) ([]LogEntry, error) {
+	revset := "::."
+	if from, to, ok := strings.Cut(revRange, ".."); ok && !strings.Contains(to, ".") {
+		revset = "only(" + stringsOr(to, ".") + ", " + stringsOr(from, "null") + ")"
+	} else if revRange != "" {
+		revset = revRange
+	}
+	args := []string{"log", "-r", "reverse(" + revset + ")", "-T", slLogTemplate}
+	if limit > 0 {
+		args = append(args, "-l", strconv.Itoa(limit))
+	}
+	out, err := r.output(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return splitEntries(out), nil
+}
+
+// StagedContent reads path from the working copy.
+func (r *SaplingRepository) StagedContent(ctx context.Context, path string) ([]byte, error) {
+	root, err := r.Root(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(root, path))
+}
+
+// UpdateStaged rewrites the working-copy file, which is what gets committed.
+func (r *SaplingRepository) UpdateStaged(ctx context.Context, path string, content []byte) error {
+	return writeWorktree(ctx, r, path, content)
+}
+
+// CurrentBranch returns the active bookmark, falling back to the short hash.
+func (r *SaplingRepository) CurrentBranch(ctx context.Context) (string, error) {
+	out, err := r.output(ctx, "log", "-r", ".", "-T", "{if(activebookmark, activebookmark, node|short)}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (r *SaplingRepository) Commit(ctx context.Context, headline, body string) error {
+	msg, err := fullMessage(headline, body)
+	if err != nil {
+		return err
+	}
+	args := []string{"commit", "-m", msg}
+	if r.AllowEmpty {
+		args = append(args, "--config", "ui.allowemptycommit=true")
+	}
+	if r.Author != "" {
+		args = append(args, "--user", r.Author)
+	}
+	return commitInteractive(ctx, r.Exec, r.Scope, nil, "sl", append(args, r.pathspec()...)...)
+}
+
+func (r *SaplingRepository) WriteHook(path, message string) error {
+	return os.WriteFile(path, []byte(message+"\n"), 0o644)
+}
+
+func stringsOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func stashRef(n int) string {
+	return fmt.Sprintf("stash@{%d}", n)
+}
+
+// stashShow runs `git stash show` for entry n, including the untracked
+// files stashed with -u where git supports it (2.32+).
+func (r *CLIRepository) stashShow(ctx context.Context, n int, args ...string) (string, error) {
+	show := append([]string{"stash", "show", "--include-untracked"}, args...)
+	out, err := r.output(ctx, append(show, stashRef(n))...)
+	if err != nil {
+		show = append([]string{"stash", "show"}, args...)
+		out, err = r.output(ctx, append(show, stashRef(n))...)
+	}
+	return out, err
+}
+
+// stashedIndex reports whether stash entry n recorded staged changes: its
+// second parent, the index, differs from the commit it was made on.
+func (r *CLIRepository) stashedIndex(ctx context.Context, n int) (bool, error) {
+	out, err := r.output(ctx, "diff", "--name-only", stashRef(n)+"^1", stashRef(n)+"^2")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+// StashDiff returns the changes stash entry n will commit, relative to the
+// commit it was made on: the ones that were staged when it was made, or
+// all of them when nothing was.
+func (r *CLIRepository) StashDiff(ctx context.Context, n int, opts DiffOptions) (string, error) {
+	staged, err := r.stashedIndex(ctx, n)
+	if err != nil {
+		return "", err
+	}
+	if staged {
+		return r.output(ctx, append(append([]string{"diff", "-U0"}, opts.args()...), stashRef(n)+"^1", stashRef(n)+"^2")...)
+	}
+	return r.stashShow(ctx, n, append([]string{"-p", "-U0"}, opts.args()...)...)
+}
+
+// StashSubject returns the message of stash entry n: "WIP on <branch>:
+// <commit>" or "On <branch>: <message>" for `git stash push -m`.
+func (r *CLIRepository) StashSubject(ctx context.Context, n int) (string, error) {
+	out, err := r.output(ctx, "log", "-n", "1", "--format=%s", stashRef(n))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// StashPop pops stash entry n and stages what StashDiff describes. When the
+// entry recorded staged changes its index is restored (--index), so the
+// edits that were unstaged stay unstaged; otherwise every path it touched
+// is staged. A pop that conflicts leaves the entry in the stash list, as
+// git does.
+func (r *CLIRepository) StashPop(ctx context.Context, n int) error {
+	staged, err := r.stashedIndex(ctx, n)
+	if err != nil {
+		return err
+	}
+	if staged {
+		_, err := r.output(ctx, "stash", "pop", "--index", stashRef(n))
+		return err
+	}
+
+	names, err := r.stashShow(ctx, n, "--name-only", "-z", "--no-renames")
+	if err != nil {
+		return err
+	}
+	if _, err := r.output(ctx, "stash", "pop", stashRef(n)); err != nil {
+		return err
+	}
+	args := []string{"add", "-A", "--"}
+	for _, name := range strings.Split(names, "\x00") {
+		if name != "" {
+			args = append(args, name)
+		}
+	}
+	if len(args) == 3 {
+		return nil
+	}
+	_, err = r.output(ctx, args...)
+	return err
+}
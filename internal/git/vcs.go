@@ -0,0 +1,213 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// VCS names a supported version control system.
+type VCS string
+
+const (
+	Git     VCS = "git"
+	Jujutsu VCS = "jj"
+	Sapling VCS = "sl"
+)
+
+// markers maps the metadata directory of each VCS to its kind, in detection
+// priority: a colocated jj repository also has .git but should be driven
+// through jj so the working-copy commit is described instead of bypassed.
+var markers = []struct {
+	dir string
+	vcs VCS
+}{
+	{".jj", Jujutsu},
+	{".sl", Sapling},
+	{".git", Git},
+}
+
+// Detect walks up from dir and reports the VCS of the closest repository,
+// defaulting to git when no marker is found.
+func Detect(dir string) VCS {
+	for {
+		for _, m := range markers {
+			if _, err := os.Stat(filepath.Join(dir, m.dir)); err == nil {
+				return m.vcs
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return Git
+		}
+		dir = parent
+	}
+}
+
+// Scope narrows what a repository diffs and commits.
+type Scope struct {
+	// Paths limits the change to these pathspecs.
+	Paths []string
+	// AllowEmpty lets Commit record a commit without changes.
+	AllowEmpty bool
+	// Timeout bounds each non-interactive subprocess; zero disables it.
+	Timeout time.Duration
+	// Author records commits as this "Name <email>", author and committer
+	// alike, and is the identity signed off with; empty uses the VCS's
+	// configuration.
+	Author string
+	// Output receives what the commit command prints; nil is stdout.
+	Output io.Writer
+}
+
+// commitOutput is where the commit command's output goes.
+func (s Scope) commitOutput() io.Writer {
+	if s.Output == nil {
+		return os.Stdout
+	}
+	return s.Output
+}
+
+// splitIdent splits "Name <email>".
+func splitIdent(ident string) (name, email string) {
+	name, email, _ = strings.Cut(ident, "<")
+	return strings.TrimSpace(name), strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(email), ">"))
+}
+
+// pathspec returns the "-- <paths>" suffix, or nothing without Paths.
+func (s Scope) pathspec() []string {
+	if len(s.Paths) == 0 {
+		return nil
+	}
+	return append([]string{"--"}, s.Paths...)
+}
+
+// Open returns the Repository for kind, limited to scope; "auto" or ""
+// detects the kind from the working directory.
+func Open(kind string, scope Scope) (Repository, error) {
+	vcs := VCS(kind)
+	if kind == "" || kind == "auto" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		vcs = Detect(wd)
+	}
+
+	switch vcs {
+	case Git:
+		repo := NewCLIRepository()
+		repo.Scope = scope
+		return repo, nil
+	case Jujutsu:
+		repo := NewJJRepository()
+		repo.Scope = scope
+		return repo, nil
+	case Sapling:
+		repo := NewSaplingRepository()
+		repo.Scope = scope
+		return repo, nil
+	}
+	return nil, fmt.Errorf("unsupported vcs %q (want auto, git, jj or sl)", kind)
+}
+
+type execFunc func(ctx context.Context, name string, args ...string) *exec.Cmd
+
+func defaultExec(ctx context.Context, name string, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, name, args...)
+}
+
+// run executes name with args under timeout and returns stdout, folding
+// stderr into the error.
+func run(ctx context.Context, execFn execFunc, timeout time.Duration, name string, args ...string) (string, error) {
+	cmd := command(ctx, execFn, timeout, name, args...)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s %s failed: %w\n%s", name, args[0], err, stderr.String())
+	}
+	return out.String(), nil
+}
+
+// commitInteractive runs a commit command with its output attached to the
+// terminal (or scope.Output), like CLIRepository.Commit does for git. env
+// is added to the environment.
+func commitInteractive(ctx context.Context, execFn execFunc, scope Scope, env []string, name string, args ...string) error {
+	cmd := execFn(ctx, name, args...)
+	cmd.Stdout = scope.commitOutput()
+	cmd.Stderr = os.Stderr
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd.Run()
+}
+
+// fullMessage joins headline and body the way git does for two -m flags.
+func fullMessage(headline, body string) (string, error) {
+	if strings.TrimSpace(headline) == "" {
+		return "", fmt.Errorf("empty headline")
+	}
+	if strings.TrimSpace(body) == "" {
+		return headline, nil
+	}
+	return headline + "\n\n" + body, nil
+}
+
+// writeWorktree overwrites path under the repository root, keeping its mode.
+func writeWorktree(ctx context.Context, repo interface {
+	Root(ctx context.Context) (string, error)
+}, path string, content []byte) error {
+	root, err := repo.Root(ctx)
+	if err != nil {
+		return err
+	}
+	full := filepath.Join(root, path)
+	info, err := os.Stat(full)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(full, content, info.Mode().Perm())
+}
+
+// untrackedAsDiff renders the listed files as new-file diffs, skipping
+// binaries and cutting each to maxPerFile bytes.
+func untrackedAsDiff(root string, names []string, maxPerFile int) string {
+	var b strings.Builder
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil || bytes.IndexByte(data, 0) != -1 {
+			continue
+		}
+		content := util.TrimTo(string(data), maxPerFile)
+
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\nnew file (untracked)\n--- /dev/null\n+++ b/%s\n", name, name, name)
+		for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+			b.WriteString("+" + line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// splitEntries parses the "\x1f"-separated, "\x1e"-terminated log records
+// produced by the jj and Sapling templates, where the last field is the
+// full description rather than git's separate body.
+func splitEntries(out string) []LogEntry {
+	entries := parseLog(out)
+	for i, e := range entries {
+		if _, body, ok := strings.Cut(e.Body, "\n"); ok {
+			entries[i].Body = strings.TrimSpace(body)
+		} else {
+			entries[i].Body = ""
+		}
+	}
+	return entries
+}
@@ -0,0 +1,233 @@
+// Package gitea creates pull requests and posts reviews through the
+// Gitea API, which Forgejo serves unchanged.
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/forge"
+)
+
+// pullRequest is the subset of the Gitea pull request payload used here.
+type pullRequest struct {
+	Number int    `json:"number"`
+	URL    string `json:"html_url"`
+	Head   struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (p pullRequest) forge() forge.PullRequest {
+	return forge.PullRequest{Number: p.Number, URL: p.URL, HeadSHA: p.Head.SHA, BaseRef: p.Base.Ref}
+}
+
+type comment struct {
+	Path        string `json:"path"`
+	NewPosition int    `json:"new_position"`
+	Body        string `json:"body"`
+}
+
+// Client talks to the API of a Gitea or Forgejo instance for one repository.
+type Client struct {
+	HTTP *http.Client
+	// URL is the instance, e.g. https://codeberg.org.
+	URL   string
+	Token string
+	Owner string
+	Repo  string
+}
+
+var _ forge.Forge = Client{}
+
+// pageSize is the largest page Gitea serves by default.
+const pageSize = 50
+
+// User returns the login the token belongs to.
+func (c Client) User(ctx context.Context) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/user", nil, &user); err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
+
+// FindPullRequest returns the open pull request whose head is branch, or
+// forge.ErrNoPullRequest. The list API cannot filter by head, so the open
+// pull requests are paged through.
+func (c Client) FindPullRequest(ctx context.Context, branch string) (forge.PullRequest, error) {
+	for page := 1; ; page++ {
+		var found []pullRequest
+		query := url.Values{"state": {"open"}, "limit": {fmt.Sprint(pageSize)}, "page": {fmt.Sprint(page)}}
+		if err := c.do(ctx, http.MethodGet, c.repoPath("pulls")+"?"+query.Encode(), nil, &found); err != nil {
+			return forge.PullRequest{}, err
+		}
+		for _, pr := range found {
+			if pr.Head.Ref == branch {
+				return pr.forge(), nil
+			}
+		}
+		if len(found) < pageSize {
+			return forge.PullRequest{}, fmt.Errorf("%w %s in %s/%s", forge.ErrNoPullRequest, branch, c.Owner, c.Repo)
+		}
+	}
+}
+
+// CreatePullRequest opens a pull request from branch into base.
+func (c Client) CreatePullRequest(ctx context.Context, branch, base, title, body string) (forge.PullRequest, error) {
+	payload := map[string]string{"head": branch, "base": base, "title": title, "body": body}
+	var created pullRequest
+	if err := c.do(ctx, http.MethodPost, c.repoPath("pulls"), payload, &created); err != nil {
+		return forge.PullRequest{}, err
+	}
+	return created.forge(), nil
+}
+
+// UpdatePullRequest replaces the title and description of pr.
+func (c Client) UpdatePullRequest(ctx context.Context, pr forge.PullRequest, title, body string) error {
+	payload := map[string]string{"title": title, "body": body}
+	var updated pullRequest
+	return c.do(ctx, http.MethodPatch, c.repoPath(fmt.Sprintf("pulls/%d", pr.Number)), payload, &updated)
+}
+
+// PostReview submits a COMMENT review with body and the line comments on
+// the pull request's head commit, returning the review's URL.
+func (c Client) PostReview(ctx context.Context, pr forge.PullRequest, body string, comments []forge.Comment) (string, error) {
+	payload := struct {
+		CommitID string    `json:"commit_id"`
+		Event    string    `json:"event"`
+		Body     string    `json:"body"`
+		Comments []comment `json:"comments"`
+	}{CommitID: pr.HeadSHA, Event: "COMMENT", Body: body, Comments: []comment{}}
+	for _, cm := range comments {
+		payload.Comments = append(payload.Comments, comment{Path: cm.Path, NewPosition: cm.Line, Body: cm.Body})
+	}
+	var review struct {
+		URL string `json:"html_url"`
+	}
+	if err := c.do(ctx, http.MethodPost, c.repoPath(fmt.Sprintf("pulls/%d/reviews", pr.Number)), payload, &review); err != nil {
+		return "", err
+	}
+	if review.URL == "" {
+		return pr.URL, nil
+	}
+	return review.URL, nil
+}
+
+// CreateIssue opens an issue. Gitea takes label IDs, so the names are
+// looked up among the repository's labels and unknown ones are dropped.
+func (c Client) CreateIssue(ctx context.Context, title, body string, labels []string) (forge.Issue, error) {
+	ids, err := c.labelIDs(ctx, labels)
+	if err != nil {
+		return forge.Issue{}, err
+	}
+	payload := struct {
+		Title  string  `json:"title"`
+		Body   string  `json:"body"`
+		Labels []int64 `json:"labels,omitempty"`
+	}{title, body, ids}
+	var created struct {
+		Number int    `json:"number"`
+		URL    string `json:"html_url"`
+	}
+	if err := c.do(ctx, http.MethodPost, c.repoPath("issues"), payload, &created); err != nil {
+		return forge.Issue{}, err
+	}
+	return forge.Issue{Number: created.Number, URL: created.URL}, nil
+}
+
+// Issue reads issue number.
+func (c Client) Issue(ctx context.Context, number int) (forge.Issue, error) {
+	var issue struct {
+		Number int    `json:"number"`
+		URL    string `json:"html_url"`
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.repoPath(fmt.Sprintf("issues/%d", number)), nil, &issue); err != nil {
+		return forge.Issue{}, err
+	}
+	return forge.Issue{Number: issue.Number, URL: issue.URL, Title: issue.Title, Body: issue.Body}, nil
+}
+
+// labelIDs maps label names to the IDs of the repository's labels.
+func (c Client) labelIDs(ctx context.Context, names []string) ([]int64, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	want := map[string]bool{}
+	for _, n := range names {
+		want[strings.ToLower(n)] = true
+	}
+	var ids []int64
+	for page := 1; ; page++ {
+		var found []struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		}
+		query := url.Values{"limit": {fmt.Sprint(pageSize)}, "page": {fmt.Sprint(page)}}
+		if err := c.do(ctx, http.MethodGet, c.repoPath("labels")+"?"+query.Encode(), nil, &found); err != nil {
+			return nil, err
+		}
+		for _, l := range found {
+			if want[strings.ToLower(l.Name)] {
+				ids = append(ids, l.ID)
+			}
+		}
+		if len(found) < pageSize {
+			return ids, nil
+		}
+	}
+}
+
+func (c Client) repoPath(path string) string {
+	return "/repos/" + url.PathEscape(c.Owner) + "/" + url.PathEscape(c.Repo) + "/" + path
+}
+
+func (c Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.URL, "/")+"/api/v1"+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("gitea %s %s: %d %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(out)
+}
@@ -0,0 +1,192 @@
+// Package github creates pull requests and posts review findings through
+// the GitHub REST API.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/forge"
+)
+
+// pullRequest is the subset of the GitHub pull request payload used here.
+type pullRequest struct {
+	Number int    `json:"number"`
+	URL    string `json:"html_url"`
+	Head   struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (p pullRequest) forge() forge.PullRequest {
+	return forge.PullRequest{Number: p.Number, URL: p.URL, HeadSHA: p.Head.SHA, BaseRef: p.Base.Ref}
+}
+
+type comment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"`
+	Body string `json:"body"`
+}
+
+// Client talks to the GitHub REST API for one repository; the zero HTTP
+// and API fields use http.DefaultClient and api.github.com.
+type Client struct {
+	HTTP *http.Client
+	// API overrides https://api.github.com, for GitHub Enterprise.
+	API   string
+	Token string
+	Owner string
+	Repo  string
+}
+
+var _ forge.Forge = Client{}
+
+// User returns the login the token belongs to.
+func (c Client) User(ctx context.Context) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/user", nil, &user); err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
+
+// FindPullRequest returns the open pull request whose head is branch, or
+// forge.ErrNoPullRequest.
+func (c Client) FindPullRequest(ctx context.Context, branch string) (forge.PullRequest, error) {
+	var found []pullRequest
+	query := url.Values{"state": {"open"}, "head": {c.Owner + ":" + branch}}
+	if err := c.do(ctx, http.MethodGet, c.repoPath("pulls")+"?"+query.Encode(), nil, &found); err != nil {
+		return forge.PullRequest{}, err
+	}
+	if len(found) == 0 {
+		return forge.PullRequest{}, fmt.Errorf("%w %s in %s/%s", forge.ErrNoPullRequest, branch, c.Owner, c.Repo)
+	}
+	return found[0].forge(), nil
+}
+
+// CreatePullRequest opens a pull request from branch into base.
+func (c Client) CreatePullRequest(ctx context.Context, branch, base, title, body string) (forge.PullRequest, error) {
+	payload := map[string]string{"head": branch, "base": base, "title": title, "body": body}
+	var created pullRequest
+	if err := c.do(ctx, http.MethodPost, c.repoPath("pulls"), payload, &created); err != nil {
+		return forge.PullRequest{}, err
+	}
+	return created.forge(), nil
+}
+
+// UpdatePullRequest replaces the title and description of pr.
+func (c Client) UpdatePullRequest(ctx context.Context, pr forge.PullRequest, title, body string) error {
+	payload := map[string]string{"title": title, "body": body}
+	var updated pullRequest
+	return c.do(ctx, http.MethodPatch, c.repoPath(fmt.Sprintf("pulls/%d", pr.Number)), payload, &updated)
+}
+
+// PostReview submits a COMMENT review with body and the line comments on
+// the pull request's head commit, returning the review's URL.
+func (c Client) PostReview(ctx context.Context, pr forge.PullRequest, body string, comments []forge.Comment) (string, error) {
+	payload := struct {
+		CommitID string    `json:"commit_id"`
+		Event    string    `json:"event"`
+		Body     string    `json:"body"`
+		Comments []comment `json:"comments"`
+	}{CommitID: pr.HeadSHA, Event: "COMMENT", Body: body, Comments: []comment{}}
+	for _, cm := range comments {
+		payload.Comments = append(payload.Comments, comment{Path: cm.Path, Line: cm.Line, Side: "RIGHT", Body: cm.Body})
+	}
+	var review struct {
+		URL string `json:"html_url"`
+	}
+	if err := c.do(ctx, http.MethodPost, c.repoPath(fmt.Sprintf("pulls/%d/reviews", pr.Number)), payload, &review); err != nil {
+		return "", err
+	}
+	return review.URL, nil
+}
+
+// CreateIssue opens an issue; GitHub creates labels that do not exist yet
+// when the token may push.
+func (c Client) CreateIssue(ctx context.Context, title, body string, labels []string) (forge.Issue, error) {
+	payload := struct {
+		Title  string   `json:"title"`
+		Body   string   `json:"body"`
+		Labels []string `json:"labels,omitempty"`
+	}{title, body, labels}
+	var created struct {
+		Number int    `json:"number"`
+		URL    string `json:"html_url"`
+	}
+	if err := c.do(ctx, http.MethodPost, c.repoPath("issues"), payload, &created); err != nil {
+		return forge.Issue{}, err
+	}
+	return forge.Issue{Number: created.Number, URL: created.URL}, nil
+}
+
+// Issue reads issue number; pull requests are issues to this endpoint too.
+func (c Client) Issue(ctx context.Context, number int) (forge.Issue, error) {
+	var issue struct {
+		Number int    `json:"number"`
+		URL    string `json:"html_url"`
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.repoPath(fmt.Sprintf("issues/%d", number)), nil, &issue); err != nil {
+		return forge.Issue{}, err
+	}
+	return forge.Issue{Number: issue.Number, URL: issue.URL, Title: issue.Title, Body: issue.Body}, nil
+}
+
+func (c Client) repoPath(path string) string {
+	return "/repos/" + url.PathEscape(c.Owner) + "/" + url.PathEscape(c.Repo) + "/" + path
+}
+
+func (c Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	api := strings.TrimRight(c.API, "/")
+	if api == "" {
+		api = "https://api.github.com"
+	}
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, api+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("github %s %s: %d %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(out)
+}
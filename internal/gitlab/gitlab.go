@@ -0,0 +1,204 @@
+// Package gitlab creates merge requests and posts review findings as
+// discussions through the GitLab REST API.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/forge"
+)
+
+// mergeRequest is the subset of the GitLab merge request payload used here.
+type mergeRequest struct {
+	IID          int    `json:"iid"`
+	URL          string `json:"web_url"`
+	SHA          string `json:"sha"`
+	TargetBranch string `json:"target_branch"`
+	DiffRefs     struct {
+		BaseSHA  string `json:"base_sha"`
+		StartSHA string `json:"start_sha"`
+		HeadSHA  string `json:"head_sha"`
+	} `json:"diff_refs"`
+}
+
+func (m mergeRequest) forge() forge.PullRequest {
+	head := m.DiffRefs.HeadSHA
+	if head == "" {
+		head = m.SHA
+	}
+	return forge.PullRequest{
+		Number:   m.IID,
+		URL:      m.URL,
+		HeadSHA:  head,
+		BaseRef:  m.TargetBranch,
+		BaseSHA:  m.DiffRefs.BaseSHA,
+		StartSHA: m.DiffRefs.StartSHA,
+	}
+}
+
+// Client talks to the GitLab REST API for one project.
+type Client struct {
+	HTTP *http.Client
+	// URL is the GitLab instance, e.g. https://gitlab.com.
+	URL string
+	// Token is a personal, project or group access token with api scope.
+	Token string
+	// Project is the full project path including subgroups.
+	Project string
+}
+
+var _ forge.Forge = Client{}
+
+// User returns the username the token belongs to.
+func (c Client) User(ctx context.Context) (string, error) {
+	var user struct {
+		Username string `json:"username"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/user", nil, &user); err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+// FindPullRequest returns the open merge request whose source is branch,
+// or forge.ErrNoPullRequest.
+func (c Client) FindPullRequest(ctx context.Context, branch string) (forge.PullRequest, error) {
+	var found []mergeRequest
+	query := url.Values{"state": {"opened"}, "source_branch": {branch}}
+	if err := c.do(ctx, http.MethodGet, c.projectPath("merge_requests")+"?"+query.Encode(), nil, &found); err != nil {
+		return forge.PullRequest{}, err
+	}
+	if len(found) == 0 {
+		return forge.PullRequest{}, fmt.Errorf("%w %s in %s", forge.ErrNoPullRequest, branch, c.Project)
+	}
+	// the list omits diff_refs; the single merge request has them
+	var mr mergeRequest
+	if err := c.do(ctx, http.MethodGet, c.projectPath(fmt.Sprintf("merge_requests/%d", found[0].IID)), nil, &mr); err != nil {
+		return forge.PullRequest{}, err
+	}
+	return mr.forge(), nil
+}
+
+// CreatePullRequest opens a merge request from branch into base.
+func (c Client) CreatePullRequest(ctx context.Context, branch, base, title, body string) (forge.PullRequest, error) {
+	payload := map[string]string{"source_branch": branch, "target_branch": base, "title": title, "description": body}
+	var created mergeRequest
+	if err := c.do(ctx, http.MethodPost, c.projectPath("merge_requests"), payload, &created); err != nil {
+		return forge.PullRequest{}, err
+	}
+	return created.forge(), nil
+}
+
+// UpdatePullRequest replaces the title and description of the merge request.
+func (c Client) UpdatePullRequest(ctx context.Context, pr forge.PullRequest, title, body string) error {
+	payload := map[string]string{"title": title, "description": body}
+	var updated mergeRequest
+	return c.do(ctx, http.MethodPut, c.projectPath(fmt.Sprintf("merge_requests/%d", pr.Number)), payload, &updated)
+}
+
+// PostReview posts body as a discussion and every comment as a discussion
+// on its line of the merge request diff. GitLab has no review object, so
+// the merge request URL is returned.
+func (c Client) PostReview(ctx context.Context, pr forge.PullRequest, body string, comments []forge.Comment) (string, error) {
+	path := c.projectPath(fmt.Sprintf("merge_requests/%d/discussions", pr.Number))
+	var discussion struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, path, map[string]string{"body": body}, &discussion); err != nil {
+		return "", err
+	}
+	for _, cm := range comments {
+		payload := map[string]interface{}{
+			"body": cm.Body,
+			"position": map[string]interface{}{
+				"position_type": "text",
+				"base_sha":      pr.BaseSHA,
+				"start_sha":     pr.StartSHA,
+				"head_sha":      pr.HeadSHA,
+				"old_path":      cm.Path,
+				"new_path":      cm.Path,
+				"new_line":      cm.Line,
+			},
+		}
+		if err := c.do(ctx, http.MethodPost, path, payload, &discussion); err != nil {
+			return "", fmt.Errorf("comment on %s:%d: %w", cm.Path, cm.Line, err)
+		}
+	}
+	return pr.URL, nil
+}
+
+// CreateIssue opens an issue; GitLab creates labels that do not exist yet.
+func (c Client) CreateIssue(ctx context.Context, title, body string, labels []string) (forge.Issue, error) {
+	payload := map[string]string{"title": title, "description": body}
+	if len(labels) > 0 {
+		payload["labels"] = strings.Join(labels, ",")
+	}
+	var created struct {
+		IID int    `json:"iid"`
+		URL string `json:"web_url"`
+	}
+	if err := c.do(ctx, http.MethodPost, c.projectPath("issues"), payload, &created); err != nil {
+		return forge.Issue{}, err
+	}
+	return forge.Issue{Number: created.IID, URL: created.URL}, nil
+}
+
+// Issue reads the issue with the project-scoped number (IID) number.
+func (c Client) Issue(ctx context.Context, number int) (forge.Issue, error) {
+	var issue struct {
+		IID         int    `json:"iid"`
+		URL         string `json:"web_url"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.projectPath(fmt.Sprintf("issues/%d", number)), nil, &issue); err != nil {
+		return forge.Issue{}, err
+	}
+	return forge.Issue{Number: issue.IID, URL: issue.URL, Title: issue.Title, Body: issue.Description}, nil
+}
+
+func (c Client) projectPath(path string) string {
+	return "/projects/" + url.PathEscape(c.Project) + "/" + path
+}
+
+func (c Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.URL, "/")+"/api/v4"+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.Token)
+	}
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("gitlab %s %s: %d %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(out)
+}
@@ -0,0 +1,229 @@
+// Package hook installs the prepare-commit-msg and pre-push integrations,
+// either as raw git hooks or through the hook managers JavaScript and
+// polyglot repositories already use.
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Manager names a way of wiring up git hooks.
+type Manager string
+
+const (
+	Git      Manager = "git"
+	Husky    Manager = "husky"
+	Lefthook Manager = "lefthook"
+)
+
+// Managers lists the supported managers in the order shown in usage text.
+var Managers = []Manager{Git, Husky, Lefthook}
+
+// Command is the hook body; $1 is the message file and $2 the commit source.
+const Command = `go-commitgen --hook "$1" --hook-source "$2" --commit=false`
+
+// PushCommand is the pre-push hook body; $1 is the remote name and the
+// refs being pushed arrive on stdin.
+const PushCommand = `go-commitgen push-check "$1"`
+
+// Hook is a git hook go-commitgen can be installed into.
+type Hook struct {
+	// Name is git's name of the hook.
+	Name string
+	// Command is the line running go-commitgen and Lefthook the same
+	// command with lefthook's {1}/{2} placeholders for the arguments.
+	Command  string
+	Lefthook string
+	// stdin is set for hooks that read what git writes to their stdin.
+	stdin bool
+	// marker identifies an existing installation so reinstalling is a
+	// no-op.
+	marker string
+}
+
+var (
+	// PrepareCommitMsg writes the generated message into the commit.
+	PrepareCommitMsg = Hook{
+		Name:     "prepare-commit-msg",
+		Command:  Command,
+		Lefthook: "go-commitgen --hook {1} --hook-source {2} --commit=false",
+		marker:   "go-commitgen --hook",
+	}
+	// PrePush reviews the outgoing commits and can stop the push.
+	PrePush = Hook{
+		Name:     "pre-push",
+		Command:  PushCommand,
+		Lefthook: "go-commitgen push-check {1}",
+		stdin:    true,
+		marker:   "go-commitgen push-check",
+	}
+)
+
+// lefthookSnippet is the lefthook.yml section running h.
+func (h Hook) lefthookSnippet() string {
+	snippet := h.Name + ":\n  commands:\n    commitgen:\n      run: " + h.Lefthook + "\n"
+	if h.stdin {
+		snippet += "      use_stdin: true\n"
+	}
+	return snippet
+}
+
+// Result reports what Install changed and what the user still has to do.
+type Result struct {
+	// Path is the file written, or already containing the hook.
+	Path string
+	// Unchanged is set when the hook was already installed.
+	Unchanged bool
+	// Next is a follow-up step for the manager, empty when none is needed.
+	Next string
+}
+
+// Installer writes hook configuration into a repository.
+type Installer struct {
+	// Root is the work tree root, where manager configs live.
+	Root string
+	// HooksDir is git's hooks directory (git rev-parse --git-path hooks),
+	// used by the raw git manager.
+	HooksDir string
+}
+
+// Install wires go-commitgen into prepare-commit-msg using m.
+func (i Installer) Install(m Manager) (Result, error) {
+	return i.InstallHook(PrepareCommitMsg, m)
+}
+
+// InstallHook wires go-commitgen into h using m.
+func (i Installer) InstallHook(h Hook, m Manager) (Result, error) {
+	switch m {
+	case Git:
+		dir := i.HooksDir
+		if dir == "" {
+			dir = filepath.Join(i.Root, ".git", "hooks")
+		}
+		return writeScript(h, filepath.Join(dir, h.Name), "#!/bin/sh\n"+h.Command+"\n", "")
+	case Husky:
+		// husky v9 runs plain shell scripts from .husky/ without a shebang
+		next := ""
+		if _, err := os.Stat(filepath.Join(i.Root, ".husky", "_")); errors.Is(err, os.ErrNotExist) {
+			next = "run `npx husky init` (or add \"prepare\": \"husky\" to package.json and run npm install) to activate .husky/"
+		}
+		return writeScript(h, filepath.Join(i.Root, ".husky", h.Name), h.Command+"\n", next)
+	case Lefthook:
+		return i.lefthook(h)
+	}
+	return Result{}, fmt.Errorf("unknown hook manager %q", m)
+}
+
+// writeScript creates an executable hook script at path. An existing script
+// is left alone: it either already runs go-commitgen or belongs to someone
+// else and must be merged by hand.
+func writeScript(h Hook, path, content, next string) (Result, error) {
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil && strings.Contains(string(existing), h.marker):
+		return Result{Path: path, Unchanged: true}, nil
+	case err == nil:
+		return Result{}, fmt.Errorf("%s already exists; add this line to it:\n%s", path, h.Command)
+	case !errors.Is(err, os.ErrNotExist):
+		return Result{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return Result{}, fmt.Errorf("create hook dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+		return Result{}, fmt.Errorf("write hook: %w", err)
+	}
+	return Result{Path: path, Next: next}, nil
+}
+
+// lefthook appends the section of h to the existing config (lefthook.yml
+// and its variants) or creates lefthook.yml. A config that already defines
+// the hook is not edited, since merging YAML blocks by hand is safer than
+// guessing indentation.
+func (i Installer) lefthook(h Hook) (Result, error) {
+	path := filepath.Join(i.Root, "lefthook.yml")
+	for _, name := range []string{"lefthook.yml", "lefthook.yaml", ".lefthook.yml", ".lefthook.yaml"} {
+		if _, err := os.Stat(filepath.Join(i.Root, name)); err == nil {
+			path = filepath.Join(i.Root, name)
+			break
+		}
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Result{}, err
+	}
+	content := string(existing)
+	if strings.Contains(content, h.marker) {
+		return Result{Path: path, Unchanged: true}, nil
+	}
+	if regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(h.Name) + `:`).MatchString(content) {
+		return Result{}, fmt.Errorf("%s already defines %s; add this command to it:\n%s", path, h.Name, h.lefthookSnippet())
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if content != "" {
+		content += "\n"
+	}
+	if err := os.WriteFile(path, []byte(content+h.lefthookSnippet()), 0o644); err != nil {
+		return Result{}, fmt.Errorf("write lefthook config: %w", err)
+	}
+	return Result{Path: path, Next: "run `lefthook install` to activate the hook"}, nil
+}
+
+// Status is where a hook runs go-commitgen.
+type Status struct {
+	Path    string
+	Manager Manager
+	// Inactive explains why git will not run the hook yet; empty when it
+	// will.
+	Inactive string
+}
+
+// Installed looks for h in git's hooks directory, .husky/ and the lefthook
+// config, in that order, and reports the first installation found.
+func (i Installer) Installed(h Hook) (Status, bool) {
+	dir := i.HooksDir
+	if dir == "" {
+		dir = filepath.Join(i.Root, ".git", "hooks")
+	}
+	script := filepath.Join(dir, h.Name)
+	if data, err := os.ReadFile(script); err == nil && strings.Contains(string(data), h.marker) {
+		status := Status{Path: script, Manager: Git}
+		if info, err := os.Stat(script); err == nil && info.Mode().Perm()&0o111 == 0 {
+			status.Inactive = "the script is not executable; run `chmod +x " + script + "`"
+		}
+		return status, true
+	}
+
+	husky := filepath.Join(i.Root, ".husky", h.Name)
+	if data, err := os.ReadFile(husky); err == nil && strings.Contains(string(data), h.marker) {
+		status := Status{Path: husky, Manager: Husky}
+		if _, err := os.Stat(filepath.Join(i.Root, ".husky", "_")); errors.Is(err, os.ErrNotExist) {
+			status.Inactive = "husky is not set up; run `npx husky init`"
+		}
+		return status, true
+	}
+
+	for _, name := range []string{"lefthook.yml", "lefthook.yaml", ".lefthook.yml", ".lefthook.yaml"} {
+		path := filepath.Join(i.Root, name)
+		if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), h.marker) {
+			status := Status{Path: path, Manager: Lefthook}
+			// lefthook install writes a script calling lefthook into git's
+			// hooks directory
+			if data, err := os.ReadFile(script); err != nil || !strings.Contains(string(data), "lefthook") {
+				status.Inactive = "lefthook is not installed into git; run `lefthook install`"
+			}
+			return status, true
+		}
+	}
+	return Status{}, false
+}
@@ -0,0 +1,64 @@
+// Package interrupt turns SIGINT/SIGTERM into context cancellation so a
+// generation can be aborted cleanly instead of killing the process.
+package interrupt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/riskibarqy/go-commitgen/internal/ollama"
+)
+
+// ExitCode is the conventional status for a process stopped by Ctrl-C.
+const ExitCode = 130
+
+// Context returns a copy of parent that is cancelled on the first SIGINT or
+// SIGTERM; a notice is written to w. A second signal exits immediately for
+// when cancellation itself hangs. Call stop to release the handler.
+func Context(parent context.Context, w io.Writer) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+			fmt.Fprintln(w, "\ninterrupted, cancelling (press Ctrl-C again to force quit)")
+			cancel()
+		case <-done:
+			return
+		}
+		select {
+		case <-signals:
+			os.Exit(ExitCode)
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
+
+// Report prints what was produced before an interruption, if err is one,
+// and reports whether it was.
+func Report(w io.Writer, err error) bool {
+	if !errors.Is(err, context.Canceled) {
+		return false
+	}
+	var partial *ollama.Interrupted
+	if errors.As(err, &partial) && partial.Partial != "" {
+		fmt.Fprintf(w, "generation interrupted; partial model output (nothing was committed):\n%s\n", partial.Partial)
+		return true
+	}
+	fmt.Fprintln(w, "generation interrupted; nothing was committed")
+	return true
+}
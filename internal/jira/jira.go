@@ -0,0 +1,79 @@
+// Package jira reads issues from Jira Cloud or Jira Server/Data Center
+// through the REST API, for commits made against a ticket.
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/forge"
+)
+
+// Client talks to one Jira site; the zero HTTP field uses
+// http.DefaultClient.
+type Client struct {
+	HTTP *http.Client
+	// URL is the site, e.g. https://example.atlassian.net.
+	URL string
+	// User and Token authenticate with basic auth, as Jira Cloud expects
+	// (e-mail address and API token); without User, Token is sent as the
+	// bearer personal access token of Jira Server/Data Center.
+	User  string
+	Token string
+}
+
+// Issue reads the issue with the given key, e.g. "PROJ-123".
+func (c Client) Issue(ctx context.Context, key string) (forge.Issue, error) {
+	var issue struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary     string `json:"summary"`
+			Description string `json:"description"`
+		} `json:"fields"`
+	}
+	// API version 2 returns the description as text, version 3 as a
+	// document tree
+	path := "/rest/api/2/issue/" + url.PathEscape(key) + "?fields=summary,description"
+	if err := c.get(ctx, path, &issue); err != nil {
+		return forge.Issue{}, err
+	}
+	return forge.Issue{
+		Key:   issue.Key,
+		URL:   strings.TrimRight(c.URL, "/") + "/browse/" + issue.Key,
+		Title: issue.Fields.Summary,
+		Body:  issue.Fields.Description,
+	}, nil
+}
+
+func (c Client) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(c.URL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case c.User != "":
+		req.SetBasicAuth(c.User, c.Token)
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("jira GET %s: %d %s", path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(out)
+}
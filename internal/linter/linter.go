@@ -0,0 +1,147 @@
+package linter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// maxLinesPerLinter caps how much of a linter's output reaches the prompt.
+const maxLinesPerLinter = 20
+
+// Linter describes a static analysis command run on staged files. Command is
+// a shell command where {files} expands to the matching files and {pkgs} to
+// their directories as "./dir" package patterns. Linters read the files
+// from the working tree, so unstaged edits to a staged file are linted too.
+type Linter struct {
+	Name       string
+	Extensions []string
+	Command    string
+}
+
+// Presets are the linters that can be enabled by name.
+var Presets = map[string]Linter{
+	"go-vet":        {Name: "go-vet", Extensions: []string{".go"}, Command: "go vet {pkgs}"},
+	"golangci-lint": {Name: "golangci-lint", Extensions: []string{".go"}, Command: "golangci-lint run {pkgs}"},
+	"eslint":        {Name: "eslint", Extensions: []string{".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs"}, Command: "eslint --format unix {files}"},
+}
+
+// Parse reads a custom linter definition "name:.ext1,.ext2:command".
+func Parse(spec string) (Linter, error) {
+	parts := strings.SplitN(spec, ":", 3)
+	if len(parts) != 3 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[2]) == "" {
+		return Linter{}, fmt.Errorf("expected name:.ext1,.ext2:command, got %q", spec)
+	}
+	l := Linter{Name: strings.TrimSpace(parts[0]), Command: strings.TrimSpace(parts[2])}
+	for _, ext := range strings.Split(parts[1], ",") {
+		if ext = strings.TrimSpace(ext); ext != "" {
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			l.Extensions = append(l.Extensions, ext)
+		}
+	}
+	return l, nil
+}
+
+// Finding is the output of one linter run.
+type Finding struct {
+	Linter string
+	Lines  []string
+}
+
+// Runner executes linters against a set of files.
+type Runner interface {
+	Run(ctx context.Context, linters []Linter, files []string) []Finding
+}
+
+// ShellRunner runs linters through the system shell (sh, or cmd on
+// Windows) in Dir.
+type ShellRunner struct {
+	Dir string
+}
+
+// Run executes every linter that has matching files. Linters exit non-zero
+// when they find something, so only their output matters; a linter that
+// cannot start is reported as a finding rather than failing the run.
+func (r ShellRunner) Run(ctx context.Context, linters []Linter, files []string) []Finding {
+	var findings []Finding
+	for _, l := range linters {
+		matched := l.match(r.existing(files))
+		if len(matched) == 0 {
+			continue
+		}
+
+		cmd := shellCommand(ctx, l.expand(matched))
+		cmd.Dir = r.Dir
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		err := cmd.Run()
+
+		lines := util.TrimLines(out.String())
+		// an ExitError means the linter ran and failed, which its output
+		// explains; any other error means it never started
+		if _, exited := err.(*exec.ExitError); err != nil && !exited && len(lines) == 0 {
+			lines = []string{"could not run: " + err.Error()}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if len(lines) > maxLinesPerLinter {
+			lines = append(lines[:maxLinesPerLinter], fmt.Sprintf("… %d more", len(lines)-maxLinesPerLinter))
+		}
+		findings = append(findings, Finding{Linter: l.Name, Lines: lines})
+	}
+	return findings
+}
+
+// existing drops deleted files, which linters cannot open.
+func (r ShellRunner) existing(files []string) []string {
+	out := make([]string, 0, len(files))
+	for _, f := range files {
+		if _, err := os.Stat(filepath.Join(r.Dir, f)); err == nil {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func (l Linter) match(files []string) []string {
+	var out []string
+	for _, f := range files {
+		ext := filepath.Ext(f)
+		for _, want := range l.Extensions {
+			if strings.EqualFold(ext, want) {
+				out = append(out, f)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (l Linter) expand(files []string) string {
+	quoted := make([]string, len(files))
+	dirs := map[string]bool{}
+	for i, f := range files {
+		quoted[i] = shellQuote(f)
+		dirs["./"+path.Dir(filepath.ToSlash(f))] = true
+	}
+	pkgs := make([]string, 0, len(dirs))
+	for d := range dirs {
+		pkgs = append(pkgs, shellQuote(strings.TrimSuffix(d, "/.")))
+	}
+	sort.Strings(pkgs)
+
+	command := strings.ReplaceAll(l.Command, "{files}", strings.Join(quoted, " "))
+	return strings.ReplaceAll(command, "{pkgs}", strings.Join(pkgs, " "))
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package linter
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// shellCommand runs command through sh.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// shellQuote quotes s as a single sh word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
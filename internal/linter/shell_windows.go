@@ -0,0 +1,23 @@
+package linter
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand runs command through cmd.exe. The command line is passed
+// verbatim, since cmd does not follow the backslash escaping Go applies to
+// ordinary arguments; /s makes cmd strip only the outer quotes.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "cmd")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `cmd /d /s /c "` + command + `"`}
+	return cmd
+}
+
+// shellQuote quotes s as a single cmd word. Windows file names cannot
+// contain double quotes, so wrapping is enough to keep spaces and the
+// characters cmd treats specially (&, |, <, >, ^) literal.
+func shellQuote(s string) string {
+	return `"` + s + `"`
+}
@@ -0,0 +1,78 @@
+// Package logging builds the leveled slog logger shared by the generator,
+// the model client and the --stdio server.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Config selects where and how much to log.
+type Config struct {
+	// Level is debug, info, warn or error.
+	Level string
+	// Format is text (logfmt-like) or json.
+	Format string
+	// File is appended to; empty logs to stderr.
+	File string
+}
+
+// Levels and Formats are the accepted Config values.
+var (
+	Levels  = []string{"debug", "info", "warn", "error"}
+	Formats = []string{"text", "json"}
+)
+
+// ParseLevel maps a level name to its slog.Level.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (want one of %s)", name, strings.Join(Levels, ", "))
+	}
+	return level, nil
+}
+
+// New builds the logger described by cfg. The returned closer releases the
+// log file and is a no-op for stderr.
+func New(cfg Config) (*slog.Logger, io.Closer, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+	var w io.Writer = os.Stderr
+	var closer io.Closer = nopCloser{}
+	if cfg.File != "" {
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open log file: %w", err)
+		}
+		w, closer = f, f
+	}
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	switch cfg.Format {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, handlerOpts)), closer, nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, handlerOpts)), closer, nil
+	}
+	closer.Close()
+	return nil, nil, fmt.Errorf("unknown log format %q (want one of %s)", cfg.Format, strings.Join(Formats, ", "))
+}
+
+// Or returns l, or a logger that drops everything when l is nil, so
+// components can leave their logger unset.
+func Or(l *slog.Logger) *slog.Logger {
+	if l != nil {
+		return l
+	}
+	return discard
+}
+
+var discard = slog.New(slog.DiscardHandler)
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
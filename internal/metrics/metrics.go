@@ -0,0 +1,206 @@
+// Package metrics counts model calls and server requests and exposes them
+// in the Prometheus text format, for monitoring a long-running server.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/ollama"
+)
+
+// durationBuckets are the upper bounds (seconds) of the latency histogram;
+// local models take from under a second to minutes.
+var durationBuckets = []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120, 300}
+
+type histogram struct {
+	counts []uint64 // per bucket, cumulative on output
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(durationBuckets))
+	}
+	for i, le := range durationBuckets {
+		if v <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// Registry holds the metrics of one process. The zero value is ready to use.
+type Registry struct {
+	mu           sync.Mutex
+	llmRequests  map[[2]string]uint64 // model, outcome
+	llmDuration  map[string]*histogram
+	tokens       map[[2]string]uint64 // model, kind
+	evalSeconds  map[string]float64
+	rpcRequests  map[[2]string]uint64 // method, outcome
+	rpcDurations map[string]*histogram
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// ObserveLLM records one model call.
+func (r *Registry) ObserveLLM(model string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.llmRequests == nil {
+		r.llmRequests, r.llmDuration = map[[2]string]uint64{}, map[string]*histogram{}
+	}
+	r.llmRequests[[2]string{model, outcome(err)}]++
+	if r.llmDuration[model] == nil {
+		r.llmDuration[model] = &histogram{}
+	}
+	r.llmDuration[model].observe(d.Seconds())
+}
+
+// ObserveUsage records the token counts the server reported for a call;
+// wire it to ollama.Client.Observe.
+func (r *Registry) ObserveUsage(u ollama.Usage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tokens == nil {
+		r.tokens, r.evalSeconds = map[[2]string]uint64{}, map[string]float64{}
+	}
+	r.tokens[[2]string{u.Model, "prompt"}] += uint64(u.PromptTokens)
+	r.tokens[[2]string{u.Model, "output"}] += uint64(u.OutputTokens)
+	r.evalSeconds[u.Model] += u.EvalDuration.Seconds()
+}
+
+// ObserveRequest records one request handled by the server.
+func (r *Registry) ObserveRequest(method string, d time.Duration, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rpcRequests == nil {
+		r.rpcRequests, r.rpcDurations = map[[2]string]uint64{}, map[string]*histogram{}
+	}
+	result := "ok"
+	if failed {
+		result = "error"
+	}
+	r.rpcRequests[[2]string{method, result}]++
+	if r.rpcDurations[method] == nil {
+		r.rpcDurations[method] = &histogram{}
+	}
+	r.rpcDurations[method].observe(d.Seconds())
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b strings.Builder
+	counterPairs(&b, "commitgen_llm_requests_total", "Model calls by model and outcome.", "model", "outcome", r.llmRequests)
+	histograms(&b, "commitgen_llm_request_duration_seconds", "Wall time of model calls.", "model", r.llmDuration)
+	counterPairs(&b, "commitgen_llm_tokens_total", "Tokens processed by model and kind (prompt, output).", "model", "kind", r.tokens)
+	b.WriteString("# HELP commitgen_llm_eval_seconds_total Server time spent generating output tokens.\n")
+	b.WriteString("# TYPE commitgen_llm_eval_seconds_total counter\n")
+	for _, model := range sortedKeys(r.evalSeconds) {
+		fmt.Fprintf(&b, "commitgen_llm_eval_seconds_total{model=%q} %g\n", model, r.evalSeconds[model])
+	}
+	counterPairs(&b, "commitgen_requests_total", "Server requests by method and outcome.", "method", "outcome", r.rpcRequests)
+	histograms(&b, "commitgen_request_duration_seconds", "Wall time of server requests.", "method", r.rpcDurations)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP serves the metrics, so the registry can be mounted at /metrics.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+// Serve exposes the registry at http://addr/metrics until ctx ends.
+func Serve(ctx context.Context, addr string, r *Registry) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", r)
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func counterPairs(b *strings.Builder, name, help, label1, label2 string, values map[[2]string]uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	keys := make([][2]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s{%s=%q,%s=%q} %d\n", name, label1, k[0], label2, k[1], values[k])
+	}
+}
+
+func histograms(b *strings.Builder, name, help, label string, values map[string]*histogram) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, key := range sortedKeys(values) {
+		h := values[key]
+		var cumulative uint64
+		for i, le := range durationBuckets {
+			if h.counts != nil {
+				cumulative += h.counts[i]
+			}
+			fmt.Fprintf(b, "%s_bucket{%s=%q,le=\"%g\"} %d\n", name, label, key, le, cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket{%s=%q,le=\"+Inf\"} %d\n", name, label, key, h.count)
+		fmt.Fprintf(b, "%s_sum{%s=%q} %g\n%s_count{%s=%q} %d\n", name, label, key, h.sum, name, label, key, h.count)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Generator is the model client being measured.
+type Generator interface {
+	Generate(ctx context.Context, endpoint string, req ollama.Request) (string, error)
+}
+
+// LLM measures every call of Next in Registry.
+type LLM struct {
+	Next     Generator
+	Registry *Registry
+}
+
+// Generate forwards the call and records its latency and outcome.
+func (l LLM) Generate(ctx context.Context, endpoint string, req ollama.Request) (string, error) {
+	started := time.Now()
+	out, err := l.Next.Generate(ctx, endpoint, req)
+	l.Registry.ObserveLLM(req.Model, time.Since(started), err)
+	return out, err
+}
@@ -0,0 +1,100 @@
+// Package notify shows a desktop notification through the platform's own
+// tools, so a slow generation can run while the user works elsewhere.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrUnavailable is returned when no notification tool is installed and
+// there is no terminal to ring instead.
+var ErrUnavailable = errors.New("no notification tool found (install libnotify's notify-send)")
+
+// The title and message reach the scripts through the environment, so
+// nothing in them needs quoting.
+const (
+	titleEnv   = "COMMITGEN_NOTIFY_TITLE"
+	messageEnv = "COMMITGEN_NOTIFY_MESSAGE"
+)
+
+// balloon shows a tray notification with the .NET runtime every Windows
+// install has; it waits for the balloon before releasing the icon.
+const balloon = `Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(5000, $env:` + titleEnv + `, $env:` + messageEnv + `, 'Info')
+Start-Sleep -Seconds 6
+$n.Dispose()`
+
+// tool is a command that shows a notification.
+type tool struct {
+	name string
+	args []string
+}
+
+var tools = map[string][]tool{
+	"darwin": {{name: "osascript", args: []string{"-e",
+		`display notification (system attribute "` + messageEnv + `") with title (system attribute "` + titleEnv + `")`}}},
+	"windows": {{name: "powershell.exe", args: []string{"-NoProfile", "-NonInteractive", "-Command", balloon}}},
+	"linux": {
+		{name: "notify-send", args: []string{"--app-name=go-commitgen"}},
+		{name: "termux-notification", args: []string{"--title"}},
+		// WSL: a Windows notification
+		{name: "powershell.exe", args: []string{"-NoProfile", "-NonInteractive", "-Command", balloon}},
+	},
+}
+
+// Send shows title and message as a desktop notification with the first
+// available tool of the platform. Without one it rings the terminal bell
+// on tty, which most terminals turn into an alert, when tty is not nil.
+func Send(ctx context.Context, title, message string, tty io.Writer) error {
+	platform := runtime.GOOS
+	if _, ok := tools[platform]; !ok {
+		platform = "linux" // the BSDs share libnotify
+	}
+	for _, t := range tools[platform] {
+		path, err := exec.LookPath(t.name)
+		if err != nil {
+			continue
+		}
+		args := append([]string(nil), t.args...)
+		switch t.name {
+		case "notify-send":
+			args = append(args, title, message)
+		case "termux-notification":
+			args = append(args, title, "--content", message)
+		}
+		env := append(os.Environ(), titleEnv+"="+title, messageEnv+"="+message)
+		if t.name == "powershell.exe" {
+			// the balloon stays up for seconds; do not hold up the caller
+			cmd := exec.Command(path, args...)
+			cmd.Env = env
+			if err := cmd.Start(); err != nil {
+				return fmt.Errorf("%s: %w", t.name, err)
+			}
+			go cmd.Wait()
+			return nil
+		}
+		cmd := exec.CommandContext(ctx, path, args...)
+		cmd.Env = env
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %w: %s", t.name, err, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+	if tty == nil {
+		return ErrUnavailable
+	}
+	_, err := io.WriteString(tty, "\a")
+	return err
+}
@@ -0,0 +1,40 @@
+package ollama
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Cleaner rewrites a complete model response before it is returned.
+type Cleaner func(string) string
+
+// DefaultCleaners strip reasoning-model artifacts that would otherwise leak
+// into parsed or fallback messages.
+var DefaultCleaners = []Cleaner{StripThinking, StripSpecialTokens}
+
+var (
+	thinkBlock    = regexp.MustCompile(`(?is)<(think|thinking|reasoning|reflection)>.*?</(think|thinking|reasoning|reflection)>`)
+	thinkOpen     = regexp.MustCompile(`(?i)<(think|thinking|reasoning|reflection)>`)
+	thinkClose    = regexp.MustCompile(`(?i)</(think|thinking|reasoning|reflection)>`)
+	specialTokens = regexp.MustCompile(`<\|[a-z_]+\|>`)
+)
+
+// StripThinking removes <think>…</think> style blocks. A closing tag
+// without an opening one (the template already opened it) drops everything
+// before it; an unterminated opening tag drops everything after it.
+func StripThinking(s string) string {
+	s = thinkBlock.ReplaceAllString(s, "")
+	if loc := thinkClose.FindStringIndex(s); loc != nil {
+		s = s[loc[1]:]
+	}
+	if loc := thinkOpen.FindStringIndex(s); loc != nil {
+		s = s[:loc[0]]
+	}
+	return strings.TrimSpace(s)
+}
+
+// StripSpecialTokens removes chat-template tokens such as <|im_end|> that
+// some models echo into their output.
+func StripSpecialTokens(s string) string {
+	return strings.TrimSpace(specialTokens.ReplaceAllString(s, ""))
+}
@@ -5,43 +5,180 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/logging"
+	"github.com/riskibarqy/go-commitgen/internal/trace"
 )
 
-// Request defines the payload sent to the Ollama API.
+// Request defines the payload sent to the Ollama API. System holds the
+// instructions; on /api/generate it is prepended to Prompt, on /api/chat it
+// becomes the system message and Prompt the user message. Format is either
+// "json" or a JSON schema the output is constrained to. KeepAlive tells the
+// server how long to keep the model loaded afterwards ("10m", "-1").
+// Context continues an earlier /api/generate conversation (see Session);
+// /api/chat ignores it. Defaults are sampling options chosen for the task
+// rather than by the user: they are sent under Options, which win, and
+// give way to the model's Modelfile with Client.ModelfileDefaults.
 type Request struct {
-	Model   string                 `json:"model"`
-	Prompt  string                 `json:"prompt"`
-	Stream  bool                   `json:"stream"`
-	Options map[string]interface{} `json:"options,omitempty"`
+	Model     string                 `json:"model"`
+	System    string                 `json:"-"`
+	Prompt    string                 `json:"prompt"`
+	Stream    bool                   `json:"stream"`
+	Format    interface{}            `json:"format,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	Defaults  map[string]interface{} `json:"-"`
+	KeepAlive string                 `json:"keep_alive,omitempty"`
+	Context   []int                  `json:"context,omitempty"`
+}
+
+// ChatMessage is a single role-tagged message of the /api/chat endpoint.
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model     string                 `json:"model"`
+	Messages  []ChatMessage          `json:"messages"`
+	Stream    bool                   `json:"stream"`
+	Format    interface{}            `json:"format,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	KeepAlive string                 `json:"keep_alive,omitempty"`
 }
 
-// Chunk mirrors the streamed response from Ollama.
+// Chunk mirrors the streamed response from Ollama; /api/generate fills
+// Response, /api/chat fills Message.
 type Chunk struct {
-	Response string `json:"response"`
-	Done     bool   `json:"done"`
+	Response string       `json:"response"`
+	Message  *ChatMessage `json:"message,omitempty"`
+	Done     bool         `json:"done"`
+	// Error is set when the model fails after the stream started.
+	Error string `json:"error,omitempty"`
+	// Context encodes the conversation so far; /api/generate sends it
+	// with the final chunk.
+	Context []int `json:"context,omitempty"`
+	// The final chunk carries the token counts and generation time (ns).
+	PromptEvalCount int   `json:"prompt_eval_count,omitempty"`
+	EvalCount       int   `json:"eval_count,omitempty"`
+	EvalDuration    int64 `json:"eval_duration,omitempty"`
+}
+
+// Usage is the token accounting of one finished generation.
+type Usage struct {
+	Model        string
+	PromptTokens int
+	OutputTokens int
+	// EvalDuration is the time the server spent generating OutputTokens.
+	EvalDuration time.Duration
+}
+
+// Interrupted is returned when the context is cancelled while the response
+// is streaming; Partial holds what the model had produced so far.
+type Interrupted struct {
+	Partial string
+	Err     error
+}
+
+func (e *Interrupted) Error() string {
+	return "generation interrupted: " + e.Err.Error()
+}
+
+func (e *Interrupted) Unwrap() error {
+	return e.Err
+}
+
+// Unreachable reports whether err means the endpoint could not be talked
+// to at all (refused or reset connection, unknown host, TLS failure,
+// timeout, or a gateway answering 502/503/504 for it) rather than that it
+// answered with an error of its own.
+func Unreachable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	msg := err.Error()
+	for _, status := range []string{"ollama error 502", "ollama error 503", "ollama error 504"} {
+		if strings.HasPrefix(msg, status) {
+			return true
+		}
+	}
+	return false
 }
 
 // Client wraps the HTTP calls to the Ollama API.
 type Client struct {
-	http *http.Client
+	http    *http.Client
+	headers http.Header
+	// Chat routes requests through /api/chat with system/user roles
+	// instead of the bare /api/generate prompt.
+	Chat bool
+	// Cleaners post-process every response, in order.
+	Cleaners []Cleaner
+	// Limiter queues requests to the endpoint; nil sends them right away.
+	Limiter *Limiter
+	// Progress is told when a request has to wait for the limiter or for
+	// a busy endpoint (429/503), so the user knows why nothing happens.
+	Progress func(msg string)
+	// Observe, when set, receives the usage of every finished generation.
+	Observe func(Usage)
+	// Log receives a debug record per request and warnings on retries;
+	// nil discards them.
+	Log *slog.Logger
+	// IdleTimeout aborts a streaming answer when no chunk arrives for this
+	// long, however long the whole answer takes; zero waits for ctx.
+	IdleTimeout time.Duration
+	// Timeout bounds each call that is answered in one piece (Show, Embed,
+	// Version, Installed, Loaded) as a whole; zero waits for ctx.
+	Timeout time.Duration
+	// ModelfileDefaults drops the request Defaults the model's Modelfile
+	// sets a parameter for, read once per model from /api/show, so a tuned
+	// Modelfile is only overridden by the user's own Options.
+	ModelfileDefaults bool
+
+	paramsMu sync.Mutex
+	params   map[string]map[string]bool // endpoint + model -> parameter names
+}
+
+// bounded derives the context of a call answered in one piece from ctx,
+// cut off after c.Timeout.
+func (c *Client) bounded(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.Timeout)
 }
 
+// MaxResponseBytes stops reading an answer that runs on, as a model with
+// an unlimited num_predict can; every answer this tool asks for is far
+// shorter.
+const MaxResponseBytes = 1 << 20
+
+// ErrIdleTimeout is returned when a streaming answer stalls for longer
+// than the client's IdleTimeout.
+var ErrIdleTimeout = errors.New("model stopped sending output")
+
 // NewClient builds a ready-to-use Ollama client.
 func NewClient(timeout time.Duration) *Client {
-	return &Client{
-		http: &http.Client{
-			Timeout: timeout,
-			Transport: &http.Transport{
-				DialContext: (&net.Dialer{Timeout: 5 * time.Second}).DialContext,
-			},
-		},
-	}
+	// without TLS files the config cannot fail
+	c, _ := NewClientWithConfig(Config{Timeout: timeout})
+	return c
 }
 
 // Generate sends a prompt to the model and returns the aggregated response.
@@ -50,45 +187,181 @@ func (c *Client) Generate(ctx context.Context, endpoint string, req Request) (st
 		req.Stream = true
 	}
 
-	payload, err := json.Marshal(req)
+	req.Options = c.options(ctx, endpoint, req)
+	path, payload, err := c.payload(req)
 	if err != nil {
 		return "", fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/api/generate", bytes.NewReader(payload))
-	if err != nil {
-		return "", fmt.Errorf("build http request: %w", err)
+	if c.Limiter != nil {
+		release, err := c.Limiter.Acquire(ctx, func() { c.progress("queued: waiting for a free slot on " + endpoint) })
+		if err != nil {
+			return "", err
+		}
+		defer release()
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.http.Do(httpReq)
+	log := logging.Or(c.Log).With("model", req.Model, "endpoint", endpoint+path)
+	log.Debug("model request", "prompt_bytes", len(req.Prompt)+len(req.System))
+	started := time.Now()
+
+	// reqCtx is cancelled with ErrIdleTimeout when the stream stalls
+	reqCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	resp, err := c.send(reqCtx, strings.TrimRight(endpoint, "/")+path, payload)
 	if err != nil {
+		log.Warn("model request failed", "err", err)
 		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
+		log.Warn("model request rejected", "status", resp.StatusCode)
 		return "", fmt.Errorf("ollama error %d: %s", resp.StatusCode, string(body))
 	}
 
-	var out strings.Builder
-	sc := bufio.NewScanner(resp.Body)
-	for sc.Scan() {
-		line := sc.Bytes()
+	var idle *time.Timer
+	if c.IdleTimeout > 0 {
+		idle = time.AfterFunc(c.IdleTimeout, func() { cancel(ErrIdleTimeout) })
+		defer idle.Stop()
+	}
+
+	// a bufio.Reader rather than a Scanner: a single chunk can exceed the
+	// Scanner's 64KB line limit, which would end the stream early
+	var (
+		out     strings.Builder
+		done    bool
+		readErr error
+	)
+	reader := bufio.NewReader(resp.Body)
+	for !done && readErr == nil {
+		var line []byte
+		line, readErr = reader.ReadBytes('\n')
+		if idle != nil {
+			idle.Reset(c.IdleTimeout)
+		}
+		// the last chunk may come without a newline
+		if line = bytes.TrimSpace(line); len(line) == 0 {
+			continue
+		}
 		var chunk Chunk
 		if err := json.Unmarshal(line, &chunk); err != nil {
+			log.Debug("skipping malformed chunk", "err", err, "chunk", preview(line))
 			continue
 		}
+		if chunk.Error != "" {
+			log.Warn("model failed mid-stream", "err", chunk.Error)
+			return "", fmt.Errorf("ollama error: %s", chunk.Error)
+		}
 		out.WriteString(chunk.Response)
-		if chunk.Done {
-			break
+		if chunk.Message != nil {
+			out.WriteString(chunk.Message.Content)
+		}
+		if out.Len() > MaxResponseBytes {
+			log.Warn("model answer too long", "bytes", out.Len(), "elapsed", time.Since(started))
+			return "", fmt.Errorf("model answer exceeded %d bytes without finishing; check the model's num_predict", MaxResponseBytes)
+		}
+		if done = chunk.Done; done {
+			log.Debug("model response", "elapsed", time.Since(started), "prompt_tokens", chunk.PromptEvalCount, "output_tokens", chunk.EvalCount)
+			if c.Observe != nil {
+				c.Observe(Usage{Model: req.Model, PromptTokens: chunk.PromptEvalCount, OutputTokens: chunk.EvalCount, EvalDuration: time.Duration(chunk.EvalDuration)})
+			}
+			if session, ok := ctx.Value(sessionKey{}).(*Session); ok && len(chunk.Context) > 0 {
+				session.record(req.Model, chunk.Context)
+			}
 		}
 	}
 
-	if err := sc.Err(); err != nil {
-		return "", err
+	// cancelling ctx closes the body under the reader, so check it first
+	// to report the interruption rather than a read error
+	if err := ctx.Err(); err != nil {
+		return "", &Interrupted{Partial: strings.TrimSpace(out.String()), Err: err}
+	}
+	if errors.Is(context.Cause(reqCtx), ErrIdleTimeout) {
+		log.Warn("model stream stalled", "idle_timeout", c.IdleTimeout, "elapsed", time.Since(started), "bytes", out.Len())
+		return "", fmt.Errorf("%w for %s after %d bytes", ErrIdleTimeout, c.IdleTimeout, out.Len())
+	}
+	if done && readErr == nil {
+		// the connection is reused once the stream is read to its end
+		_, _ = io.Copy(io.Discard, io.LimitReader(reader, maxDrain))
+	}
+	switch {
+	case errors.Is(readErr, io.EOF) && !done:
+		log.Debug("model stream ended without a done chunk", "bytes", out.Len())
+	case readErr != nil && !errors.Is(readErr, io.EOF):
+		return "", readErr
+	}
+
+	response := out.String()
+	for _, clean := range c.Cleaners {
+		response = clean(response)
+	}
+	return strings.TrimSpace(response), nil
+}
+
+// send posts payload, waiting and retrying while the endpoint answers that
+// it is busy (429/503), up to maxBusyRetries times or until ctx ends.
+func (c *Client) send(ctx context.Context, url string, payload []byte) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("build http request: %w", err)
+		}
+		for k, v := range c.headers {
+			httpReq.Header[k] = v
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		if parent := trace.TraceParent(ctx); parent != "" {
+			httpReq.Header.Set("traceparent", parent)
+		}
+
+		resp, err := c.http.Do(httpReq)
+		// the last busy answer is the caller's to report
+		if err != nil || !busy(resp.StatusCode) || attempt == maxBusyRetries {
+			return resp, err
+		}
+		wait := backoff(resp, attempt)
+		drainClose(resp.Body)
+		logging.Or(c.Log).Warn("endpoint busy", "url", url, "status", resp.StatusCode, "retry_in", wait)
+		c.progress(fmt.Sprintf("endpoint busy (%d), retrying in %s", resp.StatusCode, wait))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) progress(msg string) {
+	if c.Progress != nil {
+		c.Progress(msg)
+	}
+}
+
+// payload encodes req for the configured endpoint and returns its API path.
+func (c *Client) payload(req Request) (string, []byte, error) {
+	if !c.Chat {
+		if req.System != "" {
+			req.Prompt = req.System + "\n" + req.Prompt
+		}
+		payload, err := json.Marshal(req)
+		return "/api/generate", payload, err
 	}
 
-	return strings.TrimSpace(out.String()), nil
+	chat := chatRequest{Model: req.Model, Stream: req.Stream, Format: req.Format, Options: req.Options, KeepAlive: req.KeepAlive}
+	if req.System != "" {
+		chat.Messages = append(chat.Messages, ChatMessage{Role: "system", Content: req.System})
+	}
+	chat.Messages = append(chat.Messages, ChatMessage{Role: "user", Content: req.Prompt})
+	payload, err := json.Marshal(chat)
+	return "/api/chat", payload, err
+}
+
+// preview shortens a chunk for the log.
+func preview(b []byte) string {
+	if len(b) > 200 {
+		return string(b[:200]) + "…"
+	}
+	return string(b)
 }
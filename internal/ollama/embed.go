@@ -0,0 +1,48 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Embed returns the embedding vector of text from an embedding model
+// (e.g. nomic-embed-text) via /api/embeddings.
+func (c *Client) Embed(ctx context.Context, endpoint, model, text string) ([]float64, error) {
+	payload, err := json.Marshal(map[string]string{"model": model, "prompt": text})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	if c.Limiter != nil {
+		release, err := c.Limiter.Acquire(ctx, func() { c.progress("queued: waiting for a free slot on " + endpoint) })
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	ctx, cancel := c.bounded(ctx)
+	defer cancel()
+	resp, err := c.send(ctx, strings.TrimRight(endpoint, "/")+"/api/embeddings", payload)
+	if err != nil {
+		return nil, err
+	}
+	defer drainClose(resp.Body)
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("ollama error %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var out struct {
+		Embedding []float64 `json:"embedding"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode embedding: %w", err)
+	}
+	if len(out.Embedding) == 0 {
+		return nil, fmt.Errorf("model %s returned no embedding; is it an embedding model?", model)
+	}
+	return out.Embedding, nil
+}
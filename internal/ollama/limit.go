@@ -0,0 +1,121 @@
+package ollama
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limiter keeps one process from overwhelming a shared endpoint: requests
+// start at most Rate per second (token bucket) and at most Concurrent run
+// at once. Callers beyond either limit queue until ctx ends instead of
+// failing. The zero value of either field disables that limit.
+type Limiter struct {
+	Rate       float64
+	Concurrent int
+
+	once   sync.Once
+	slots  chan struct{}
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// Acquire blocks until a request may start and returns the function that
+// frees its slot. notify, when set, is told once that the request queues.
+func (l *Limiter) Acquire(ctx context.Context, notify func()) (func(), error) {
+	l.once.Do(func() {
+		if l.Concurrent > 0 {
+			l.slots = make(chan struct{}, l.Concurrent)
+		}
+		l.tokens = 1
+	})
+
+	queued := false
+	queue := func() {
+		if !queued && notify != nil {
+			notify()
+		}
+		queued = true
+	}
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			queue()
+			select {
+			case l.slots <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	for l.Rate > 0 {
+		wait := l.take()
+		if wait == 0 {
+			break
+		}
+		queue()
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// take spends a token when one is available, otherwise it returns how long
+// until the next one is.
+func (l *Limiter) take() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.Rate
+	}
+	if l.tokens > 1 {
+		l.tokens = 1
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.Rate * float64(time.Second))
+}
+
+const (
+	// maxBackoff caps the wait between retries of a busy endpoint.
+	maxBackoff = 30 * time.Second
+	// maxBusyRetries is how often a busy endpoint is retried before its
+	// answer is returned as it is.
+	maxBusyRetries = 10
+	// maxBackoffShift keeps time.Second<<attempt from overflowing; 1<<5
+	// seconds is already past maxBackoff.
+	maxBackoffShift = 5
+)
+
+// busy reports whether the endpoint asked the client to come back later.
+func busy(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// backoff is the server's Retry-After when it sent one, otherwise an
+// exponential delay for the given attempt.
+func backoff(resp *http.Response, attempt int) time.Duration {
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		return min(time.Duration(secs)*time.Second, maxBackoff)
+	}
+	return min(time.Second<<min(attempt, maxBackoffShift), maxBackoff)
+}
@@ -0,0 +1,83 @@
+package ollama
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/logging"
+)
+
+// ParameterNames returns the parameters the model's Modelfile sets, such
+// as "temperature" or "num_ctx", from the "name value" lines /api/show
+// reports.
+func (m ModelInfo) ParameterNames() map[string]bool {
+	names := map[string]bool{}
+	for _, line := range strings.Split(m.Parameters, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			names[fields[0]] = true
+		}
+	}
+	return names
+}
+
+// MergedOptions returns Defaults overlaid with Options: the options sent
+// for req unless the Modelfile takes over some of the defaults.
+func (r Request) MergedOptions() map[string]interface{} {
+	if len(r.Defaults) == 0 {
+		return r.Options
+	}
+	merged := make(map[string]interface{}, len(r.Defaults)+len(r.Options))
+	for k, v := range r.Defaults {
+		merged[k] = v
+	}
+	for k, v := range r.Options {
+		merged[k] = v
+	}
+	return merged
+}
+
+// options returns the options to send for req: with ModelfileDefaults
+// the Defaults the Modelfile sets a parameter for are left out.
+func (c *Client) options(ctx context.Context, endpoint string, req Request) map[string]interface{} {
+	if !c.ModelfileDefaults || len(req.Defaults) == 0 {
+		return req.MergedOptions()
+	}
+	set := c.modelfileParams(ctx, endpoint, req.Model)
+	trimmed := Request{Options: req.Options, Defaults: make(map[string]interface{}, len(req.Defaults))}
+	var kept []string
+	for k, v := range req.Defaults {
+		if set[k] {
+			kept = append(kept, k)
+			continue
+		}
+		trimmed.Defaults[k] = v
+	}
+	if len(kept) > 0 {
+		sort.Strings(kept)
+		logging.Or(c.Log).Debug("using the Modelfile's parameters", "model", req.Model, "parameters", strings.Join(kept, ","))
+	}
+	return trimmed.MergedOptions()
+}
+
+// modelfileParams returns the parameters model's Modelfile sets on
+// endpoint, asking once per model; a failed lookup sets none, so the
+// defaults apply.
+func (c *Client) modelfileParams(ctx context.Context, endpoint, model string) map[string]bool {
+	c.paramsMu.Lock()
+	defer c.paramsMu.Unlock()
+	id := endpoint + "\x00" + model
+	if set, ok := c.params[id]; ok {
+		return set
+	}
+	if c.params == nil {
+		c.params = map[string]map[string]bool{}
+	}
+	info, err := c.Show(ctx, endpoint, model)
+	if err != nil {
+		logging.Or(c.Log).Debug("model parameters unavailable, using the built-in defaults", "model", model, "err", err)
+	}
+	set := info.ParameterNames()
+	c.params[id] = set
+	return set
+}
@@ -0,0 +1,84 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// InstalledModel is a model pulled on the endpoint, from /api/tags.
+type InstalledModel struct {
+	Name string `json:"name"`
+	// Size is the size of the weights in bytes.
+	Size int64 `json:"size"`
+}
+
+// LoadedModel is a model the endpoint holds in memory, from /api/ps.
+type LoadedModel struct {
+	Name string `json:"name"`
+	// Size is the memory the loaded model takes, context included; SizeVRAM
+	// is the part of it on the GPU. A model with SizeVRAM < Size runs
+	// partly on the CPU.
+	Size     int64 `json:"size"`
+	SizeVRAM int64 `json:"size_vram"`
+}
+
+// Installed lists the models pulled on the endpoint via /api/tags.
+func (c *Client) Installed(ctx context.Context, endpoint string) ([]InstalledModel, error) {
+	var out struct {
+		Models []InstalledModel `json:"models"`
+	}
+	if err := c.get(ctx, endpoint, "/api/tags", &out); err != nil {
+		return nil, err
+	}
+	return out.Models, nil
+}
+
+// Loaded lists the models the endpoint holds in memory via /api/ps.
+func (c *Client) Loaded(ctx context.Context, endpoint string) ([]LoadedModel, error) {
+	var out struct {
+		Models []LoadedModel `json:"models"`
+	}
+	if err := c.get(ctx, endpoint, "/api/ps", &out); err != nil {
+		return nil, err
+	}
+	return out.Models, nil
+}
+
+// ModelName adds the implicit ":latest" tag, so that "llama3" and
+// "llama3:latest" compare equal.
+func ModelName(model string) string {
+	if model != "" && !strings.Contains(model[strings.LastIndex(model, "/")+1:], ":") {
+		return model + ":latest"
+	}
+	return model
+}
+
+// get decodes the JSON answer of a GET of path on the endpoint into out.
+func (c *Client) get(ctx context.Context, endpoint, path string, out interface{}) error {
+	ctx, cancel := c.bounded(ctx)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(endpoint, "/")+path, nil)
+	if err != nil {
+		return fmt.Errorf("build http request: %w", err)
+	}
+	for k, v := range c.headers {
+		httpReq.Header[k] = v
+	}
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer drainClose(resp.Body)
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("ollama error %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
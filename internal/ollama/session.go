@@ -0,0 +1,45 @@
+package ollama
+
+import (
+	"context"
+	"sync"
+)
+
+// Session keeps the context tokens /api/generate returns with its last
+// chunk. A later request to the same model can send them back as
+// Request.Context and continue from there instead of sending a long input,
+// such as a diff, again. /api/chat returns no tokens, so a chat client
+// never fills a session.
+type Session struct {
+	mu     sync.Mutex
+	model  string
+	tokens []int
+}
+
+type sessionKey struct{}
+
+// WithSession makes Generate record the context of answers to requests
+// made with the returned ctx into s.
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// Tokens returns the recorded context when it was recorded for model, and
+// nil otherwise; a nil session has none.
+func (s *Session) Tokens(model string) []int {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.model != model {
+		return nil
+	}
+	return s.tokens
+}
+
+func (s *Session) record(model string, tokens []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.model, s.tokens = model, tokens
+}
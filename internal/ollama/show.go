@@ -0,0 +1,53 @@
+package ollama
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ModelInfo is the part of /api/show the client uses.
+type ModelInfo struct {
+	Modelfile  string `json:"modelfile"`
+	Parameters string `json:"parameters"`
+	Template   string `json:"template"`
+	ModifiedAt string `json:"modified_at"`
+	// Digest identifies the exact build of the model: a hash of the whole
+	// /api/show answer, so it changes when the model is pulled again or
+	// recreated from a different Modelfile.
+	Digest string `json:"-"`
+}
+
+// Show describes model via /api/show.
+func (c *Client) Show(ctx context.Context, endpoint, model string) (ModelInfo, error) {
+	payload, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return ModelInfo{}, fmt.Errorf("marshal request: %w", err)
+	}
+	ctx, cancel := c.bounded(ctx)
+	defer cancel()
+	resp, err := c.send(ctx, strings.TrimRight(endpoint, "/")+"/api/show", payload)
+	if err != nil {
+		return ModelInfo{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return ModelInfo{}, fmt.Errorf("read model info: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return ModelInfo{}, fmt.Errorf("ollama error %d: %s", resp.StatusCode, strings.TrimSpace(string(body[:min(len(body), 512)])))
+	}
+
+	var info ModelInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return ModelInfo{}, fmt.Errorf("decode model info: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	info.Digest = hex.EncodeToString(sum[:])
+	return info, nil
+}
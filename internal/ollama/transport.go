@@ -0,0 +1,154 @@
+package ollama
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Config tunes the HTTP client used to reach the endpoint.
+type Config struct {
+	// Timeout bounds the wait for the endpoint to start answering, which
+	// includes loading the model and reading the prompt. It does not cut a
+	// streaming answer short; IdleTimeout bounds the gaps in it instead.
+	// Calls answered in one piece, such as Show and Embed, are bounded by
+	// Timeout as a whole.
+	Timeout     time.Duration
+	IdleTimeout time.Duration
+	// CAFile adds a PEM bundle to the system roots, for internal gateways.
+	CAFile string
+	// CertFile/KeyFile present a client certificate (mutual TLS).
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify disables certificate verification entirely.
+	InsecureSkipVerify bool
+	// APIKey is sent as a bearer token; Headers are attached as-is.
+	APIKey  string
+	Headers map[string]string
+	// RateLimit (requests per second) and MaxConcurrent limit the load
+	// this process puts on the endpoint; zero disables them.
+	RateLimit     float64
+	MaxConcurrent int
+	// MaxIdleConns is how many idle keep-alive connections to the
+	// endpoint are kept for the next request; zero means
+	// DefaultMaxIdleConns.
+	MaxIdleConns int
+	// Log is handed to the client; nil discards its records.
+	Log *slog.Logger
+}
+
+// DefaultMaxIdleConns keeps a connection per request an ensemble, a
+// critic and the embeddings have in flight at once.
+const DefaultMaxIdleConns = 8
+
+// Connections idle for longer are closed; servers and proxies drop them
+// after a minute or two anyway.
+const idleConnTimeout = 90 * time.Second
+
+// maxDrain bounds what is read of an answer's unread remainder so its
+// connection can be reused; a longer remainder costs a new connection.
+const maxDrain = 64 << 10
+
+// NewClientWithConfig builds a client honouring HTTP(S)_PROXY/NO_PROXY and
+// the TLS settings in cfg.
+func NewClientWithConfig(cfg Config) (*Client, error) {
+	tlsConfig, err := cfg.tls()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	for k, v := range cfg.Headers {
+		headers.Set(k, v)
+	}
+	if cfg.APIKey != "" {
+		headers.Set("Authorization", "Bearer "+cfg.APIKey)
+	}
+
+	var limiter *Limiter
+	if cfg.RateLimit > 0 || cfg.MaxConcurrent > 0 {
+		limiter = &Limiter{Rate: cfg.RateLimit, Concurrent: cfg.MaxConcurrent}
+	}
+
+	idleConns := cfg.MaxIdleConns
+	if idleConns <= 0 {
+		idleConns = DefaultMaxIdleConns
+	}
+
+	// one Transport per Client pools the connections of every call it
+	// makes, generation, review and embeddings alike, so remote endpoints
+	// pay the TCP and TLS handshake once per process
+	return &Client{
+		Cleaners:    DefaultCleaners,
+		Limiter:     limiter,
+		Log:         cfg.Log,
+		IdleTimeout: cfg.IdleTimeout,
+		Timeout:     cfg.Timeout,
+		headers:     headers,
+		http: &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				DialContext:           (&net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+				TLSClientConfig:       tlsConfig,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: cfg.Timeout,
+				// a custom dialer or TLS config turns HTTP/2 off unless
+				// it is asked for; it is negotiated over TLS only
+				ForceAttemptHTTP2:   true,
+				MaxIdleConns:        idleConns,
+				MaxIdleConnsPerHost: idleConns,
+				IdleConnTimeout:     idleConnTimeout,
+			},
+		},
+	}, nil
+}
+
+func (cfg Config) tls() (*tls.Config, error) {
+	if cfg.CAFile == "" && cfg.CertFile == "" && cfg.KeyFile == "" && !cfg.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	conf := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify} //nolint:gosec // opt-in for self-signed gateways
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		conf.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, fmt.Errorf("client certificate needs both a cert and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	return conf, nil
+}
+
+// drainClose reads what is left of body, up to maxDrain, before closing
+// it: an HTTP/1.1 connection whose answer was not read to the end is torn
+// down instead of going back to the pool.
+func drainClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxDrain))
+	body.Close()
+}
@@ -0,0 +1,16 @@
+package ollama
+
+import "context"
+
+// Version asks the endpoint for its Ollama version via /api/version. It is
+// the cheapest call that proves the endpoint is up and accepts the
+// configured credentials.
+func (c *Client) Version(ctx context.Context, endpoint string) (string, error) {
+	var out struct {
+		Version string `json:"version"`
+	}
+	if err := c.get(ctx, endpoint, "/api/version", &out); err != nil {
+		return "", err
+	}
+	return out.Version, nil
+}
@@ -0,0 +1,31 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/bench"
+)
+
+// BenchTable writes the rows of `go-commitgen bench` as an aligned table,
+// one line per model and run. Scores are shown from 0 to 1; "-" marks a
+// column the run could not measure.
+func BenchTable(w io.Writer, rows []bench.Row) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tPROMPT\tSAMPLES\tFAILED\tROUGE-L\tSIMILARITY\tTYPE ACC\tLATENCY\tDATE")
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\tv%s\t%d\t%d\t%.3f\t%s\t%s\t%s\t%s\n",
+			r.Model, r.Prompt, r.Samples, r.Failed, r.Rouge, ratio(r.Similarity), ratio(r.TypeAccuracy),
+			r.Latency.Round(100*time.Millisecond), r.Date.Local().Format("2006-01-02 15:04"))
+	}
+	return tw.Flush()
+}
+
+func ratio(v float64) string {
+	if v < 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f", v)
+}
@@ -0,0 +1,63 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/riskibarqy/go-commitgen/internal/ollama"
+	"github.com/riskibarqy/go-commitgen/internal/usecase"
+)
+
+// Exit statuses of `go-commitgen bot`, stable for wrappers such as
+// Renovate's postUpgradeTasks. Invalid flags or config exit with 2 before
+// the bot runs.
+const (
+	ExitOK          = 0
+	ExitFailed      = 1
+	ExitUsage       = 2
+	ExitNoChanges   = 3
+	ExitUnreachable = 4
+)
+
+// ExitCode maps the outcome of a bot run to its exit status.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case usecase.NoChanges(err):
+		return ExitNoChanges
+	case ollama.Unreachable(err):
+		return ExitUnreachable
+	}
+	return ExitFailed
+}
+
+// BotResult is the JSON object `go-commitgen bot` prints on stdout.
+type BotResult struct {
+	Headline   string   `json:"headline,omitempty"`
+	Body       string   `json:"body,omitempty"`
+	Committed  bool     `json:"committed"`
+	Offline    bool     `json:"offline,omitempty"`
+	Violations []string `json:"violations,omitempty"`
+	// Truncation is set when the model saw only part of the diff.
+	Truncation *usecase.Truncation `json:"truncation,omitempty"`
+	Error      string              `json:"error,omitempty"`
+	ExitCode   int                 `json:"exitCode"`
+}
+
+// Bot writes the outcome of a bot run as a single line of JSON and
+// returns the exit status to end with. committed says whether the run
+// was asked to commit; a failed run never committed.
+func Bot(w io.Writer, r usecase.Result, committed bool, err error) (int, error) {
+	out := BotResult{Committed: committed && err == nil, ExitCode: ExitCode(err)}
+	if err != nil {
+		out.Error = err.Error()
+	} else {
+		out.Headline, out.Body, out.Offline = r.Message.Headline, r.Message.Body, r.Offline
+		out.Truncation = r.Truncation
+		for _, v := range r.Violations {
+			out.Violations = append(out.Violations, v.String())
+		}
+	}
+	return out.ExitCode, json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,118 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	"github.com/riskibarqy/go-commitgen/internal/usecase"
+)
+
+// EmitFormats are the renderings --emit accepts.
+var EmitFormats = []string{"headline", "body", "conventional", "markdown"}
+
+// Emit writes the message once per format, in the order given. With more
+// than one format each rendering is preceded by a "--- format ---" line,
+// so they can be told apart and copied separately.
+func Emit(w io.Writer, r usecase.Result, formats []string) error {
+	var b strings.Builder
+	for i, format := range formats {
+		text, err := render(r, format)
+		if err != nil {
+			return err
+		}
+		if len(formats) > 1 {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "--- %s ---\n", format)
+		}
+		if text != "" {
+			b.WriteString(text + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func render(r usecase.Result, format string) (string, error) {
+	switch format {
+	case "headline":
+		return r.Message.Headline, nil
+	case "body":
+		return r.Message.Body, nil
+	case "conventional":
+		return Conventional(r).String(), nil
+	case "markdown":
+		return Markdown(r), nil
+	}
+	return "", fmt.Errorf("unknown --emit format %q; use %s", format, strings.Join(EmitFormats, ", "))
+}
+
+// Conventional renders the message as the Conventional Commits
+// specification writes it, "type(scope): description", instead of the
+// ticket-first headline. Messages not built from the model's parts, such
+// as merge messages, keep their headline.
+func Conventional(r usecase.Result) commit.Message {
+	p := r.Parts
+	if p.CommitType == "" || p.Description == "" {
+		return r.Message
+	}
+	typ := p.CommitType
+	if p.Scope != "" {
+		typ += "(" + p.Scope + ")"
+	}
+	// the description as polished and styled in the headline, after the
+	// ticket and the bracketed type
+	description := p.Description
+	for _, bracketed := range []string{"[" + typ + "] ", "[" + p.CommitType + "] "} {
+		if _, after, ok := strings.Cut(r.Message.Headline, bracketed); ok && after != "" {
+			description = after
+			break
+		}
+	}
+	return commit.Message{Headline: typ + ": " + description, Body: r.Message.Body}
+}
+
+// Markdown renders the message for chat: the headline in bold, section
+// headings in bold, multi-line paragraphs as bullet lists, and without
+// the git trailers.
+func Markdown(r usecase.Result) string {
+	blocks := []string{"**" + r.Message.Headline + "**"}
+	body := strings.TrimSpace(strings.ReplaceAll(r.Message.Body, "\r\n", "\n"))
+	paragraphs := strings.Split(body, "\n\n")
+	if commit.Trailers(body) != nil {
+		paragraphs = paragraphs[:len(paragraphs)-1]
+	}
+	for _, p := range paragraphs {
+		lines := strings.Split(strings.TrimSpace(p), "\n")
+		if len(lines) == 0 || lines[0] == "" {
+			continue
+		}
+		if heading, ok := sectionHeading(lines[0]); ok {
+			blocks = append(blocks, "**"+heading+":** "+strings.Join(lines[1:], " "))
+			continue
+		}
+		if len(lines) > 1 {
+			for i, line := range lines {
+				if !strings.HasPrefix(line, "- ") && !strings.HasPrefix(line, "* ") {
+					lines[i] = "- " + line
+				}
+			}
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// sectionHeading reports whether line is the heading of a body section
+// (Conventions.Sections), whose text is wrapped over the following lines.
+func sectionHeading(line string) (string, bool) {
+	for _, s := range commit.BodySections {
+		if line == s.Heading+":" {
+			return s.Heading, true
+		}
+	}
+	return "", false
+}
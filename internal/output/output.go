@@ -0,0 +1,200 @@
+// Package output renders generation results for people and for tools.
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/forge"
+	"github.com/riskibarqy/go-commitgen/internal/usecase"
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// PorcelainVersion is bumped only when the porcelain format changes in a
+// way existing parsers would misread; new record types may be added
+// without a bump, so parsers must ignore prefixes they do not know.
+const PorcelainVersion = 1
+
+// Human writes the review (if any) followed by the message, as shown in
+// the README.
+func Human(w io.Writer, r usecase.Result) error {
+	var b strings.Builder
+	switch {
+	case r.ReviewErr != nil:
+		fmt.Fprintf(&b, "Review failed: %v\n\n", r.ReviewErr)
+	case r.Review != "":
+		b.WriteString("Review findings:\n" + r.Review + "\n\n")
+	}
+	if len(r.StyleFixes) > 0 {
+		b.WriteString("Style fixes:\n")
+		for _, fix := range r.StyleFixes {
+			b.WriteString("- " + fix.String() + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if r.Score != nil {
+		fmt.Fprintf(&b, "Score: %s", r.Score)
+		if r.Regenerations > 0 {
+			fmt.Fprintf(&b, " after %d regeneration(s)", r.Regenerations)
+		}
+		b.WriteString("\n\n")
+	}
+	if len(r.Candidates) > 0 {
+		b.WriteString("Candidates:\n")
+		for _, c := range r.Candidates {
+			if c.Err != nil {
+				fmt.Fprintf(&b, "- %s: failed: %v\n", c.Model, c.Err)
+				continue
+			}
+			fmt.Fprintf(&b, "- %s (%s): %s\n", c.Model, c.Elapsed.Round(100*time.Millisecond), c.Message.Headline)
+		}
+		b.WriteString("\n")
+	}
+	if len(r.Issues) > 0 {
+		b.WriteString("Follow-up issues:\n")
+		for _, issue := range r.Issues {
+			fmt.Fprintf(&b, "- %s %s\n", issue.Ref(), issue.URL)
+		}
+		b.WriteString("\n")
+	}
+	if r.Offline {
+		b.WriteString("Offline: the model was unreachable, so this message was written from file stats; edit it before committing.\n\n")
+	}
+	if t := r.Truncation; t != nil {
+		fmt.Fprintf(&b, "Truncated: the model saw %d of the diff's %d bytes", t.Sent, t.Bytes)
+		if t.Partial != "" {
+			b.WriteString("; cut short: " + t.Partial)
+		}
+		if len(t.Dropped) > 0 {
+			b.WriteString("; left out: " + strings.Join(t.Dropped, ", "))
+		}
+		b.WriteString(". Raise --max-bytes or use --summarize-large to describe all of it.\n\n")
+	}
+	b.WriteString(r.Message.Headline + "\n")
+	if r.Message.Body != "" {
+		b.WriteString("\n" + r.Message.Body + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Headline writes the headline alone, for `go-commitgen msg` and command
+// substitution.
+func Headline(w io.Writer, r usecase.Result) error {
+	_, err := io.WriteString(w, r.Message.Headline+"\n")
+	return err
+}
+
+// Issue writes the issue a `from-issue` commit is made against, so the
+// author sees what the message will be built around.
+func Issue(w io.Writer, issue forge.Issue) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", issue.Ref(), issue.Title)
+	if issue.URL != "" {
+		b.WriteString(issue.URL + "\n")
+	}
+	if body := util.TruncateShorten(util.CondenseSpaces(issue.Body), 200); body != "" {
+		b.WriteString(body + "\n")
+	}
+	_, err := io.WriteString(w, b.String()+"\n")
+	return err
+}
+
+// Notification returns the title and text of the desktop notification
+// for a finished generation: the headline, or why it failed.
+func Notification(r usecase.Result, err error) (title, message string) {
+	if err != nil {
+		return "go-commitgen: generation failed", err.Error()
+	}
+	title = "go-commitgen: message ready"
+	if r.Elapsed > 0 {
+		title += fmt.Sprintf(" (%s)", r.Elapsed.Round(time.Second))
+	}
+	return title, r.Message.Headline
+}
+
+// Porcelain writes r in the stable line-oriented format for editor
+// integrations: every line is "PREFIX: value", multi-line values repeat
+// their prefix once per line (a blank line is a bare "PREFIX:"), and the
+// record ends with "END".
+//
+//	VERSION: 1
+//	HEADLINE: TES-123 [feat] add login audit hook
+//	BODY: add audit publisher for login flow
+//	REVIEW: - missing null check on new helper
+//	REVIEW-ERROR: context deadline exceeded
+//	VIOLATION: description: description is 80 characters, limit is 72
+//	FIXED: imperative: "added" rewritten as "add"
+//	OWNER: @team-auth
+//	OFFLINE: model unreachable, message written from file stats
+//	TRUNCATED: 16384/52113 bytes
+//	PARTIAL: internal/api/handler.go
+//	DROPPED: internal/api/handler_test.go
+//	CANDIDATE: llama3.1: TES-123 [feat] add login audit hook
+//	SCORE: 8/10 (accuracy 9, specificity 8, conventions 10)
+//	ISSUE: https://github.com/acme/api/issues/12
+//	END
+func Porcelain(w io.Writer, r usecase.Result) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "VERSION: %d\n", PorcelainVersion)
+	field(&b, "HEADLINE", r.Message.Headline)
+	field(&b, "BODY", r.Message.Body)
+	field(&b, "REVIEW", r.Review)
+	if r.ReviewErr != nil {
+		field(&b, "REVIEW-ERROR", r.ReviewErr.Error())
+	}
+	for _, v := range r.Violations {
+		field(&b, "VIOLATION", v.String())
+	}
+	for _, fix := range r.StyleFixes {
+		field(&b, "FIXED", fix.String())
+	}
+	for _, owner := range r.Owners {
+		field(&b, "OWNER", owner)
+	}
+	if r.Offline {
+		field(&b, "OFFLINE", "model unreachable, message written from file stats")
+	}
+	if t := r.Truncation; t != nil {
+		field(&b, "TRUNCATED", fmt.Sprintf("%d/%d bytes", t.Sent, t.Bytes))
+		field(&b, "PARTIAL", t.Partial)
+		for _, path := range t.Dropped {
+			field(&b, "DROPPED", path)
+		}
+	}
+	if r.Score != nil {
+		field(&b, "SCORE", r.Score.String())
+	}
+	for _, issue := range r.Issues {
+		field(&b, "ISSUE", issue.URL)
+	}
+	for _, c := range r.Candidates {
+		if c.Err != nil {
+			field(&b, "CANDIDATE-ERROR", c.Model+": "+c.Err.Error())
+			continue
+		}
+		field(&b, "CANDIDATE", c.Model+": "+c.Message.Headline)
+	}
+	b.WriteString("END\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// field writes one prefixed line per line of value, keeping blank lines
+// inside it as a bare "PREFIX:" so paragraphs survive the round trip.
+func field(b *strings.Builder, prefix, value string) {
+	value = strings.Trim(strings.ReplaceAll(value, "\r\n", "\n"), "\n")
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			b.WriteString(prefix + ":\n")
+			continue
+		}
+		b.WriteString(prefix + ": " + line + "\n")
+	}
+}
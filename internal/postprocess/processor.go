@@ -0,0 +1,74 @@
+package postprocess
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+)
+
+// Processor rewrites a generated message before it is shown or committed.
+type Processor interface {
+	Process(ctx context.Context, msg commit.Message) (commit.Message, error)
+}
+
+// Exec runs a user command through the shell. The message is written to its
+// stdin as JSON ({"headline": "...", "body": "..."}) and the rewritten message
+// is read back from stdout in the same shape. Empty output keeps the message.
+type Exec struct {
+	Command string
+}
+
+// Process implements Processor.
+func (e Exec) Process(ctx context.Context, msg commit.Message) (commit.Message, error) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return msg, fmt.Errorf("marshal message: %w", err)
+	}
+
+	name, args := "sh", []string{"-c", e.Command}
+	if runtime.GOOS == "windows" {
+		name, args = "cmd", []string{"/C", e.Command}
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	var out, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return msg, fmt.Errorf("post-processor %q failed: %v\n%s", e.Command, err, stderr.String())
+	}
+
+	if strings.TrimSpace(out.String()) == "" {
+		return msg, nil
+	}
+
+	var rewritten commit.Message
+	if err := json.Unmarshal(out.Bytes(), &rewritten); err != nil {
+		return msg, fmt.Errorf("post-processor %q returned invalid JSON: %w", e.Command, err)
+	}
+	if strings.TrimSpace(rewritten.Headline) == "" {
+		return msg, fmt.Errorf("post-processor %q returned an empty headline", e.Command)
+	}
+	return rewritten, nil
+}
+
+// Chain applies processors in order, feeding each the previous output.
+type Chain []Processor
+
+// Process implements Processor.
+func (c Chain) Process(ctx context.Context, msg commit.Message) (commit.Message, error) {
+	for _, p := range c {
+		var err error
+		if msg, err = p.Process(ctx, msg); err != nil {
+			return msg, err
+		}
+	}
+	return msg, nil
+}
@@ -1,25 +1,284 @@
 package prompt
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
+
+// CommitInput gathers everything the commit prompt can draw on.
+type CommitInput struct {
+	Diff   string
+	Branch string
+	// Types is the commit_type enum offered to the model.
+	Types []string
+	// RecentCommits holds subjects of the latest commits touching the staged files.
+	RecentCommits []string
+	// Touched lists "path: func A, type B" declarations changed per file.
+	Touched []string
+	// Blame lists "path: N lines from "subject" (hash)" for the commits
+	// that last changed the lines the diff replaces.
+	Blame []string
+	// Moves describes code blocks that were moved and removed from Diff.
+	Moves []string
+	// Noise counts the import, formatting and comment hunks removed from
+	// Diff, per file.
+	Noise []string
+	// Summaries replaces Diff when the change was too large to send whole.
+	Summaries []string
+	// DiffInContext leaves Diff out of the prompt because the model has
+	// already read it, in the review it is continuing from.
+	DiffInContext bool
+	// Intent is the author's own explanation of why the change was made.
+	Intent string
+	// Sections asks for the body as separate "what", "why" and
+	// "how_to_test" fields instead of a single "body".
+	Sections bool
+	// Examples are accepted messages of similar past changes, as few-shot
+	// style guidance.
+	Examples []Example
+	// RepoContext holds "dir: description" notes on the modules involved.
+	RepoContext []string
+	// Scopes is the allowed scope list; empty leaves "scope" out.
+	Scopes []string
+	// Submodules describes submodule pointer bumps and the upstream
+	// commits they pull in.
+	Submodules []string
+	// Operation notes the rebase, cherry-pick or revert the change is
+	// being committed in, and its conflicts.
+	Operation string
+	// Tone adjusts the body length and voice; the zero Tone keeps them.
+	Tone Tone
+	// Limits are the field lengths the answer must keep to.
+	Limits Limits
+}
+
+// Limits are the lengths in characters of the answer's fields; zero
+// fields keep the defaults of 72, 100 and 300. NoBody asks for an empty
+// body instead.
+type Limits struct {
+	Description int
+	Summary     int
+	Body        int
+	NoBody      bool
+}
+
+func (l Limits) withDefaults() Limits {
+	if l.Description <= 0 {
+		l.Description = 72
+	}
+	if l.Summary <= 0 {
+		l.Summary = 100
+	}
+	if l.Body <= 0 {
+		l.Body = 300
+	}
+	return l
+}
+
+// Example pairs a summary of a past change with the message the author
+// accepted for it.
+type Example struct {
+	Change  string
+	Message string
+}
 
 // Commit builds the prompt sent to the model for commit generation.
-func Commit(diff, branch string) string {
-	return fmt.Sprintf(`You help craft git commit messages.
+func Commit(in CommitInput) Prompt {
+	limits := in.Limits.withDefaults()
+	body := fmt.Sprintf(`- "body": 1-3 sentences that highlight key details or rationale (<= %d characters). Use newline separators if listing items.`, limits.Body)
+	example := `{"commit_type":"fix","description":"handle nil pointer in parser","summary":"avoid panic when schema metadata missing","body":"Add nil check before parser access to prevent runtime crash."}`
+	switch {
+	case limits.NoBody:
+		body = `- "body": always "", the message is the headline alone.`
+		example = `{"commit_type":"fix","description":"handle nil pointer in parser","summary":"avoid panic when schema metadata missing","body":""}`
+	case in.Sections:
+		body = `- "what": what the change does, 1-2 sentences (<= 300 characters).
+- "why": the reason for the change, 1-2 sentences (<= 300 characters).
+- "how_to_test": how a reviewer can verify it, 1-2 sentences (<= 300 characters).`
+		example = `{"commit_type":"fix","description":"handle nil pointer in parser","summary":"avoid panic when schema metadata missing","what":"Add a nil check before the parser reads schema metadata.","why":"Schemas without metadata crashed the import.","how_to_test":"Import a schema without a metadata block; it loads instead of panicking."}`
+	case in.Tone.Body != "":
+		body = in.Tone.Body
+		if limits.Body != in.Tone.MaxBody {
+			body += fmt.Sprintf("\n- Keep \"body\" within %d characters.", limits.Body)
+		}
+	}
+	if in.Tone.Voice != "" {
+		body += "\n- Tone: " + in.Tone.Voice
+	}
+	scope := ""
+	if len(in.Scopes) > 0 {
+		scope = fmt.Sprintf("\n- \"scope\": the area the change belongs to, one of [%s], or \"\" when it spans several.", typeEnum(in.Scopes))
+	}
+	system := fmt.Sprintf(`You help craft git commit messages.
 Analyse the staged diff and respond with a single JSON object describing the commit.
 
 Requirements:
-- "commit_type": choose the best fit from ["feat","fix","perf","refactor","docs","test","build","chore","ci"].
-- "description": short imperative summary of what changed (<= 72 characters, no trailing punctuation, lower case start).
-- "summary": brief reason or impact of the change (<= 100 characters).
-- "body": 1-3 sentences that highlight key details or rationale (<= 300 characters). Use newline separators if listing items.
+- "commit_type": choose the best fit from [%s].%s
+- "description": short imperative summary of what changed (<= %d characters, no trailing punctuation, lower case start).
+- "summary": brief reason or impact of the change (<= %d characters).
+%s
 - Output only valid JSON. No prose, markdown, or backticks.
 
 Example:
-{"commit_type":"fix","description":"handle nil pointer in parser","summary":"avoid panic when schema metadata missing","body":"Add nil check before parser access to prevent runtime crash."}
-
-Context:
-- Branch: %s
-- Diff:
 %s
-`, branch, diff)
+`, typeEnum(in.Types), scope, limits.Description, limits.Summary, body, example)
+
+	return Prompt{System: system, User: "Context:\n" + commitContext(in)}
+}
+
+// CommitRetry re-prompts the model with the violations found in its previous answer.
+func CommitRetry(in CommitInput, previous string, violations []string) Prompt {
+	p := Commit(in)
+	var b strings.Builder
+	b.WriteString(p.User)
+	b.WriteString("\nYour previous answer was:\n")
+	b.WriteString(strings.TrimSpace(previous))
+	b.WriteString("\n\nIt broke these rules:\n")
+	for _, v := range violations {
+		b.WriteString("- " + v + "\n")
+	}
+	b.WriteString("\nRespond again with a single corrected JSON object only.\n")
+	p.User = b.String()
+	return p
+}
+
+// RepairJSON asks the model to fix the syntax of an answer that could not
+// be decoded, reported as problem, without changing what it says. The
+// diff is not sent again: only the object is needed.
+func RepairJSON(previous, problem string) Prompt {
+	return Prompt{
+		System: `You fix broken JSON.
+The object below was meant to be valid JSON but could not be parsed.
+Correct its syntax only: quotes, escapes, commas and brackets.
+Keep every key and every value word for word.
+Return only the corrected JSON object, without code fences or comments.
+`,
+		User: fmt.Sprintf("Parse error: %s\n\n%s\n", problem, strings.TrimSpace(previous)),
+	}
+}
+
+// CommitFeedback asks the model to revise a message the user rejected,
+// following their feedback.
+func CommitFeedback(in CommitInput, previous, feedback string) Prompt {
+	p := Commit(in)
+	var b strings.Builder
+	b.WriteString(p.User)
+	b.WriteString("\nA previous suggestion was:\n")
+	b.WriteString(strings.TrimSpace(previous))
+	b.WriteString("\n\nThe user asked for these changes:\n")
+	b.WriteString(strings.TrimSpace(feedback))
+	b.WriteString("\n\nRespond with a single revised JSON object only.\n")
+	p.User = b.String()
+	return p
+}
+
+// CommitJudge asks the model to pick the best of several answers to the
+// commit prompt, or to merge their strengths into one.
+func CommitJudge(in CommitInput, candidates []string) Prompt {
+	p := Commit(in)
+	var b strings.Builder
+	b.WriteString(p.User)
+	b.WriteString("\nSeveral models answered with these commit messages:\n")
+	for i, c := range candidates {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, strings.TrimSpace(c))
+	}
+	b.WriteString("\nJudge them against the diff: which is the most accurate and specific, and which follows the requirements best? " +
+		"Return the best one, or combine the strengths of several into one, as a single JSON object only.\n")
+	p.User = b.String()
+	return p
+}
+
+func typeEnum(types []string) string {
+	if len(types) == 0 {
+		types = []string{"feat", "fix", "perf", "refactor", "docs", "test", "build", "chore", "ci"}
+	}
+	quoted := make([]string, len(types))
+	for i, t := range types {
+		quoted[i] = fmt.Sprintf("%q", t)
+	}
+	return strings.Join(quoted, ",")
+}
+
+func commitContext(in CommitInput) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "- Branch: %s\n", in.Branch)
+	if in.Operation != "" {
+		fmt.Fprintf(&b, "- In progress: %s\n", in.Operation)
+	}
+	if in.Intent != "" {
+		fmt.Fprintf(&b, "- Author intent (the why behind the change; build the message around it): %s\n", in.Intent)
+	}
+	if len(in.RecentCommits) > 0 {
+		b.WriteString("- Recent commits touching these files (reuse their vocabulary, do not repeat them):\n")
+		for _, msg := range in.RecentCommits {
+			fmt.Fprintf(&b, "  - %s\n", msg)
+		}
+	}
+	if len(in.Examples) > 0 {
+		b.WriteString("- Messages the author accepted for similar past changes (follow their style and level of detail, not their content):\n")
+		for _, ex := range in.Examples {
+			fmt.Fprintf(&b, "  - Change:\n%s\n    Message:\n%s\n", indent(ex.Change, "      "), indent(ex.Message, "      "))
+		}
+	}
+	if len(in.RepoContext) > 0 {
+		b.WriteString("- What the modules involved do (background only; describe the diff, not these):\n")
+		for _, note := range in.RepoContext {
+			fmt.Fprintf(&b, "  - %s\n", note)
+		}
+	}
+	if len(in.Touched) > 0 {
+		b.WriteString("- Functions/types touched:\n")
+		for _, t := range in.Touched {
+			fmt.Fprintf(&b, "  - %s\n", t)
+		}
+	}
+	if len(in.Blame) > 0 {
+		b.WriteString("- Commits that wrote the lines being replaced (describe the change relative to their intent where it helps, e.g. \"retry only idempotent requests\" for a change to retry logic):\n")
+		for _, origin := range in.Blame {
+			fmt.Fprintf(&b, "  - %s\n", origin)
+		}
+	}
+	if len(in.Submodules) > 0 {
+		b.WriteString("- Submodule updates (describe them by what they bring in, e.g. \"bump libfoo submodule to abc1234: faster parser\"):\n")
+		for _, note := range in.Submodules {
+			fmt.Fprintf(&b, "  - %s\n", note)
+		}
+	}
+	if len(in.Moves) > 0 {
+		b.WriteString("- Code moves (removed from the diff below, describe them as moves):\n")
+		for _, m := range in.Moves {
+			fmt.Fprintf(&b, "  - %s\n", m)
+		}
+	}
+	if len(in.Noise) > 0 {
+		b.WriteString("- Mechanical hunks left out of the diff below (mention them in passing at most):\n")
+		for _, n := range in.Noise {
+			fmt.Fprintf(&b, "  - %s\n", n)
+		}
+	}
+	if len(in.Summaries) > 0 {
+		b.WriteString("- The diff is too large to include; per-file summaries of every change:\n")
+		for _, line := range in.Summaries {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+		return b.String()
+	}
+	if strings.TrimSpace(in.Diff) == "" {
+		b.WriteString("- No file changes: this is an intentionally empty commit (release marker, CI trigger, ...); describe it from the author intent.\n")
+		return b.String()
+	}
+	if in.DiffInContext {
+		b.WriteString("- Diff: the one you reviewed above; describe that change, not the review.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "- Diff:\n%s\n", in.Diff)
+	return b.String()
+}
+
+func indent(text, prefix string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
 }
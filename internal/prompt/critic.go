@@ -0,0 +1,24 @@
+package prompt
+
+import "fmt"
+
+// Critique builds the prompt of the critic pass, which scores a finished
+// commit message against the change it describes. The answer is the
+// JSON object {"accuracy": n, "specificity": n, "conventions": n,
+// "notes": "..."} with scores from 1 to 10. The description is held to
+// in.Limits like the commit prompt's.
+func Critique(in CommitInput, message string) Prompt {
+	system := fmt.Sprintf(`You review git commit messages written for a change.
+Score the commit message below against the change, each from 1 (poor) to 10 (excellent):
+- "accuracy": does it describe what the diff actually does, without claims the diff does not support?
+- "specificity": does it name the concrete behaviour, component or bug instead of vague wording like "update code" or "fix issues"?
+- "conventions": is the type one of [%s] and the right one, is the description a lower case imperative summary of at most %d characters without a trailing period, and does the body explain rather than repeat the headline?
+- "notes": when any score is below 8, one or two sentences on what to change; otherwise "".
+Output only the JSON object. No prose, markdown, or backticks.
+
+Example:
+{"accuracy":6,"specificity":4,"conventions":9,"notes":"The diff adds retry logic to the uploader; say so instead of \"improve upload\"."}
+`, typeEnum(in.Types), in.Limits.withDefaults().Description)
+
+	return Prompt{System: system, User: "Context:\n" + commitContext(in) + "\nCommit message:\n" + indent(message, "  ") + "\n"}
+}
@@ -0,0 +1,33 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Explain builds the prompt that explains past changes to someone reading
+// unfamiliar history. subject says what is explained ("commit 1a2b3c4",
+// "the history of api/auth.go"); commits are the messages, newest first,
+// and changes the diff or per-file summaries, trimmed to fit.
+func Explain(subject string, commits []string, changes string) Prompt {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Explain %s.\n\nCommit messages (newest first):\n%s\n", subject, strings.Join(commits, "\n"))
+	if strings.TrimSpace(changes) != "" {
+		fmt.Fprintf(&b, "\nChanges:\n%s\n", changes)
+	}
+
+	return Prompt{
+		System: `You explain past code changes to a developer who is new to the codebase.
+From the commit messages and the diff below, explain in plain language what the change did and why it was made.
+
+Return plain text with these three paragraphs, each starting with its label:
+What: the behaviour before and after, in terms of what the code does, not which lines moved.
+Why: the reason, taken from the commit messages where they give one; when you infer it from the code instead, say "probably" so the reader can tell.
+Watch out: anything a reader of this code today should know, such as edge cases, follow-up work the messages mention or behaviour that looks accidental. Write "Nothing stands out." when there is nothing.
+- Name functions, types and files when it helps the reader find them.
+- Do not repeat the commit messages verbatim and do not invent ticket IDs.
+- No headings, markdown emphasis or closing remarks.
+`,
+		User: b.String(),
+	}
+}
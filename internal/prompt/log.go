@@ -0,0 +1,48 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LogSummary builds the prompt that condenses a range of commits for people
+// who were not there: standups, release emails, backport notes. style is
+// "bullets" or "paragraph".
+func LogSummary(commits []string, style string) Prompt {
+	format := `- Write one narrative paragraph (<= 600 characters) describing what the commits achieved overall.`
+	if style == "bullets" {
+		format = `- Write up to 10 lines starting with "- ", grouping related commits into a single line.`
+	}
+
+	return Prompt{
+		System: fmt.Sprintf(`You summarise git history for teammates.
+Describe what the following commits changed, focusing on outcomes rather than individual commits.
+
+Return plain text following this format:
+%s
+- Mention ticket IDs when the commits reference them.
+- No headings, markdown emphasis, or backticks.
+`, format),
+		User: "Commits (newest first):\n" + strings.Join(commits, "\n") + "\n",
+	}
+}
+
+// Standup builds the prompt that turns one person's recent commits, across
+// repositories, into what they say at a standup. since is how far back
+// the commits go ("yesterday"); commits are "[repo] subject: body" lines,
+// newest first.
+func Standup(commits []string, since string) Prompt {
+	return Prompt{
+		System: `You write a developer's standup update from their own commits.
+Say what they got done, as they would say it to their team: outcomes, not commit by commit.
+
+Return plain text following this format:
+- Up to 6 lines starting with "- ", most significant work first, grouping related commits into a single line.
+- Name the repository in brackets when the commits span several, e.g. "- [api] ...".
+- Mention ticket IDs when the commits reference them.
+- First person is implied: start with a verb ("Fixed", "Added"), no "I".
+- No headings, greetings, plans for today, markdown emphasis, or backticks.
+`,
+		User: fmt.Sprintf("Commits since %s (newest first):\n%s\n", since, strings.Join(commits, "\n")),
+	}
+}
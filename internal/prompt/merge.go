@@ -0,0 +1,33 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Merge builds the prompt used to write the body of a merge commit.
+func Merge(message string, incoming []string, diff string) Prompt {
+	commits := "(not available)"
+	if len(incoming) > 0 {
+		commits = "- " + strings.Join(incoming, "\n- ")
+	}
+
+	return Prompt{
+		System: `You help craft git merge commit messages.
+Summarise what the incoming branch brings in so a reader of the history understands the merge without opening it.
+
+Return plain text following this format:
+- One short paragraph (<= 300 characters) describing the overall purpose of the incoming changes.
+- Then up to 6 lines starting with "- ", each naming a notable change.
+- No headline, markdown headings, or backticks.
+`,
+		User: fmt.Sprintf(`Merge: %s
+
+Incoming commits:
+%s
+
+Diff:
+%s
+`, message, commits, diff),
+	}
+}
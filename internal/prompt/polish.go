@@ -0,0 +1,22 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Polish builds the proofreading prompt run over a finished message. The
+// answer is the JSON object {"description": "...", "body": "..."}.
+func Polish(description, body string) Prompt {
+	in, _ := json.Marshal(map[string]string{"description": description, "body": body})
+	return Prompt{
+		System: `You proofread git commit messages.
+Fix spelling, grammar and punctuation mistakes in the JSON object below and nothing else:
+- keep the meaning, the wording, the line breaks, the "- " bullets and the length;
+- never touch identifiers, file paths, code, commands or words in backticks;
+- keep the description in lower case imperative mood without a trailing period.
+Return only the JSON object with the same "description" and "body" keys.
+`,
+		User: fmt.Sprintf("%s\n", in),
+	}
+}
@@ -0,0 +1,24 @@
+package prompt
+
+// Version identifies the prompt templates and how answers to them are
+// read. Bump it with every template change, so cached answers to the old
+// prompts are not reused.
+const Version = "2"
+
+// Prompt separates the instructions (system role) from the material the
+// model works on (user role) so chat endpoints can use proper roles.
+type Prompt struct {
+	System string
+	User   string
+}
+
+// String joins both parts for endpoints without roles.
+func (p Prompt) String() string {
+	if p.System == "" {
+		return p.User
+	}
+	if p.User == "" {
+		return p.System
+	}
+	return p.System + "\n" + p.User
+}
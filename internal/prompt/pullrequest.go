@@ -0,0 +1,30 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PullRequest builds the prompt for a pull request title and description
+// covering the commits of a branch. changes are per-file summaries or the
+// raw diff of the branch, trimmed to fit.
+func PullRequest(branch string, commits []string, changes string) Prompt {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Branch: %s\nCommits (newest first):\n%s\n", branch, strings.Join(commits, "\n"))
+	if strings.TrimSpace(changes) != "" {
+		fmt.Fprintf(&b, "\nChanges on the branch:\n%s\n", changes)
+	}
+
+	return Prompt{
+		System: `You write pull request descriptions in markdown for reviewers.
+Describe the branch as a whole from its commits and changes, not commit by commit.
+
+Format:
+- First line: the title, an imperative summary of at most 72 characters without markdown; keep the ticket ID of the branch or commits in front when there is one.
+- Then an empty line and the sections "## Summary" (what and why, 2-4 sentences), "## Changes" (bullets naming the affected packages, APIs or flags) and "## Testing" (how to verify); add "## Notes" only for migrations, config changes or follow-ups.
+
+Output only the title and the description, without closing remarks.
+`,
+		User: b.String(),
+	}
+}
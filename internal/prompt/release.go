@@ -0,0 +1,40 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReleaseNotes builds the markdown release notes prompt for the commits
+// since a release. audience is "users" (what changed for them, in plain
+// language) or "developers" (a detailed internal change log). changes are
+// per-file summaries or the raw diff of the range, trimmed to fit.
+func ReleaseNotes(since string, commits []string, changes, audience string) Prompt {
+	guide := `Audience: end users of the product.
+- Start with a "## Highlights" section of at most 5 bullets describing the user-visible improvements in plain language and their benefit.
+- Follow with "## Fixes" and, only if anything breaks existing usage, "## Breaking changes" with upgrade steps.
+- Leave out refactors, tests, CI, dependency bumps and internal renames entirely; never mention file paths, function names or ticket IDs.`
+	if audience == "developers" {
+		guide = `Audience: developers working on the codebase.
+- Use the sections "## Features", "## Fixes", "## Performance", "## Refactoring", "## Internal" (build, CI, tests, dependencies) and "## Breaking changes"; omit empty sections.
+- One bullet per change, naming the affected packages, APIs or flags and the ticket ID in parentheses when a commit references one.
+- Call out migrations, config changes and anything that needs a follow-up.`
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Commits since %s (newest first):\n%s\n", since, strings.Join(commits, "\n"))
+	if strings.TrimSpace(changes) != "" {
+		fmt.Fprintf(&b, "\nChanges in the range:\n%s\n", changes)
+	}
+
+	return Prompt{
+		System: fmt.Sprintf(`You write release notes in markdown.
+Describe what changed since the previous release from the commits and changes below, grouping related commits and ignoring reverts that cancel out.
+
+%s
+
+Output only the markdown notes, without a title line or closing remarks.
+`, guide),
+		User: b.String(),
+	}
+}
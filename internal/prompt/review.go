@@ -1,19 +1,64 @@
 package prompt
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
+
+// ReviewInput gathers everything the review prompt can draw on.
+type ReviewInput struct {
+	Diff string
+	// Owners holds "path: @owner" lines from CODEOWNERS for the changed files.
+	Owners []string
+	// StaticFindings holds "[linter] message" lines from external linters.
+	StaticFindings []string
+	// TestGaps are heuristic "no tests updated for X" candidates.
+	TestGaps []string
+}
 
 // Review builds the prompt for lightweight code review.
-func Review(diff string) string {
-	return fmt.Sprintf(`You are a meticulous senior engineer.
+func Review(in ReviewInput) Prompt {
+	return Prompt{
+		System: `You are a meticulous senior engineer.
 Review the following git diff and highlight any potential issues.
 
 Return plain text following this format:
-- If you see problems: list each on its own line starting with "- " and keep each finding under 160 characters.
+- If you see problems: list each on its own line starting with "- " and its severity, "[high]" (bugs, security holes, data loss), "[medium]" or "[low]", and keep each finding under 160 characters.
+- When a finding is about a specific added line, follow the severity with the file path and the line number in the new file, e.g. "- [high] internal/api/login.go:42: token compared with == is not constant time".
 - If the changes look good: respond with "No blocking issues found."
 
 Focus on correctness, security, performance, tests, and edge cases. Do not mention formatting unless it hides a bug.
-
+`,
+		User: strings.TrimPrefix(fmt.Sprintf(`%s%s%s
 Diff:
 %s
-`, diff)
+`, ownersSection(in.Owners), staticSection(in.StaticFindings), testGapSection(in.TestGaps), in.Diff), "\n"),
+	}
+}
+
+func staticSection(findings []string) string {
+	if len(findings) == 0 {
+		return ""
+	}
+	return `
+Static analysis already reported the following. Repeat the ones that matter as findings (keep the [linter] prefix), merged with your own, and do not report the same problem twice:
+` + strings.Join(findings, "\n") + "\n"
+}
+
+func ownersSection(owners []string) string {
+	if len(owners) == 0 {
+		return ""
+	}
+	return `
+Code owners of the changed files (end a finding with "flag for <owner>" when it concerns their code):
+- ` + strings.Join(owners, "\n- ") + "\n"
+}
+
+func testGapSection(gaps []string) string {
+	if len(gaps) == 0 {
+		return ""
+	}
+	return `
+A heuristic found exported code changed without test changes. For each candidate, check the diff: if the change alters behaviour, report "- no tests updated for <name>"; drop candidates that are pure refactors, renames or comments:
+- ` + strings.Join(gaps, "\n- ") + "\n"
 }
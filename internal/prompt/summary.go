@@ -0,0 +1,14 @@
+package prompt
+
+import "fmt"
+
+// Summarize builds the prompt used to condense one chunk of a large diff.
+func Summarize(diff string) Prompt {
+	return Prompt{
+		System: `You summarise parts of a large git diff so a commit message can be written later.
+For every file in the diff below, write exactly one line in the form "- <path>: <what changed and why>".
+Keep each line under 160 characters, name concrete functions/types when possible, and output nothing else.
+`,
+		User: fmt.Sprintf("Diff:\n%s\n", diff),
+	}
+}
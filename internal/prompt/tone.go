@@ -0,0 +1,56 @@
+package prompt
+
+// Tone changes how much a commit message says and how it sounds.
+type Tone struct {
+	Name string
+	// Body replaces the prompt's "body" requirement; Voice is added to the
+	// requirements.
+	Body  string
+	Voice string
+	// NumPredict is the answer's token budget and MaxBody the body length
+	// in characters, both sized for Body.
+	NumPredict int
+	MaxBody    int
+}
+
+// Tones are the choices of --tone; the zero Tone keeps the default prompt.
+var Tones = []Tone{
+	{
+		Name:       "concise",
+		Body:       `- "body": at most one short sentence (<= 100 characters), only when the headline leaves something important unsaid.`,
+		Voice:      "Be terse: no filler, no restating the headline.",
+		NumPredict: 80,
+		MaxBody:    100,
+	},
+	{
+		Name:       "detailed",
+		Body:       `- "body": 3-6 sentences or "- " bullets (<= 700 characters) covering what changed, why it was needed, its impact and anything a reviewer should watch for.`,
+		Voice:      "Explain the rationale fully; a reader of the history should not need to open the diff.",
+		NumPredict: 320,
+		MaxBody:    700,
+	},
+	{
+		Name:       "casual",
+		Body:       `- "body": 1-2 plain sentences (<= 200 characters) on why the change was made.`,
+		Voice:      "Write in a relaxed, friendly voice with plain words; contractions are fine.",
+		NumPredict: 120,
+		MaxBody:    200,
+	},
+	{
+		Name:       "formal",
+		Body:       `- "body": 2-4 complete sentences (<= 500 characters) stating what changed and the rationale behind it.`,
+		Voice:      "Use precise, formal technical language in complete sentences, without contractions or colloquialisms.",
+		NumPredict: 240,
+		MaxBody:    500,
+	},
+}
+
+// LookupTone returns the tone called name.
+func LookupTone(name string) (Tone, bool) {
+	for _, t := range Tones {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Tone{}, false
+}
@@ -0,0 +1,320 @@
+// Package retrieval indexes short descriptions of the repository's packages
+// and directories with an embedding model, so the descriptions of the
+// modules a change touches, and of those most related to it, can be given
+// to the model as architectural context.
+package retrieval
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Embedder turns text into a vector; ollama.Client implements it.
+type Embedder interface {
+	Embed(ctx context.Context, endpoint, model, text string) ([]float64, error)
+}
+
+// Entry describes one directory of the repository.
+type Entry struct {
+	// Dir is slash separated and relative to the root; "." is the root.
+	Dir     string `json:"dir"`
+	Summary string `json:"summary"`
+	// Hash identifies Summary, so an unchanged entry keeps its vector.
+	Hash   string    `json:"hash"`
+	Vector []float64 `json:"vector,omitempty"`
+}
+
+// Index is the embedded description of a repository.
+type Index struct {
+	Root    string    `json:"root"`
+	Model   string    `json:"model"`
+	Built   time.Time `json:"built"`
+	Entries []Entry   `json:"entries"`
+}
+
+// Limits keep indexing and the prompt small on large repositories.
+const (
+	maxEntries      = 500
+	maxExported     = 15
+	maxFileNames    = 10
+	maxSummaryBytes = 600
+)
+
+// skipDirs are never indexed.
+var skipDirs = map[string]bool{"vendor": true, "node_modules": true, "testdata": true, "third_party": true}
+
+// Path returns where the index of root is kept: the user cache directory,
+// so nothing is added to the working tree.
+func Path(root string) string {
+	sum := sha256.Sum256([]byte(root))
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "go-commitgen", "index", hex.EncodeToString(sum[:8])+".json")
+}
+
+// Describe walks root and summarises every directory holding files: Go
+// packages by their package comment and exported names, other directories
+// by the first paragraph of their README or their file names.
+func Describe(root string) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		name := d.Name()
+		if p != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || skipDirs[name]) {
+			return filepath.SkipDir
+		}
+		if len(entries) >= maxEntries {
+			return filepath.SkipAll
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if summary := describeDir(p); summary != "" {
+			if len(summary) > maxSummaryBytes {
+				summary = summary[:maxSummaryBytes]
+			}
+			sum := sha256.Sum256([]byte(summary))
+			entries = append(entries, Entry{Dir: filepath.ToSlash(rel), Summary: summary, Hash: hex.EncodeToString(sum[:8])})
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func describeDir(dir string) string {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var names, goFiles []string
+	var readme string
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		names = append(names, f.Name())
+		switch {
+		case strings.HasSuffix(f.Name(), ".go") && !strings.HasSuffix(f.Name(), "_test.go"):
+			goFiles = append(goFiles, filepath.Join(dir, f.Name()))
+		case strings.EqualFold(strings.TrimSuffix(f.Name(), filepath.Ext(f.Name())), "readme"):
+			readme = filepath.Join(dir, f.Name())
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	if len(goFiles) > 0 {
+		if summary := describeGo(goFiles); summary != "" {
+			return summary
+		}
+	}
+	if readme != "" {
+		if para := firstParagraph(readme); para != "" {
+			return para
+		}
+	}
+	if len(names) > maxFileNames {
+		names = append(names[:maxFileNames], "...")
+	}
+	return "files: " + strings.Join(names, ", ")
+}
+
+func describeGo(files []string) string {
+	fset := token.NewFileSet()
+	var pkg, doc string
+	var exported []string
+	for _, name := range files {
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		pkg = f.Name.Name
+		if f.Doc != nil && doc == "" {
+			doc = strings.Join(strings.Fields(f.Doc.Text()), " ")
+		}
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil && d.Name.IsExported() {
+					exported = append(exported, d.Name.Name)
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.IsExported() {
+						exported = append(exported, ts.Name.Name)
+					}
+				}
+			}
+		}
+	}
+	if pkg == "" {
+		return ""
+	}
+	sort.Strings(exported)
+	if len(exported) > maxExported {
+		exported = append(exported[:maxExported], "...")
+	}
+	summary := "package " + pkg
+	if doc != "" {
+		summary += ": " + strings.TrimPrefix(doc, "Package "+pkg+" ")
+	}
+	if len(exported) > 0 {
+		summary += " Exports: " + strings.Join(exported, ", ") + "."
+	}
+	return summary
+}
+
+func firstParagraph(file string) string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	for _, para := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n\n") {
+		para = strings.TrimSpace(para)
+		// skip titles, badges and underlines
+		if para == "" || strings.HasPrefix(para, "#") || strings.HasPrefix(para, "[!") || strings.HasPrefix(para, "<") || !strings.Contains(para, " ") {
+			continue
+		}
+		return strings.Join(strings.Fields(para), " ")
+	}
+	return ""
+}
+
+// Build describes root and embeds every entry with model, reusing the
+// vectors of previous whose summaries did not change. It reports how many
+// entries had to be embedded.
+func Build(ctx context.Context, e Embedder, endpoint, model, root string, previous Index) (Index, int, error) {
+	entries, err := Describe(root)
+	if err != nil {
+		return Index{}, 0, err
+	}
+	known := map[string][]float64{}
+	if previous.Model == model {
+		for _, entry := range previous.Entries {
+			known[entry.Hash] = entry.Vector
+		}
+	}
+	embedded := 0
+	for i := range entries {
+		if v, ok := known[entries[i].Hash]; ok {
+			entries[i].Vector = v
+			continue
+		}
+		v, err := e.Embed(ctx, endpoint, model, entries[i].Summary)
+		if err != nil {
+			return Index{}, embedded, fmt.Errorf("embed %s: %w", entries[i].Dir, err)
+		}
+		entries[i].Vector = v
+		embedded++
+	}
+	return Index{Root: root, Model: model, Built: time.Now().UTC(), Entries: entries}, embedded, nil
+}
+
+// Load reads the index at path; a missing index is empty.
+func Load(p string) (Index, error) {
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Index{}, nil
+	}
+	if err != nil {
+		return Index{}, fmt.Errorf("read index: %w", err)
+	}
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return Index{}, fmt.Errorf("parse index %s: %w", p, err)
+	}
+	return idx, nil
+}
+
+// Save writes idx to path, creating its directory.
+func Save(p string, idx Index) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("create index dir: %w", err)
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o644)
+}
+
+// Touched returns the entries of the directories holding files, deepest
+// first as they are the most specific.
+func (idx Index) Touched(files []string) []Entry {
+	dirs := map[string]bool{}
+	for _, f := range files {
+		dirs[path.Dir(filepath.ToSlash(f))] = true
+	}
+	var out []Entry
+	for _, e := range idx.Entries {
+		if dirs[e.Dir] {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return strings.Count(out[i].Dir, "/") > strings.Count(out[j].Dir, "/")
+	})
+	return out
+}
+
+// Nearest returns up to n entries most similar to query, best first,
+// leaving out those in exclude.
+func (idx Index) Nearest(query []float64, n int, exclude []Entry) []Entry {
+	skip := map[string]bool{}
+	for _, e := range exclude {
+		skip[e.Dir] = true
+	}
+	type scored struct {
+		Entry
+		score float64
+	}
+	var candidates []scored
+	for _, e := range idx.Entries {
+		if !skip[e.Dir] && len(e.Vector) == len(query) {
+			candidates = append(candidates, scored{e, Cosine(query, e.Vector)})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	out := make([]Entry, len(candidates))
+	for i, c := range candidates {
+		out[i] = c.Entry
+	}
+	return out
+}
+
+// Cosine is the cosine similarity of two embeddings; 0 when either is zero.
+func Cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,363 @@
+// Package rpc serves the generator to editor extensions over a small
+// JSON-RPC 2.0 protocol on stdin/stdout: one JSON object per line in each
+// direction, handled one request at a time.
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	"github.com/riskibarqy/go-commitgen/internal/logging"
+	"github.com/riskibarqy/go-commitgen/internal/metrics"
+	"github.com/riskibarqy/go-commitgen/internal/ollama"
+	"github.com/riskibarqy/go-commitgen/internal/trace"
+	"github.com/riskibarqy/go-commitgen/internal/usecase"
+)
+
+// Standard JSON-RPC error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// maxLine bounds a single request; diffs never travel from the editor, so
+// requests stay small.
+const maxLine = 1 << 20
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error object.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// GenerateParams are the parameters of "generate". Zero values keep the
+// options the server was started with; Review, when given, turns the
+// review on or off for this request.
+type GenerateParams struct {
+	Review  *bool  `json:"review,omitempty"`
+	Model   string `json:"model,omitempty"`
+	Context string `json:"context,omitempty"`
+}
+
+// RegenerateParams are the parameters of "regenerate". Previous defaults to
+// the last message the server generated.
+type RegenerateParams struct {
+	Feedback string          `json:"feedback"`
+	Previous *commit.Message `json:"previous,omitempty"`
+	Model    string          `json:"model,omitempty"`
+}
+
+// MessageResult is returned by "generate" and "regenerate".
+type MessageResult struct {
+	Headline    string   `json:"headline"`
+	Body        string   `json:"body"`
+	Review      string   `json:"review,omitempty"`
+	ReviewError string   `json:"reviewError,omitempty"`
+	Violations  []string `json:"violations,omitempty"`
+	StyleFixes  []string `json:"styleFixes,omitempty"`
+	// Offline is set when the model was unreachable and the message was
+	// written from file stats.
+	Offline bool `json:"offline,omitempty"`
+	// Candidates are the answers of every model of an ensemble (--models).
+	Candidates []CandidateResult `json:"candidates,omitempty"`
+	// Score is the critic's verdict (--critic) on the message.
+	Score *usecase.Score `json:"score,omitempty"`
+	// Truncation is set when the model saw only part of the diff
+	// (--max-bytes): its size, and the files left out or cut short.
+	Truncation *usecase.Truncation `json:"truncation,omitempty"`
+}
+
+// CandidateResult is one model's answer of an ensemble; Error is set
+// instead of the message when the model failed.
+type CandidateResult struct {
+	Model    string `json:"model"`
+	Headline string `json:"headline,omitempty"`
+	Body     string `json:"body,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReviewResult is returned by "review".
+type ReviewResult struct {
+	Review      string   `json:"review"`
+	ReviewError string   `json:"reviewError,omitempty"`
+	Model       string   `json:"model,omitempty"`
+	Owners      []string `json:"owners,omitempty"`
+}
+
+// Server answers requests with Service, starting every call from Options.
+// Service, and with it the model client's connection pool, is shared by
+// every request of the session.
+type Server struct {
+	Service *usecase.Service
+	Options usecase.Options
+	// Timeout bounds each request that streams no model answer; zero means
+	// no limit. generate, review and regenerate are bounded by the model
+	// client instead, until the first byte and between chunks, so a long
+	// generation is not cut off.
+	Timeout time.Duration
+	// KeepAlive is sent with every model call so the models stay loaded
+	// between requests of the session ("30m", "-1" for forever).
+	KeepAlive string
+	// Metrics, when set, counts requests and model calls; serve it with
+	// metrics.Serve.
+	Metrics *metrics.Registry
+	// Log receives one record per request; nil discards them.
+	Log *slog.Logger
+	// Tracer, when set, records every request as its own trace and
+	// exports it after responding.
+	Tracer *trace.Tracer
+
+	last commit.Message
+}
+
+// Serve reads requests from r and writes responses to w until r is
+// exhausted, "exit" is received or ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	if s.Service == nil {
+		return errors.New("rpc server has no service")
+	}
+	svc := *s.Service
+	if s.KeepAlive != "" {
+		svc.LLM = keepAlive{LLMClient: svc.LLM, duration: s.KeepAlive}
+	}
+	if s.Metrics != nil {
+		svc.LLM = metrics.LLM{Next: svc.LLM, Registry: s.Metrics}
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxLine)
+	enc := json.NewEncoder(w)
+	for sc.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			if err := enc.Encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+
+		started := time.Now()
+		reqCtx, span := trace.Start(trace.WithTracer(ctx, s.Tracer), "rpc."+req.Method)
+		result, rpcErr := s.dispatch(reqCtx, &svc, req)
+		if rpcErr != nil { // a nil *Error must not become a non-nil error
+			span.End(rpcErr)
+		} else {
+			span.End(nil)
+		}
+		s.observe(req.Method, time.Since(started), rpcErr)
+		if rpcErr != nil {
+			logging.Or(s.Log).Warn("rpc request failed", "method", req.Method, "elapsed", time.Since(started), "code", rpcErr.Code, "err", rpcErr.Message)
+		} else {
+			logging.Or(s.Log).Info("rpc request", "method", req.Method, "elapsed", time.Since(started))
+		}
+		if len(req.ID) == 0 {
+			// notifications get no response
+			continue
+		}
+		resp := response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}
+		if rpcErr == nil && result == nil {
+			resp.Result = struct{}{}
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+		s.flushTraces(ctx)
+		if req.Method == "shutdown" {
+			return nil
+		}
+	}
+	return sc.Err()
+}
+
+// streamed are the methods whose model answers stream in; a total deadline
+// would cut a long generation short.
+var streamed = map[string]bool{"generate": true, "review": true, "regenerate": true}
+
+func (s *Server) dispatch(ctx context.Context, svc *usecase.Service, req request) (interface{}, *Error) {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return nil, &Error{Code: CodeInvalidRequest, Message: "expected a JSON-RPC 2.0 request with a method"}
+	}
+	if s.Timeout > 0 && !streamed[req.Method] {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"name":    "go-commitgen",
+			"methods": []string{"generate", "review", "regenerate", "shutdown", "exit"},
+		}, nil
+	case "shutdown":
+		return nil, nil
+	case "generate":
+		var params GenerateParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		opts := s.Options
+		if params.Review != nil {
+			opts.Review = *params.Review
+		}
+		if params.Model != "" {
+			opts.Model = params.Model
+		}
+		if params.Context != "" {
+			opts.Context = params.Context
+		}
+		return s.generate(ctx, svc, opts)
+	case "regenerate":
+		var params RegenerateParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if params.Feedback == "" {
+			return nil, &Error{Code: CodeInvalidParams, Message: "feedback is required"}
+		}
+		previous := s.last
+		if params.Previous != nil {
+			previous = *params.Previous
+		}
+		opts := s.Options
+		opts.Review = false
+		opts.Feedback = params.Feedback
+		opts.Previous = previous.Headline + "\n\n" + previous.Body
+		if params.Model != "" {
+			opts.Model = params.Model
+		}
+		return s.generate(ctx, svc, opts)
+	case "review":
+		var params GenerateParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		opts := s.Options
+		opts.Review = true
+		if params.Model != "" {
+			opts.ReviewModel = params.Model
+		}
+		result, err := svc.Review(ctx, opts)
+		if err != nil {
+			return nil, &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		out := ReviewResult{Review: result.Review, Model: result.ReviewModel, Owners: result.Owners}
+		if result.ReviewErr != nil {
+			out.ReviewError = result.ReviewErr.Error()
+		}
+		return out, nil
+	}
+	return nil, &Error{Code: CodeMethodNotFound, Message: "unknown method " + req.Method}
+}
+
+func (s *Server) generate(ctx context.Context, svc *usecase.Service, opts usecase.Options) (interface{}, *Error) {
+	result, err := svc.Execute(ctx, opts)
+	if err != nil {
+		return nil, &Error{Code: CodeInternalError, Message: err.Error()}
+	}
+	s.last = result.Message
+
+	out := MessageResult{Headline: result.Message.Headline, Body: result.Message.Body, Review: result.Review, Offline: result.Offline, Score: result.Score, Truncation: result.Truncation}
+	if result.ReviewErr != nil {
+		out.ReviewError = result.ReviewErr.Error()
+	}
+	for _, v := range result.Violations {
+		out.Violations = append(out.Violations, v.String())
+	}
+	for _, fix := range result.StyleFixes {
+		out.StyleFixes = append(out.StyleFixes, fix.String())
+	}
+	for _, c := range result.Candidates {
+		candidate := CandidateResult{Model: c.Model, Headline: c.Message.Headline, Body: c.Message.Body}
+		if c.Err != nil {
+			candidate.Error = c.Err.Error()
+		}
+		out.Candidates = append(out.Candidates, candidate)
+	}
+	return out, nil
+}
+
+// flushTraces exports the finished spans; a collector that is down only
+// costs a log record.
+func (s *Server) flushTraces(ctx context.Context) {
+	if s.Tracer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := s.Tracer.Flush(ctx); err != nil {
+		logging.Or(s.Log).Warn("trace export failed", "err", err)
+	}
+}
+
+// observe records a request in Metrics; unknown methods share one label so
+// a misbehaving client cannot grow the series without bound.
+func (s *Server) observe(method string, d time.Duration, rpcErr *Error) {
+	if s.Metrics == nil {
+		return
+	}
+	if rpcErr != nil && (rpcErr.Code == CodeMethodNotFound || rpcErr.Code == CodeInvalidRequest) {
+		method = "unknown"
+	}
+	s.Metrics.ObserveRequest(method, d, rpcErr != nil)
+}
+
+func decodeParams(raw json.RawMessage, v interface{}) *Error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// keepAlive stamps every request with the session's keep_alive duration.
+type keepAlive struct {
+	usecase.LLMClient
+	duration string
+}
+
+func (k keepAlive) Generate(ctx context.Context, endpoint string, req ollama.Request) (string, error) {
+	req.KeepAlive = k.duration
+	return k.LLMClient.Generate(ctx, endpoint, req)
+}
@@ -0,0 +1,178 @@
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// Record is one generation outcome appended to the local JSONL store.
+type Record struct {
+	Time         time.Time     `json:"time"`
+	Model        string        `json:"model"`
+	Latency      time.Duration `json:"latency_ns"`
+	Accepted     bool          `json:"accepted"`
+	Verbatim     bool          `json:"verbatim"`
+	EditDistance int           `json:"edit_distance"`
+}
+
+// NewRecord compares the generated message with what was finally committed.
+// Messages themselves are not stored, only how far apart they were.
+func NewRecord(model string, latency time.Duration, generated, final string, accepted bool) Record {
+	distance := util.EditDistance(generated, final)
+	return Record{
+		Time:         time.Now().UTC(),
+		Model:        model,
+		Latency:      latency,
+		Accepted:     accepted,
+		Verbatim:     accepted && distance == 0,
+		EditDistance: distance,
+	}
+}
+
+// DefaultPath returns the store location, honouring COMMITGEN_STATS_FILE.
+func DefaultPath() string {
+	if v := os.Getenv("COMMITGEN_STATS_FILE"); v != "" {
+		return v
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(".", ".commitgen-stats.jsonl")
+	}
+	return filepath.Join(dir, "go-commitgen", "stats.jsonl")
+}
+
+// Append adds rec to the store at path, creating it if needed.
+func Append(path string, rec Record) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create stats dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open stats file: %w", err)
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal stats record: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Load reads every record from path; a missing store is empty.
+func Load(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open stats file: %w", err)
+	}
+	defer f.Close()
+
+	var out []Record
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out, sc.Err()
+}
+
+// ModelSummary aggregates the records of one model.
+type ModelSummary struct {
+	Model        string
+	Total        int
+	Accepted     int
+	Verbatim     int
+	AvgLatency   time.Duration
+	AvgEditDist  float64
+	totalLatency time.Duration
+	totalDist    int
+}
+
+// Summarize groups records per model, sorted by usage.
+func Summarize(records []Record) []ModelSummary {
+	byModel := map[string]*ModelSummary{}
+	for _, rec := range records {
+		s, ok := byModel[rec.Model]
+		if !ok {
+			s = &ModelSummary{Model: rec.Model}
+			byModel[rec.Model] = s
+		}
+		s.Total++
+		s.totalLatency += rec.Latency
+		if rec.Accepted {
+			s.Accepted++
+			s.totalDist += rec.EditDistance
+		}
+		if rec.Verbatim {
+			s.Verbatim++
+		}
+	}
+
+	out := make([]ModelSummary, 0, len(byModel))
+	for _, s := range byModel {
+		s.AvgLatency = s.totalLatency / time.Duration(s.Total)
+		if s.Accepted > 0 {
+			s.AvgEditDist = float64(s.totalDist) / float64(s.Accepted)
+		}
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].Model < out[j].Model
+	})
+	return out
+}
+
+// Print writes the aggregates as a table.
+func Print(w io.Writer, records []Record) {
+	if len(records) == 0 {
+		fmt.Fprintln(w, "No generations recorded yet (run with --stats to start recording).")
+		return
+	}
+	fmt.Fprintf(w, "%-28s %6s %9s %9s %10s %9s\n", "MODEL", "RUNS", "ACCEPTED", "VERBATIM", "AVG LAT", "AVG EDIT")
+	for _, s := range Summarize(records) {
+		fmt.Fprintf(w, "%-28s %6d %8.0f%% %8.0f%% %10s %9.1f\n",
+			util.TruncateShorten(s.Model, 28),
+			s.Total,
+			percent(s.Accepted, s.Total),
+			percent(s.Verbatim, s.Total),
+			s.AvgLatency.Round(10*time.Millisecond),
+			s.AvgEditDist,
+		)
+	}
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+// Report loads the store at path and prints its aggregates; it backs the
+// `stats` subcommand.
+func Report(w io.Writer, path string) error {
+	records, err := Load(path)
+	if err != nil {
+		return err
+	}
+	Print(w, records)
+	return nil
+}
@@ -0,0 +1,242 @@
+// Package trace records spans of the generation pipeline and exports them
+// to an OpenTelemetry collector over OTLP/HTTP with JSON encoding, which
+// every collector accepts, so no SDK dependency is needed.
+package trace
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracer collects finished spans until Flush sends them to Endpoint.
+type Tracer struct {
+	// Endpoint is the OTLP traces URL, e.g. http://localhost:4318/v1/traces.
+	Endpoint string
+	// Service is reported as the service.name resource attribute.
+	Service string
+	// Headers are sent with every export (e.g. an API key).
+	Headers map[string]string
+	HTTP    *http.Client
+
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// Span is one timed step. A nil *Span is valid and records nothing, so
+// callers never check whether tracing is enabled.
+type Span struct {
+	tracer  *Tracer
+	traceID [16]byte
+	spanID  [8]byte
+	parent  [8]byte
+	name    string
+	start   time.Time
+	end     time.Time
+	attrs   map[string]interface{}
+	err     string
+}
+
+type ctxKey struct{}
+
+// WithTracer enables tracing for everything run with the returned context;
+// the spans started under it share a new trace.
+func WithTracer(ctx context.Context, t *Tracer) context.Context {
+	if t == nil {
+		return ctx
+	}
+	root := &Span{tracer: t}
+	_, _ = rand.Read(root.traceID[:])
+	return context.WithValue(ctx, ctxKey{}, root)
+}
+
+// Start opens a span named name as a child of the span in ctx. Without a
+// tracer in ctx it returns ctx unchanged and a nil span.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent, _ := ctx.Value(ctxKey{}).(*Span)
+	if parent == nil {
+		return ctx, nil
+	}
+	s := &Span{tracer: parent.tracer, traceID: parent.traceID, parent: parent.spanID, name: name, start: time.Now()}
+	_, _ = rand.Read(s.spanID[:])
+	return context.WithValue(ctx, ctxKey{}, s), s
+}
+
+// Set records an attribute; strings, bools, ints and floats are supported.
+func (s *Span) Set(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	if s.attrs == nil {
+		s.attrs = map[string]interface{}{}
+	}
+	s.attrs[key] = value
+}
+
+// End finishes the span, marking it failed when err is not nil, and hands
+// it to the tracer. Passing the function's named error keeps call sites to
+// `defer func() { span.End(err) }()`.
+func (s *Span) End(err error) {
+	if s == nil || !s.end.IsZero() {
+		return
+	}
+	s.end = time.Now()
+	if err != nil {
+		s.err = err.Error()
+	}
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, s)
+	s.tracer.mu.Unlock()
+}
+
+// TraceParent returns the W3C traceparent header of the span in ctx, or ""
+// when there is none, so model gateways can join the trace.
+func TraceParent(ctx context.Context) string {
+	s, _ := ctx.Value(ctxKey{}).(*Span)
+	if s == nil || s.spanID == ([8]byte{}) {
+		return ""
+	}
+	return "00-" + hex.EncodeToString(s.traceID[:]) + "-" + hex.EncodeToString(s.spanID[:]) + "-01"
+}
+
+// Flush exports the spans finished so far. Spans are dropped after a
+// failed export; tracing must never hold up a commit.
+func (t *Tracer) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mu.Unlock()
+	if len(spans) == 0 || t.Endpoint == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(t.payload(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+	client := t.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("export traces: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("export traces: %d %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// The OTLP JSON encoding of ExportTraceServiceRequest: ids are hex, times
+// are nanoseconds as decimal strings.
+type (
+	otlpRequest struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}
+	resourceSpans struct {
+		Resource struct {
+			Attributes []attribute `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+	scopeSpans struct {
+		Scope struct {
+			Name string `json:"name"`
+		} `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpSpan struct {
+		TraceID           string      `json:"traceId"`
+		SpanID            string      `json:"spanId"`
+		ParentSpanID      string      `json:"parentSpanId,omitempty"`
+		Name              string      `json:"name"`
+		Kind              int         `json:"kind"`
+		StartTimeUnixNano string      `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+		Attributes        []attribute `json:"attributes,omitempty"`
+		Status            struct {
+			Code    int    `json:"code,omitempty"`
+			Message string `json:"message,omitempty"`
+		} `json:"status"`
+	}
+	attribute struct {
+		Key   string                 `json:"key"`
+		Value map[string]interface{} `json:"value"`
+	}
+)
+
+// spanKindInternal and statusError are OTLP enum values.
+const (
+	spanKindInternal = 1
+	statusError      = 2
+)
+
+func (t *Tracer) payload(spans []*Span) otlpRequest {
+	service := t.Service
+	if service == "" {
+		service = "go-commitgen"
+	}
+	var rs resourceSpans
+	rs.Resource.Attributes = []attribute{attr("service.name", service)}
+	var ss scopeSpans
+	ss.Scope.Name = "github.com/riskibarqy/go-commitgen"
+	for _, s := range spans {
+		out := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		if s.parent != ([8]byte{}) {
+			out.ParentSpanID = hex.EncodeToString(s.parent[:])
+		}
+		for k, v := range s.attrs {
+			out.Attributes = append(out.Attributes, attr(k, v))
+		}
+		if s.err != "" {
+			out.Status.Code, out.Status.Message = statusError, s.err
+		}
+		ss.Spans = append(ss.Spans, out)
+	}
+	rs.ScopeSpans = []scopeSpans{ss}
+	return otlpRequest{ResourceSpans: []resourceSpans{rs}}
+}
+
+func attr(key string, v interface{}) attribute {
+	var value map[string]interface{}
+	switch v := v.(type) {
+	case bool:
+		value = map[string]interface{}{"boolValue": v}
+	case int:
+		value = map[string]interface{}{"intValue": strconv.Itoa(v)}
+	case int64:
+		value = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		value = map[string]interface{}{"doubleValue": v}
+	default:
+		value = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+	return attribute{Key: key, Value: value}
+}
@@ -0,0 +1,234 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	"github.com/riskibarqy/go-commitgen/internal/usecase"
+)
+
+type pane int
+
+const (
+	diffPane pane = iota
+	messagePane
+	reviewPane
+	panes
+)
+
+// action is what a key asks Run to do beyond changing the model.
+type action int
+
+const (
+	actNone action = iota
+	actQuit
+	actCommit
+	actEdit
+	actRegenerate
+)
+
+// model is the state of the screen; it only changes through key and
+// result, so the loop in Run stays a plain read-update-draw cycle.
+type model struct {
+	width, height int
+
+	diff       []string
+	candidates []commit.Message
+	selected   int
+	review     []string
+	types      []string
+
+	focus  pane
+	offset [panes]int
+	status string
+	busy   bool
+	// feedback is the regeneration request being typed; nil when the
+	// prompt is closed.
+	feedback *string
+}
+
+// result adds a finished generation as a new candidate and selects it.
+func (m *model) result(r usecase.Result, err error) {
+	m.busy = false
+	if err != nil {
+		m.status = "generation failed: " + err.Error()
+		return
+	}
+	if len(m.diff) == 0 {
+		m.diff = strings.Split(strings.TrimRight(r.DiffUsed, "\n"), "\n")
+	}
+	m.candidates = append(m.candidates, r.Message)
+	m.selected = len(m.candidates) - 1
+	// the other answers of an ensemble follow the chosen one
+	for _, c := range r.Candidates {
+		if c.Err == nil && c.Message.Headline != r.Message.Headline {
+			m.candidates = append(m.candidates, c.Message)
+		}
+	}
+	m.offset[messagePane] = 0
+
+	var review []string
+	switch {
+	case r.ReviewErr != nil:
+		review = append(review, "review failed: "+r.ReviewErr.Error())
+	case r.Review != "":
+		review = append(review, strings.Split(r.Review, "\n")...)
+	}
+	for _, v := range r.Violations {
+		review = append(review, "violation: "+v.String())
+	}
+	for _, fix := range r.StyleFixes {
+		review = append(review, "fixed: "+fix.String())
+	}
+	if len(review) > 0 || len(m.review) == 0 {
+		m.review = review
+		m.offset[reviewPane] = 0
+	}
+	m.status = ""
+}
+
+// current returns the selected candidate.
+func (m *model) current() (commit.Message, bool) {
+	if len(m.candidates) == 0 {
+		return commit.Message{}, false
+	}
+	return m.candidates[m.selected], true
+}
+
+// key applies one key press and returns what else it asks for; the
+// string is the feedback of actRegenerate.
+func (m *model) key(k string) (action, string) {
+	if m.feedback != nil {
+		switch k {
+		case "enter":
+			text := strings.TrimSpace(*m.feedback)
+			m.feedback = nil
+			if text == "" {
+				return actNone, ""
+			}
+			return actRegenerate, text
+		case "esc", "ctrl-c":
+			m.feedback = nil
+		case "backspace":
+			if s := *m.feedback; s != "" {
+				_, size := utf8.DecodeLastRuneInString(s)
+				*m.feedback = s[:len(s)-size]
+			}
+		default:
+			if r, size := utf8.DecodeRuneInString(k); size == len(k) && unicode.IsPrint(r) {
+				*m.feedback += k
+			}
+		}
+		return actNone, ""
+	}
+
+	page := m.height / 2
+	switch k {
+	case "q", "esc", "ctrl-c":
+		return actQuit, ""
+	case "tab":
+		m.focus = (m.focus + 1) % panes
+	case "shift-tab":
+		m.focus = (m.focus + panes - 1) % panes
+	case "up", "k":
+		m.scroll(-1)
+	case "down", "j":
+		m.scroll(1)
+	case "pgup":
+		m.scroll(-page)
+	case "pgdown", " ":
+		m.scroll(page)
+	case "home", "g":
+		m.offset[m.focus] = 0
+	case "left", "h":
+		if m.selected > 0 {
+			m.selected--
+			m.offset[messagePane] = 0
+		}
+	case "right", "l":
+		if m.selected < len(m.candidates)-1 {
+			m.selected++
+			m.offset[messagePane] = 0
+		}
+	case "r":
+		if !m.busy {
+			return actRegenerate, ""
+		}
+	case "f":
+		if !m.busy && len(m.candidates) > 0 {
+			empty := ""
+			m.feedback = &empty
+		}
+	case "t":
+		if msg, ok := m.current(); ok && len(m.types) > 0 {
+			msg.Headline = retype(msg.Headline, m.types)
+			m.candidates[m.selected] = msg
+		}
+	case "e":
+		if _, ok := m.current(); ok {
+			return actEdit, ""
+		}
+	case "c", "enter":
+		if _, ok := m.current(); ok && !m.busy {
+			return actCommit, ""
+		}
+	}
+	return actNone, ""
+}
+
+func (m *model) scroll(n int) {
+	m.offset[m.focus] += n
+	if m.offset[m.focus] < 0 {
+		m.offset[m.focus] = 0
+	}
+}
+
+// retype replaces the type inside the headline's brackets with the next
+// of types, keeping the ticket and any scope: "ABC-1 [feat(api)] x"
+// becomes "ABC-1 [fix(api)] x" when fix follows feat.
+func retype(headline string, types []string) string {
+	prefix, description := commit.SplitHeadline(headline)
+	open, close := strings.LastIndex(prefix, "["), strings.LastIndex(prefix, "]")
+	if open == -1 || close < open {
+		return headline
+	}
+	current, scope := prefix[open+1:close], ""
+	if i := strings.Index(current, "("); i != -1 {
+		current, scope = current[:i], current[i:]
+	}
+	next := types[0]
+	for i, t := range types {
+		if t == current {
+			next = types[(i+1)%len(types)]
+		}
+	}
+	return prefix[:open+1] + next + scope + prefix[close:] + description
+}
+
+// editable renders msg for the editor and parse reads it back: the
+// first line is the headline, the rest after a blank line the body, and
+// lines starting with "#" are dropped.
+func editable(msg commit.Message) string {
+	return msg.String() + "\n\n# Edit the message; lines starting with # are ignored.\n"
+}
+
+func parse(text string) commit.Message {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t"))
+		}
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return commit.Message{}
+	}
+	return commit.Message{
+		Headline: lines[0],
+		Body:     strings.Trim(strings.Join(lines[1:], "\n"), "\n"),
+	}
+}
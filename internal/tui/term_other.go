@@ -0,0 +1,20 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package tui
+
+import (
+	"errors"
+	"os"
+)
+
+var errUnsupported = errors.New("the TUI needs a Unix terminal; use the default flow instead")
+
+type terminal struct{}
+
+func rawMode(f *os.File) (*terminal, error) { return nil, errUnsupported }
+
+func (t *terminal) restore() error { return nil }
+
+func size(f *os.File) (width, height int, err error) { return 0, 0, errUnsupported }
+
+func notifyResize(c chan<- os.Signal) {}
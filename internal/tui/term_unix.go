@@ -0,0 +1,63 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package tui
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// terminal is the state needed to put a terminal back the way it was.
+type terminal struct {
+	fd    uintptr
+	saved syscall.Termios
+}
+
+func ioctl(fd, req uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// rawMode switches f to byte-at-a-time input without echo or signal
+// keys; Ctrl-C arrives as a key. A read returns nothing after a tenth of
+// a second without input, so readers can check for cancellation. Output
+// processing is kept, so "\n" still starts a new line.
+func rawMode(f *os.File) (*terminal, error) {
+	t := &terminal{fd: f.Fd()}
+	if err := ioctl(t.fd, ioctlGetTermios, unsafe.Pointer(&t.saved)); err != nil {
+		return nil, err
+	}
+	raw := t.saved
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 0
+	raw.Cc[syscall.VTIME] = 1
+	if err := ioctl(t.fd, ioctlSetTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *terminal) restore() error {
+	return ioctl(t.fd, ioctlSetTermios, unsafe.Pointer(&t.saved))
+}
+
+// size returns the columns and rows of the terminal on f.
+func size(f *os.File) (width, height int, err error) {
+	var ws struct{ Row, Col, X, Y uint16 }
+	if err := ioctl(f.Fd(), syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
+// notifyResize delivers a value on c whenever the terminal is resized.
+func notifyResize(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGWINCH)
+}
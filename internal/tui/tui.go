@@ -0,0 +1,309 @@
+// Package tui is the full-screen interactive mode: the staged diff on one
+// pane, the generated candidates on another and the review findings on a
+// third, with keys to regenerate, edit, change the type and commit.
+//
+// It was planned on bubbletea but is built on the terminal directly (raw
+// mode via termios, ANSI escapes) instead, because go.mod has no
+// requirements and the tool builds from a bare checkout; adding the
+// framework would be its first third-party dependency. The model/view
+// split mirrors bubbletea's update-view architecture, so moving onto it
+// later only replaces tui.go and the term_*.go files. The cost of going
+// without is platform support: termios exists only on Unix, so Windows
+// gets errUnsupported where bubbletea would have worked.
+package tui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/riskibarqy/go-commitgen/internal/cache"
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	"github.com/riskibarqy/go-commitgen/internal/usecase"
+)
+
+// ErrQuit is returned when the user leaves without committing.
+var ErrQuit = errors.New("quit without committing")
+
+// escapes maps the key sequences terminals send to key names.
+var escapes = map[string]string{
+	"\x1b[A": "up", "\x1b[B": "down", "\x1b[C": "right", "\x1b[D": "left",
+	"\x1bOA": "up", "\x1bOB": "down", "\x1bOC": "right", "\x1bOD": "left",
+	"\x1b[5~": "pgup", "\x1b[6~": "pgdown", "\x1b[H": "home", "\x1b[1~": "home",
+	"\x1b[Z": "shift-tab",
+}
+
+// keys splits what one read returned into key names; printable keys are
+// their own text.
+func keys(b []byte) []string {
+	var out []string
+	for len(b) > 0 {
+		if b[0] == 0x1b && len(b) > 1 {
+			name, n := "", 0
+			for seq, key := range escapes {
+				if bytes.HasPrefix(b, []byte(seq)) {
+					name, n = key, len(seq)
+				}
+			}
+			if n == 0 && b[1] == '[' {
+				// unknown CSI sequence: skip to its final byte
+				for n = 2; n < len(b) && (b[n] < 0x40 || b[n] > 0x7e); n++ {
+				}
+				n = min(n+1, len(b))
+			}
+			if n > 0 {
+				if name != "" {
+					out = append(out, name)
+				}
+				b = b[n:]
+				continue
+			}
+		}
+		switch b[0] {
+		case 0x1b:
+			out = append(out, "esc")
+		case 0x03:
+			out = append(out, "ctrl-c")
+		case '\t':
+			out = append(out, "tab")
+		case '\r', '\n':
+			out = append(out, "enter")
+		case 0x7f, 0x08:
+			out = append(out, "backspace")
+		default:
+			r, size := utf8.DecodeRune(b)
+			out = append(out, string(r))
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return out
+}
+
+// session owns the terminal while Run is active.
+type session struct {
+	in, out *os.File
+	term    *terminal
+	// reading is held by the input loop during each read; taking it
+	// stops the loop from stealing input from an editor or git.
+	reading sync.Mutex
+	done    chan struct{}
+	stopped sync.Once
+}
+
+// read runs the input loop until stop, sending what each read returns
+// to input and a failed read to errs.
+func (s *session) read(input chan<- []byte, errs chan<- error) {
+	buf := make([]byte, 256)
+	for {
+		s.reading.Lock()
+		select {
+		case <-s.done:
+			s.reading.Unlock()
+			return
+		default:
+		}
+		n, err := s.in.Read(buf)
+		s.reading.Unlock()
+		if n > 0 {
+			select {
+			case input <- append([]byte(nil), buf[:n]...):
+			case <-s.done:
+				return
+			}
+		}
+		// raw mode reads time out with io.EOF
+		if err != nil && !errors.Is(err, io.EOF) {
+			errs <- err
+			return
+		}
+	}
+}
+
+// stop ends the input loop and waits until it no longer reads.
+func (s *session) stop() {
+	s.stopped.Do(func() {
+		close(s.done)
+		s.reading.Lock()
+		s.reading.Unlock()
+	})
+}
+
+func (s *session) enter() error {
+	term, err := rawMode(s.in)
+	if err != nil {
+		return fmt.Errorf("tui: %w", err)
+	}
+	s.term = term
+	_, err = io.WriteString(s.out, "\x1b[?1049h\x1b[?25l\x1b[2J")
+	return err
+}
+
+func (s *session) leave() {
+	if s.term == nil {
+		return
+	}
+	_, _ = io.WriteString(s.out, "\x1b[?25h\x1b[?1049l")
+	_ = s.term.restore()
+	s.term = nil
+}
+
+// Run opens the interface on the terminal in/out, generates a first
+// candidate and lets the user regenerate (optionally with feedback),
+// edit, change the type and finally commit the chosen message, which it
+// returns. Issues for the findings of the review on screen are opened
+// only then (Options.CreateIssues); when that fails the message comes
+// back with the error. Leaving without committing returns ErrQuit.
+func Run(ctx context.Context, svc *usecase.Service, opts usecase.Options, in, out *os.File) (commit.Message, error) {
+	s := &session{in: in, out: out, done: make(chan struct{})}
+	if err := s.enter(); err != nil {
+		return commit.Message{}, err
+	}
+	defer s.leave()
+
+	m := &model{types: opts.Conventions.AllowedTypes()}
+	if m.width, m.height, _ = size(out); m.width == 0 {
+		m.width, m.height = 80, 24
+	}
+
+	input := make(chan []byte)
+	readErr := make(chan error, 1)
+	go s.read(input, readErr)
+	defer s.stop()
+
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+	defer signal.Stop(resize)
+
+	genCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type generated struct {
+		result usecase.Result
+		err    error
+	}
+	results := make(chan generated, 1)
+	// last is the generation whose review the interface shows
+	var last usecase.Result
+	generate := func(feedback string) {
+		layer := opts
+		if msg, ok := m.current(); ok && feedback != "" {
+			layer.Feedback, layer.Previous = feedback, msg.String()
+		}
+		ctx := genCtx
+		if len(m.candidates) > 0 {
+			// a regeneration wants a new answer, not the cached one
+			ctx = cache.Skip(ctx)
+		}
+		m.busy, m.status = true, ""
+		go func() {
+			r, err := svc.Execute(ctx, layer)
+			results <- generated{r, err}
+		}()
+	}
+	generate("")
+
+	for {
+		if _, err := io.WriteString(out, m.view()); err != nil {
+			return commit.Message{}, err
+		}
+		select {
+		case <-ctx.Done():
+			return commit.Message{}, ctx.Err()
+		case err := <-readErr:
+			return commit.Message{}, fmt.Errorf("tui: read terminal: %w", err)
+		case <-resize:
+			if w, h, err := size(out); err == nil {
+				m.width, m.height = w, h
+			}
+			_, _ = io.WriteString(out, "\x1b[2J")
+		case g := <-results:
+			m.result(g.result, g.err)
+			if g.err == nil {
+				last = g.result
+			}
+		case b := <-input:
+			for _, k := range keys(b) {
+				act, feedback := m.key(k)
+				switch act {
+				case actQuit:
+					return commit.Message{}, ErrQuit
+				case actRegenerate:
+					generate(feedback)
+				case actEdit:
+					msg, _ := m.current()
+					edited, err := s.edit(msg)
+					if err != nil {
+						m.status = "edit failed: " + err.Error()
+					} else if edited.Headline == "" {
+						m.status = "empty message, edit discarded"
+					} else {
+						m.candidates[m.selected] = edited
+					}
+					_, _ = io.WriteString(out, "\x1b[2J")
+				case actCommit:
+					msg, _ := m.current()
+					s.stop()
+					s.leave()
+					if err := svc.Commit(ctx, msg); err != nil {
+						return commit.Message{}, err
+					}
+					// the commit stands even when its issues cannot be linked
+					return msg, svc.CreateIssues(ctx, opts, &last)
+				}
+			}
+		}
+	}
+}
+
+// edit hands the terminal to the user's editor ($GIT_EDITOR, $VISUAL,
+// $EDITOR or vi) with msg in a temporary file and reads the result back.
+func (s *session) edit(msg commit.Message) (commit.Message, error) {
+	f, err := os.CreateTemp("", "COMMIT_EDITMSG-*")
+	if err != nil {
+		return commit.Message{}, err
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.WriteString(f, editable(msg)); err != nil {
+		f.Close()
+		return commit.Message{}, err
+	}
+	if err := f.Close(); err != nil {
+		return commit.Message{}, err
+	}
+
+	editor := "vi"
+	for _, env := range []string{"GIT_EDITOR", "VISUAL", "EDITOR"} {
+		if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+			editor = v
+			break
+		}
+	}
+
+	s.reading.Lock()
+	defer s.reading.Unlock()
+	s.leave()
+	// through the shell, so editors with arguments ("code --wait") work
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", f.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = s.in, s.out, os.Stderr
+	runErr := cmd.Run()
+	if err := s.enter(); err != nil {
+		return commit.Message{}, err
+	}
+	if runErr != nil {
+		return commit.Message{}, fmt.Errorf("%s: %w", editor, runErr)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return commit.Message{}, err
+	}
+	return parse(string(data)), nil
+}
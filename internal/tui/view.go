@@ -0,0 +1,161 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	reset   = "\x1b[0m"
+	bold    = "\x1b[1m"
+	reverse = "\x1b[7m"
+	dim     = "\x1b[2m"
+	red     = "\x1b[31m"
+	green   = "\x1b[32m"
+	cyan    = "\x1b[36m"
+)
+
+const help = "tab pane  ↑↓ scroll  ←→ candidate  r regenerate  f feedback  e edit  t type  c commit  q quit"
+
+// view draws the whole screen: the diff on the left, the selected
+// candidate above the review on the right and a status line.
+func (m *model) view() string {
+	if m.width < 20 || m.height < 6 {
+		return "\x1b[H\x1b[2Jterminal too small"
+	}
+	rows := m.height - 1
+	left := m.width / 2
+	right := m.width - left - 1
+	top := rows / 2
+
+	diffLines := m.pane(diffPane, " Diff ", m.diff, left, rows, diffColor)
+	title, message := " Message ", []string{"generating…"}
+	if msg, ok := m.current(); ok {
+		title = fmt.Sprintf(" Message %d/%d ", m.selected+1, len(m.candidates))
+		message = wrap(msg.String(), right)
+	} else if !m.busy {
+		message = []string{"no candidate"}
+	}
+	messageLines := m.pane(messagePane, title, message, right, top, nil)
+	review := m.review
+	if len(review) == 0 {
+		review = []string{"no review (run with --review)"}
+	}
+	reviewLines := m.pane(reviewPane, " Review ", wrapAll(review, right), right, rows-top, nil)
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i := 0; i < rows; i++ {
+		b.WriteString(diffLines[i] + dim + "│" + reset)
+		if i < top {
+			b.WriteString(messageLines[i])
+		} else {
+			b.WriteString(reviewLines[i-top])
+		}
+		b.WriteString("\n")
+	}
+	status := help
+	switch {
+	case m.feedback != nil:
+		status = "feedback (enter to regenerate, esc to cancel): " + *m.feedback
+	case m.busy:
+		status = "generating…  " + help
+	case m.status != "":
+		status = m.status
+	}
+	b.WriteString(reverse + fit(status, m.width) + reset)
+	return b.String()
+}
+
+// pane renders lines into a box of width×height whose first row is the
+// title, highlighted when the pane has the focus; the scroll offset is
+// clamped to the content.
+func (m *model) pane(p pane, title string, lines []string, width, height int, color func(string) string) []string {
+	out := make([]string, 0, height)
+	style := bold
+	if m.focus == p {
+		style = reverse
+	}
+	out = append(out, style+fit(title, width)+reset)
+
+	if max := len(lines) - (height - 1); m.offset[p] > max {
+		m.offset[p] = max
+	}
+	if m.offset[p] < 0 {
+		m.offset[p] = 0
+	}
+	for i := m.offset[p]; len(out) < height; i++ {
+		if i >= len(lines) {
+			out = append(out, strings.Repeat(" ", width))
+			continue
+		}
+		line := fit(lines[i], width)
+		if color != nil {
+			line = color(line)
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+func diffColor(line string) string {
+	switch {
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "diff "):
+		return bold + line + reset
+	case strings.HasPrefix(line, "+"):
+		return green + line + reset
+	case strings.HasPrefix(line, "-"):
+		return red + line + reset
+	case strings.HasPrefix(line, "@@"):
+		return cyan + line + reset
+	}
+	return line
+}
+
+// fit expands tabs, drops control characters and cuts or pads s to
+// exactly width columns.
+func fit(s string, width int) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return string([]rune(s)[:width])
+}
+
+// wrap splits text into lines of at most width columns, breaking on
+// spaces.
+func wrap(s string, width int) []string {
+	return wrapAll(strings.Split(strings.TrimRight(s, "\n"), "\n"), width)
+}
+
+func wrapAll(lines []string, width int) []string {
+	var out []string
+	for _, line := range lines {
+		if utf8.RuneCountInString(line) <= width {
+			out = append(out, line)
+			continue
+		}
+		current := ""
+		for _, word := range strings.Fields(line) {
+			switch {
+			case current == "":
+				current = word
+			case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width:
+				out = append(out, current)
+				current = word
+			default:
+				current += " " + word
+			}
+		}
+		out = append(out, current)
+	}
+	return out
+}
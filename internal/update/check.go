@@ -0,0 +1,53 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CheckInterval is how long the answer of the startup check is reused, so
+// that GitHub is asked at most once a day.
+const CheckInterval = 24 * time.Hour
+
+// checkState is what Check remembers between runs.
+type checkState struct {
+	Checked time.Time `json:"checked"`
+	Latest  string    `json:"latest"`
+}
+
+// DefaultStateFile is where Check remembers the latest release.
+func DefaultStateFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "go-commitgen", "update-check.json")
+}
+
+// Check returns the tag of the latest release when it is newer than
+// current, and "" otherwise. The tag is remembered in stateFile for
+// CheckInterval; a failed lookup is remembered too, so an offline machine
+// does not retry on every run.
+func (u Updater) Check(ctx context.Context, current, stateFile string) (string, error) {
+	var state checkState
+	if data, err := os.ReadFile(stateFile); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+	if time.Since(state.Checked) >= CheckInterval {
+		rel, err := u.Latest(ctx)
+		state = checkState{Checked: time.Now(), Latest: rel.Tag}
+		if data, merr := json.Marshal(state); merr == nil && os.MkdirAll(filepath.Dir(stateFile), 0o755) == nil {
+			_ = os.WriteFile(stateFile, data, 0o644)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if Newer(state.Latest, current) {
+		return state.Latest, nil
+	}
+	return "", nil
+}
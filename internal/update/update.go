@@ -0,0 +1,310 @@
+// Package update replaces the running binary with the latest GitHub
+// release after verifying its checksum and the signature of the checksum
+// file.
+package update
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Repo is the GitHub repository releases are published to.
+const Repo = "riskibarqy/go-commitgen"
+
+// ChecksumsAsset lists "<sha256>  <asset>" lines for every binary of a
+// release; ChecksumsAsset+".sig" is its base64 ed25519 signature.
+const ChecksumsAsset = "checksums.txt"
+
+// PublicKey is the base64 ed25519 key release checksums are signed with,
+// set at link time. When empty Install refuses unless the caller opted
+// into checksum-only verification with Updater.AllowUnsigned.
+var PublicKey = ""
+
+// maxBinary bounds the download so a bad redirect cannot fill the disk.
+const maxBinary = 200 << 20
+
+// Release is the subset of the GitHub release payload used here.
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a downloadable file of a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Updater talks to the GitHub API; the zero value uses http.DefaultClient
+// and api.github.com.
+type Updater struct {
+	HTTP *http.Client
+	// API overrides https://api.github.com, for GitHub Enterprise mirrors.
+	API string
+	// Token is sent as a bearer token to lift the anonymous rate limit.
+	// It only goes to the API host, never to asset downloads.
+	Token string
+	// AllowUnsigned lets a build without a PublicKey install a release
+	// checked against checksums.txt alone, which only guards against
+	// corrupt downloads, not against a tampered release.
+	AllowUnsigned bool
+}
+
+// ErrNoReleaseKey is returned by Install when the build embeds no release
+// key and Updater.AllowUnsigned is not set.
+var ErrNoReleaseKey = errors.New("this build embeds no release signing key, so the release cannot be authenticated; pass --allow-unsigned to trust its checksum alone")
+
+// AssetName is the binary published for the running platform, e.g.
+// "go-commitgen_linux_amd64" or "go-commitgen_windows_amd64.exe".
+func AssetName() string {
+	name := fmt.Sprintf("go-commitgen_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Latest fetches the newest published release.
+func (u Updater) Latest(ctx context.Context) (Release, error) {
+	body, err := u.get(ctx, u.api()+"/repos/"+Repo+"/releases/latest", 1<<20)
+	if err != nil {
+		return Release{}, fmt.Errorf("fetch latest release: %w", err)
+	}
+	var rel Release
+	if err := json.Unmarshal(body, &rel); err != nil {
+		return Release{}, fmt.Errorf("decode release: %w", err)
+	}
+	return rel, nil
+}
+
+// Newer reports whether release tag latest is a higher version than
+// current. Development builds ("dev" or anything unparsable) are never
+// considered outdated, so they are only replaced on request.
+func Newer(latest, current string) bool {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+func parseVersion(v string) ([3]int, bool) {
+	var out [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i != -1 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return out, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return out, false
+		}
+		out[i] = n
+	}
+	return out, true
+}
+
+// Install downloads the binary of rel for this platform, verifies it and
+// atomically replaces the executable at path.
+func (u Updater) Install(ctx context.Context, rel Release, path string) error {
+	name := AssetName()
+	binary, ok := rel.asset(name)
+	if !ok {
+		return fmt.Errorf("release %s has no binary for this platform (%s)", rel.Tag, name)
+	}
+	sums, ok := rel.asset(ChecksumsAsset)
+	if !ok {
+		return fmt.Errorf("release %s publishes no %s; refusing to install an unverified binary", rel.Tag, ChecksumsAsset)
+	}
+
+	if PublicKey == "" && !u.AllowUnsigned {
+		return ErrNoReleaseKey
+	}
+
+	checksums, err := u.get(ctx, sums.URL, 1<<20)
+	if err != nil {
+		return fmt.Errorf("download checksums: %w", err)
+	}
+	if PublicKey != "" {
+		sigAsset, ok := rel.asset(ChecksumsAsset + ".sig")
+		if !ok {
+			return fmt.Errorf("release %s is not signed", rel.Tag)
+		}
+		sig, err := u.get(ctx, sigAsset.URL, 4096)
+		if err != nil {
+			return fmt.Errorf("download signature: %w", err)
+		}
+		if err := verifySignature(checksums, sig); err != nil {
+			return err
+		}
+	}
+	want, err := checksumFor(checksums, name)
+	if err != nil {
+		return err
+	}
+
+	data, err := u.get(ctx, binary.URL, maxBinary)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", name, err)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+	}
+	return replace(path, data)
+}
+
+func (rel Release) asset(name string) (Asset, bool) {
+	for _, a := range rel.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+func verifySignature(checksums, sig []byte) error {
+	key, err := base64.StdEncoding.DecodeString(PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("built-in release key is invalid")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), checksums, raw) {
+		return errors.New("checksum signature does not match the release key")
+	}
+	return nil
+}
+
+// checksumFor finds the sha256 of name in a sha256sum-style listing.
+func checksumFor(checksums []byte, name string) (string, error) {
+	sc := bufio.NewScanner(strings.NewReader(string(checksums)))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s lists no checksum for %s", ChecksumsAsset, name)
+}
+
+// replace writes data next to path and renames it over the original, so a
+// failed update never leaves a half-written executable. Windows cannot
+// overwrite a running binary, so the old one is moved aside first.
+func replace(path string, data []byte) error {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(resolved), ".go-commitgen-update-*")
+	if err != nil {
+		return fmt.Errorf("create temp file (is %s writable?): %w", filepath.Dir(resolved), err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := resolved + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(resolved, old); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp.Name(), resolved); err != nil {
+			_ = os.Rename(old, resolved)
+			return err
+		}
+		return nil
+	}
+	return os.Rename(tmp.Name(), resolved)
+}
+
+func (u Updater) api() string {
+	if api := strings.TrimRight(u.API, "/"); api != "" {
+		return api
+	}
+	return "https://api.github.com"
+}
+
+// get fetches url. The token is only attached when url is on the API
+// host; Go's client drops it again if the API redirects elsewhere.
+func (u Updater) get(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	api, err := neturl.Parse(u.api())
+	if err != nil {
+		return nil, fmt.Errorf("parse API URL: %w", err)
+	}
+	if req.URL.Scheme == api.Scheme && req.URL.Host == api.Host {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if u.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+u.Token)
+		}
+	}
+	client := u.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s: %d %s", url, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s: response larger than %d bytes", url, limit)
+	}
+	return data, nil
+}
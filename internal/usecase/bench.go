@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/bench"
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	"github.com/riskibarqy/go-commitgen/internal/git"
+	"github.com/riskibarqy/go-commitgen/internal/prompt"
+	"github.com/riskibarqy/go-commitgen/internal/retrieval"
+)
+
+// Bench generates a message for every sample with each of opts.Models
+// (opts.Model when there are none) through the usual pipeline, without
+// the review, and scores it against the sample's human message. Messages
+// are embedded with opts.EmbedModel for the similarity column when
+// s.Embedder is set. One row is returned per model, in order; a sample
+// that fails only counts as failed.
+func (s *Service) Bench(ctx context.Context, opts Options, samples []bench.Sample, dataset string) ([]bench.Row, error) {
+	models := opts.Models
+	if len(models) == 0 {
+		models = []string{opts.Model}
+	}
+	run := opts
+	run.Models, run.Judge, run.AutoModels = nil, "", nil
+	run.Review = false
+	run.IncludeUntracked = false
+	run.HookSource = ""
+
+	embedder := s.Embedder
+	if opts.EmbedModel == "" {
+		embedder = nil
+	}
+	// the human messages are embedded once for all models
+	references := map[string][]float64{}
+	embed := func(text string) ([]float64, bool) {
+		if embedder == nil {
+			return nil, false
+		}
+		vector, err := embedder.Embed(ctx, opts.Endpoint, opts.EmbedModel, text)
+		if err != nil {
+			s.log().Warn("embedding failed; leaving out the similarity", "model", opts.EmbedModel, "err", err)
+			embedder = nil
+			return nil, false
+		}
+		return vector, true
+	}
+
+	rows := make([]bench.Row, 0, len(models))
+	for _, model := range models {
+		run.Model = model
+		scores := make([]bench.Score, 0, len(samples))
+		for i, sample := range samples {
+			if err := ctx.Err(); err != nil {
+				return rows, err
+			}
+			sub := Service{Repo: &git.PatchRepository{Diff: sample.Diff, Branch: "bench"}, LLM: s.LLM, Linters: s.Linters, Log: s.Log, Embedder: s.Embedder}
+			started := time.Now()
+			result, err := sub.Execute(ctx, run)
+			score := bench.Score{Elapsed: time.Since(started), Err: err}
+			if err != nil {
+				s.log().Warn("sample failed", "model", model, "sample", sample.Name, "err", err)
+				scores = append(scores, score)
+				continue
+			}
+			score = scoreSample(opts.Conventions, sample, result, score)
+			if embedder != nil {
+				reference, ok := references[sample.Name]
+				if !ok {
+					reference, ok = embed(sample.Message)
+					references[sample.Name] = reference
+				}
+				if generated, embedded := embed(result.Message.String()); ok && embedded {
+					score.Similarity, score.Embedded = retrieval.Cosine(generated, reference), true
+				}
+			}
+			s.log().Debug("bench sample", "model", model, "sample", sample.Name, "n", i+1, "rouge_l", score.Rouge, "type_match", score.TypeMatch)
+			scores = append(scores, score)
+		}
+		row := bench.Summarise(model, prompt.Version, dataset, scores)
+		s.log().Info("bench model done", "model", model, "samples", row.Samples, "failed", row.Failed, "rouge_l", row.Rouge)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// scoreSample compares the generated description and type with the
+// human headline's.
+func scoreSample(conventions commit.Conventions, sample bench.Sample, result Result, score bench.Score) bench.Score {
+	humanType, humanDescription := bench.Subject(sample.Message)
+	description := result.Parts.Description
+	if description == "" {
+		_, description = bench.Subject(result.Message.Headline)
+	}
+	score.Rouge = bench.RougeL(description, humanDescription)
+	if humanType != "" {
+		score.Typed = true
+		want := conventions.NormaliseParts(commit.Parts{CommitType: humanType}).CommitType
+		score.TypeMatch = result.Parts.CommitType == want
+	}
+	return score
+}
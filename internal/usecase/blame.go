@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	difftext "github.com/riskibarqy/go-commitgen/internal/diff"
+	"github.com/riskibarqy/go-commitgen/internal/git"
+	"github.com/riskibarqy/go-commitgen/internal/trace"
+)
+
+// blamer is what blame context needs beyond the Repository interface; the
+// git repository implements it.
+type blamer interface {
+	BlameLines(ctx context.Context, path string, start, end int) ([]git.BlameLine, error)
+}
+
+// maxBlameOrigins bounds the commits named in the prompt; the ones that
+// wrote the most replaced lines come first.
+const maxBlameOrigins = 8
+
+// blameOrigin is a commit that last changed some of the replaced lines of
+// a file.
+type blameOrigin struct {
+	path    string
+	hash    string
+	subject string
+	lines   int
+}
+
+// blameContext blames the lines the staged hunks remove or rewrite, not
+// their context lines, and names the commits that wrote them, so the model
+// can describe the change against the earlier intent. New files, pure
+// additions and blame failures yield nothing.
+func (s *Service) blameContext(ctx context.Context, opts Options, d string) []string {
+	if !opts.BlameContext {
+		return nil
+	}
+	repo, ok := s.Repo.(blamer)
+	if !ok {
+		return nil
+	}
+	ctx, span := trace.Start(ctx, "git.blame")
+
+	var origins []*blameOrigin
+	for _, f := range difftext.SplitFiles(d) {
+		byHash := map[string]*blameOrigin{}
+		for _, r := range difftext.RemovedRanges(f.Text) {
+			lines, err := repo.BlameLines(ctx, f.Path, r.Start, r.End)
+			if err != nil {
+				// new or renamed files have nothing to blame
+				s.log().Debug("blame failed", "path", f.Path, "err", err)
+				break
+			}
+			for _, l := range lines {
+				o, ok := byHash[l.Hash]
+				if !ok {
+					o = &blameOrigin{path: f.Path, hash: l.Hash, subject: l.Subject}
+					byHash[l.Hash] = o
+					origins = append(origins, o)
+				}
+				o.lines++
+			}
+		}
+	}
+	sort.SliceStable(origins, func(i, j int) bool { return origins[i].lines > origins[j].lines })
+	if len(origins) > maxBlameOrigins {
+		origins = origins[:maxBlameOrigins]
+	}
+
+	span.Set("blame.origins", len(origins))
+	span.End(nil)
+
+	out := make([]string, 0, len(origins))
+	for _, o := range origins {
+		out = append(out, fmt.Sprintf("%s: %d %s from %q (%s)", o.path, o.lines, lineWord(o.lines), o.subject, shortHash(o.hash)))
+	}
+	return out
+}
+
+func lineWord(n int) string {
+	if n == 1 {
+		return "line"
+	}
+	return "lines"
+}
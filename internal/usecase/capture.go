@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/capture"
+	"github.com/riskibarqy/go-commitgen/internal/ollama"
+	"github.com/riskibarqy/go-commitgen/internal/version"
+)
+
+// exchange is one model call seen by a recorder.
+type exchange struct {
+	endpoint string
+	request  ollama.Request
+	response string
+	err      error
+}
+
+// recorder passes calls through to an LLMClient and keeps what was sent
+// and answered.
+type recorder struct {
+	next LLMClient
+
+	mu        sync.Mutex
+	exchanges []exchange
+}
+
+func (r *recorder) Generate(ctx context.Context, endpoint string, req ollama.Request) (string, error) {
+	out, err := r.next.Generate(ctx, endpoint, req)
+	r.mu.Lock()
+	r.exchanges = append(r.exchanges, exchange{endpoint: endpoint, request: req, response: out, err: err})
+	r.mu.Unlock()
+	return out, err
+}
+
+// Capture runs the generation without committing and bundles the trimmed
+// diff, branch, settings, every prompt and raw model answer and the final
+// message for a bug report. settings describes the configuration in
+// effect; secrets (API keys, header values) are redacted from every file
+// along with anything that looks like a token. A failed generation is
+// captured too, with its error, since that is usually what gets reported.
+func (s *Service) Capture(ctx context.Context, opts Options, settings string, secrets []string) (capture.Bundle, error) {
+	if s == nil || s.Repo == nil || s.LLM == nil {
+		return capture.Bundle{}, errors.New("service not properly initialized")
+	}
+	rec := &recorder{next: s.LLM}
+	sub := *s
+	sub.LLM = rec
+
+	// a capture must not touch the working tree
+	layer := opts
+	layer.StripMarkers = false
+	result, err := sub.Execute(ctx, layer)
+
+	bundle := capture.Bundle{Created: time.Now()}
+	diff, branch := result.DiffUsed, result.Branch
+	if diff == "" {
+		diff, _, _, _, _ = s.stagedDiff(ctx, layer)
+	}
+	if branch == "" {
+		branch, _ = s.Repo.CurrentBranch(ctx)
+	}
+	build := version.Get()
+	bundle.Add("environment.txt", fmt.Sprintf("version: %s\ncommit: %s\nbuilt: %s\nmodified: %t\ngo: %s\nplatform: %s\n", build.Version, build.Commit, build.Date, build.Modified, build.Go, build.Platform))
+	bundle.Add("settings.txt", settings, secrets...)
+	bundle.Add("branch.txt", branch+"\n", secrets...)
+	bundle.Add("diff.patch", diff, secrets...)
+	for i, ex := range rec.exchanges {
+		bundle.Add(fmt.Sprintf("%02d-prompt.txt", i+1), describeRequest(ex), secrets...)
+		response := ex.response
+		if ex.err != nil {
+			response += "\n--- error ---\n" + ex.err.Error() + "\n"
+		}
+		bundle.Add(fmt.Sprintf("%02d-response.txt", i+1), response, secrets...)
+	}
+	if result.Review != "" {
+		bundle.Add("review.txt", result.Review, secrets...)
+	}
+	if err != nil {
+		bundle.Add("error.txt", err.Error()+"\n", secrets...)
+	} else {
+		bundle.Add("message.txt", result.Message.String()+"\n", secrets...)
+	}
+	return bundle, nil
+}
+
+// describeRequest renders a model call as its model, endpoint, sampling
+// options and format followed by the system and user prompts.
+func describeRequest(ex exchange) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "model: %s\nendpoint: %s\n", ex.request.Model, ex.endpoint)
+	if merged := ex.request.MergedOptions(); len(merged) > 0 {
+		options, _ := json.Marshal(merged)
+		fmt.Fprintf(&b, "options: %s\n", options)
+	}
+	if ex.request.Format != nil {
+		format, _ := json.Marshal(ex.request.Format)
+		fmt.Fprintf(&b, "format: %s\n", format)
+	}
+	if ex.request.System != "" {
+		b.WriteString("\n--- system ---\n" + ex.request.System + "\n")
+	}
+	b.WriteString("\n--- prompt ---\n" + ex.request.Prompt + "\n")
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/git"
+)
+
+// maxConflictFiles bounds the paths named in errors and the prompt.
+const maxConflictFiles = 5
+
+// checkConflicts refuses to describe a change while paths are still
+// unmerged: the diff would show conflict markers and half-applied hunks,
+// and the message would describe them. Force only warns. A rebase,
+// cherry-pick or revert without conflicts is described, with a warning
+// that continuing it would keep the original message.
+func (s *Service) checkConflicts(opts Options, state git.MergeState) error {
+	what := state.Operation
+	if what == "" {
+		what = "merge"
+	}
+	if len(state.Conflicts) == 0 {
+		if state.Operation != "" {
+			s.log().Warn("generating during a stopped "+state.Operation, "replaying", state.Replaying,
+				"hint", "git "+state.Operation+" --continue would reuse the original message")
+		}
+		return nil
+	}
+	if !opts.Force {
+		return fmt.Errorf("%s in progress with unresolved conflicts in %s: resolve and stage them first, or pass --force", what, fileList(state.Conflicts))
+	}
+	s.log().Warn("generating despite unresolved conflicts", "operation", what, "files", state.Conflicts)
+	return nil
+}
+
+// operationNote tells the model which stopped rebase, cherry-pick or revert
+// the change belongs to, so the message describes the replayed commit as
+// applied rather than the conflict resolution.
+func operationNote(state git.MergeState) string {
+	if state.Operation == "" && len(state.Conflicts) == 0 {
+		return ""
+	}
+	var note string
+	switch state.Operation {
+	case "rebase":
+		note = "rebasing; this change replays an earlier commit"
+	case "cherry-pick":
+		note = "cherry-picking an earlier commit onto this branch"
+	case "revert":
+		note = "reverting an earlier commit"
+	default:
+		note = "resolving a merge"
+	}
+	if state.Replaying != "" {
+		note += fmt.Sprintf(" (%q)", state.Replaying)
+	}
+	if len(state.Conflicts) > 0 {
+		note += "; conflict markers are left in " + fileList(state.Conflicts) + ", do not describe them as changes"
+	}
+	return note
+}
+
+func fileList(files []string) string {
+	if len(files) > maxConflictFiles {
+		return strings.Join(files[:maxConflictFiles], ", ") + fmt.Sprintf(" and %d more", len(files)-maxConflictFiles)
+	}
+	return strings.Join(files, ", ")
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	"github.com/riskibarqy/go-commitgen/internal/prompt"
+	"github.com/riskibarqy/go-commitgen/internal/trace"
+)
+
+var criticDefaults = map[string]interface{}{"temperature": 0.0, "top_p": 0.9, "num_predict": 200}
+
+// Score is the critic's verdict on a message, each aspect from 1 to 10.
+type Score struct {
+	Accuracy    int    `json:"accuracy"`
+	Specificity int    `json:"specificity"`
+	Conventions int    `json:"conventions"`
+	Notes       string `json:"notes"`
+	// Model is the critic that gave the score.
+	Model string `json:"-"`
+}
+
+// Overall is the lowest of the three scores: a message is as good as its
+// weakest aspect.
+func (s Score) Overall() int {
+	return min(s.Accuracy, s.Specificity, s.Conventions)
+}
+
+func (s Score) String() string {
+	out := fmt.Sprintf("%d/10 (accuracy %d, specificity %d, conventions %d)", s.Overall(), s.Accuracy, s.Specificity, s.Conventions)
+	if s.Notes != "" {
+		out += ": " + s.Notes
+	}
+	return out
+}
+
+// critique scores parts with the critic model and, while the score stays
+// below opts.CriticThreshold, regenerates with the critic's notes as
+// feedback up to opts.CriticRetries times. The best scoring answer wins;
+// a failed critic call keeps the answer it was asked about.
+func (s *Service) critique(ctx context.Context, opts Options, input prompt.CommitInput, previous []string, parts commit.Parts, result *Result) commit.Parts {
+	if !opts.Critic {
+		return parts
+	}
+	best, violations := parts, result.Violations
+	for round := 0; ; round++ {
+		msg := opts.Conventions.BuildMessage(input.Branch, parts)
+		score, err := s.score(ctx, opts, input, msg)
+		if err != nil {
+			s.log().Warn("critic failed; keeping the message", "model", score.Model, "err", err)
+			break
+		}
+		s.log().Info("critic score", "model", score.Model, "round", round+1, "score", score.Overall(),
+			"accuracy", score.Accuracy, "specificity", score.Specificity, "conventions", score.Conventions, "notes", score.Notes)
+		if result.Score == nil || score.Overall() > result.Score.Overall() {
+			best, violations, result.Score = parts, result.Violations, &score
+		}
+		if score.Overall() >= opts.CriticThreshold || round >= opts.CriticRetries {
+			break
+		}
+
+		retry := opts
+		retry.Previous = msg.String()
+		retry.Feedback = strings.TrimSpace(opts.Feedback + "\n" + fmt.Sprintf("A reviewer scored it %s. Improve the weakest aspect.", score))
+		var r Result
+		next, err := s.generateParts(ctx, retry, input, previous, &r)
+		if err != nil {
+			s.log().Warn("regenerating after a low critic score failed", "err", err)
+			break
+		}
+		parts, result.Violations = next, r.Violations
+		result.Attempts += r.Attempts
+		result.Regenerations++
+	}
+	result.Violations = violations
+	return best
+}
+
+// score asks the critic model (default the generating model) for its
+// verdict on msg.
+func (s *Service) score(ctx context.Context, opts Options, input prompt.CommitInput, msg commit.Message) (Score, error) {
+	model := opts.CriticModel
+	if model == "" {
+		model = opts.Model
+	}
+	req := newRequest(model, prompt.Critique(input, msg.String()), criticDefaults, opts.LLMOptions)
+	if opts.ResponseFormat == "schema" || opts.ResponseFormat == "json" {
+		req.Format = "json"
+	}
+	callCtx, span := trace.Start(ctx, "llm.critique")
+	span.Set("llm.model", model)
+	raw, err := s.LLM.Generate(callCtx, opts.Endpoint, req)
+	span.End(err)
+	if err != nil {
+		return Score{Model: model}, err
+	}
+
+	var score Score
+	err = commit.DecodeJSON(raw, &score)
+	score.Model = model
+	if err != nil {
+		return score, fmt.Errorf("critic answer is not JSON: %w", err)
+	}
+	for _, v := range []int{score.Accuracy, score.Specificity, score.Conventions} {
+		if v < 1 || v > 10 {
+			return score, fmt.Errorf("critic answer has a score outside 1-10: %s", raw)
+		}
+	}
+	score.Notes = strings.TrimSpace(score.Notes)
+	return score, nil
+}
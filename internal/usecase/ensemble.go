@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	"github.com/riskibarqy/go-commitgen/internal/prompt"
+	"github.com/riskibarqy/go-commitgen/internal/trace"
+)
+
+var judgeDefaults = map[string]interface{}{"temperature": 0.1, "top_p": 0.9, "num_predict": 250}
+
+// Candidate is the answer of one model of an ensemble.
+type Candidate struct {
+	Model string
+	// Message is built like the final message but skips the polish pass
+	// and the post-processors; Err is set instead when the model failed.
+	Message commit.Message
+	Err     error
+	Elapsed time.Duration
+
+	parts commit.Parts
+}
+
+// ensemble asks every model of opts.Models for commit parts at once. With
+// a Judge, that model picks or merges the best of the answers; without
+// one the first model's answer is used and all are kept in
+// result.Candidates for the user to choose from. A single model (or none)
+// is a plain generateParts call.
+func (s *Service) ensemble(ctx context.Context, opts Options, input prompt.CommitInput, previous []string, result *Result) (commit.Parts, error) {
+	if len(opts.Models) < 2 {
+		return s.generateParts(ctx, opts, input, previous, result)
+	}
+
+	candidates := make([]Candidate, len(opts.Models))
+	results := make([]Result, len(opts.Models))
+	var wg sync.WaitGroup
+	for i, model := range opts.Models {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			member := opts
+			member.Model = model
+			started := time.Now()
+			parts, err := s.generateParts(ctx, member, input, previous, &results[i])
+			candidates[i] = Candidate{Model: model, Err: err, Elapsed: time.Since(started), parts: parts}
+		}()
+	}
+	wg.Wait()
+
+	first := -1
+	var errs []error
+	for i, c := range candidates {
+		if c.Err != nil {
+			s.log().Warn("ensemble model failed", "model", c.Model, "err", c.Err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.Model, c.Err))
+			continue
+		}
+		s.log().Debug("ensemble candidate", "model", c.Model, "elapsed", c.Elapsed, "description", c.parts.Description)
+		msg, err := s.candidateMessage(opts, input.Branch, c.parts, result.Findings)
+		if err != nil {
+			return commit.Parts{}, err
+		}
+		candidates[i].Message = msg
+		if first < 0 {
+			first = i
+		}
+	}
+	if first < 0 {
+		return commit.Parts{}, errors.Join(errs...)
+	}
+	result.Candidates = candidates
+	result.Attempts, result.Violations = results[first].Attempts, results[first].Violations
+
+	if opts.Judge == "" {
+		return candidates[first].parts, nil
+	}
+	parts, err := s.judge(ctx, opts, input, candidates)
+	if err != nil {
+		s.log().Warn("judge failed; using the first candidate", "model", opts.Judge, "err", err)
+		return candidates[first].parts, nil
+	}
+	result.Violations = nil
+	return parts, nil
+}
+
+// judge shows the successful candidates to opts.Judge and returns the
+// answer it picks or merges from them. An answer breaking the conventions
+// is an error, so the caller falls back to a candidate that passed them.
+func (s *Service) judge(ctx context.Context, opts Options, input prompt.CommitInput, candidates []Candidate) (commit.Parts, error) {
+	var answers []string
+	for _, c := range candidates {
+		if c.Err != nil {
+			continue
+		}
+		raw, err := json.Marshal(c.parts)
+		if err != nil {
+			return commit.Parts{}, err
+		}
+		answers = append(answers, string(raw))
+	}
+
+	req := newRequest(opts.Judge, prompt.CommitJudge(input, answers), judgeDefaults, opts.LLMOptions)
+	req.Format = responseFormat(opts)
+	callCtx, span := trace.Start(ctx, "llm.judge")
+	span.Set("llm.model", opts.Judge)
+	span.Set("llm.candidates", len(answers))
+	raw, err := s.LLM.Generate(callCtx, opts.Endpoint, req)
+	span.End(err)
+	if err != nil {
+		return commit.Parts{}, err
+	}
+	parts, err := commit.DecodeParts(raw)
+	if err != nil {
+		return commit.Parts{}, fmt.Errorf("answer is not a JSON object: %w", err)
+	}
+	if violations := opts.Conventions.Lint(parts); len(violations) > 0 {
+		return commit.Parts{}, fmt.Errorf("answer breaks the conventions: %s", violations[0])
+	}
+	s.log().Debug("judged", "model", opts.Judge, "description", parts.Description)
+	return opts.Conventions.NormaliseParts(parts), nil
+}
+
+// candidateMessage builds the message of an ensemble candidate with the
+// style rules, follow-ups, issue keyword, trailers and sign-off of the
+// final message.
+func (s *Service) candidateMessage(opts Options, branch string, parts commit.Parts, findings []Finding) (commit.Message, error) {
+	msg, _ := s.applyStyle(opts, opts.Conventions.BuildMessage(branch, parts))
+	msg = withFollowUps(opts, msg, findings)
+	if opts.IssueKeywords != nil {
+		msg = opts.Conventions.WithIssueKeyword(msg, branch, parts.CommitType, opts.IssueKeywords)
+	}
+	extra, err := trailers(opts, branch)
+	if err != nil {
+		return commit.Message{}, err
+	}
+	msg = commit.WithTrailers(msg, extra)
+	if opts.Signoff != "" {
+		msg = commit.WithTrailers(msg, []string{commit.Signoff(opts.Signoff)})
+	}
+	return msg, nil
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/examples"
+	"github.com/riskibarqy/go-commitgen/internal/prompt"
+)
+
+// fewShot picks the accepted messages of the past changes in this
+// repository most similar to diff. A missing or unreadable library only
+// means no examples.
+func (s *Service) fewShot(ctx context.Context, opts Options, diff string) []prompt.Example {
+	if opts.FewShot <= 0 || opts.ExamplesFile == "" {
+		return nil
+	}
+	repos, err := s.repoKeys(ctx)
+	if err != nil {
+		return nil
+	}
+	library, err := examples.Load(opts.ExamplesFile, repos...)
+	if err != nil {
+		s.log().Warn("few-shot examples unavailable", "err", err)
+		return nil
+	}
+	var out []prompt.Example
+	for _, ex := range examples.Similar(library, examples.Summarize(diff), opts.FewShot) {
+		out = append(out, prompt.Example{Change: ex.Summary, Message: ex.Message})
+	}
+	s.log().Debug("few-shot examples", "library", len(library), "used", len(out))
+	return out
+}
+
+// RecordExample adds the message the user finally committed for diff
+// (Result.DiffUsed) to the few-shot library at opts.ExamplesFile.
+func (s *Service) RecordExample(ctx context.Context, opts Options, diff, message string) error {
+	if opts.ExamplesFile == "" || strings.TrimSpace(message) == "" || strings.TrimSpace(diff) == "" {
+		return nil
+	}
+	repo, err := s.repoKey(ctx)
+	if err != nil {
+		return err
+	}
+	return examples.Append(opts.ExamplesFile, examples.Example{
+		Time:    time.Now().UTC(),
+		Repo:    repo,
+		Summary: examples.Summarize(diff),
+		Message: strings.TrimSpace(message),
+	})
+}
+
+// commonDirer is implemented by repositories with linked worktrees;
+// git.CLIRepository implements it.
+type commonDirer interface {
+	CommonDir(ctx context.Context) (string, error)
+}
+
+// repoKey identifies the repository the same way from every linked
+// worktree: by git's common directory, or the root for other VCSs.
+func (s *Service) repoKey(ctx context.Context) (string, error) {
+	if repo, ok := s.Repo.(commonDirer); ok {
+		if dir, err := repo.CommonDir(ctx); err == nil {
+			return dir, nil
+		}
+	}
+	return s.Repo.Root(ctx)
+}
+
+// repoKeys returns repoKey and the keys examples of this repository were
+// recorded under before it: the root of the worktree in use and, for a
+// ".git" common directory, the root of the main checkout.
+func (s *Service) repoKeys(ctx context.Context) ([]string, error) {
+	key, err := s.repoKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{key}
+	if root, err := s.Repo.Root(ctx); err == nil && root != key {
+		keys = append(keys, root)
+	}
+	if filepath.Base(key) == ".git" {
+		if main := filepath.Dir(key); main != keys[len(keys)-1] {
+			keys = append(keys, main)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/git"
+	"github.com/riskibarqy/go-commitgen/internal/prompt"
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+var explainDefaults = map[string]interface{}{"temperature": 0.2, "top_p": 0.9, "num_predict": 500}
+
+// maxExplainedCommits bounds the commits read for a range or a file's
+// history; older ones rarely fit the byte budget anyway.
+const maxExplainedCommits = 30
+
+// archaeologist is what Explain needs beyond the Repository interface; the
+// git repository implements it.
+type archaeologist interface {
+	IsRevision(ctx context.Context, rev string) bool
+	PathLog(ctx context.Context, revRange string, paths []string, limit int) ([]git.LogEntry, error)
+	ChangeDiff(ctx context.Context, rev string, paths []string, opts git.DiffOptions) (string, error)
+}
+
+// Explain tells in plain language what a past change did and why, from its
+// messages and diff. target is a commit, a range ("A..B") or a file, whose
+// recent history is explained; "" is HEAD. file, when set, limits a commit
+// or range to that path.
+func (s *Service) Explain(ctx context.Context, opts Options, target, file string) (string, error) {
+	repo, ok := s.Repo.(archaeologist)
+	if !ok {
+		return "", errors.New("explain needs a git repository")
+	}
+	if target == "" {
+		target = "HEAD"
+	}
+	var paths []string
+	if file != "" {
+		paths = []string{file}
+	}
+
+	var (
+		subject string
+		entries []git.LogEntry
+		diff    string
+		err     error
+	)
+	isRevision := repo.IsRevision(ctx, target)
+	switch {
+	case isRevision && !strings.Contains(target, ".."):
+		subject = "commit " + target
+		if entries, err = repo.PathLog(ctx, target, nil, 1); err != nil {
+			return "", err
+		}
+		diff, err = repo.ChangeDiff(ctx, target, paths, opts.Diff)
+	case isRevision:
+		subject = "the commits of " + target
+		if entries, err = repo.PathLog(ctx, target, paths, maxExplainedCommits); err != nil {
+			return "", err
+		}
+		diff, err = repo.ChangeDiff(ctx, target, paths, opts.Diff)
+	default:
+		if _, statErr := os.Stat(target); statErr != nil {
+			return "", fmt.Errorf("%q is neither a commit, a range such as main..HEAD nor a file", target)
+		}
+		subject = "how " + target + " came to be, from its recent commits"
+		if entries, err = repo.PathLog(ctx, "HEAD", []string{target}, maxExplainedCommits); err != nil {
+			return "", err
+		}
+		diff, err = fileHistory(ctx, opts, repo, target, entries)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("no commits found for %s", target)
+	}
+	if file != "" {
+		subject += ", as far as " + file + " is concerned"
+	}
+
+	commits := make([]string, 0, len(entries))
+	for _, e := range entries {
+		line := fmt.Sprintf("- %s %s", shortHash(e.Hash), e.Subject)
+		if e.Body != "" {
+			line += ": " + util.TruncateShorten(util.CondenseSpaces(e.Body), 400)
+		}
+		commits = append(commits, line)
+	}
+
+	changes := s.promptDiff(ctx, opts, util.TrimTo(diff, opts.MaxBytes))
+	if opts.SummarizeLarge && opts.MaxBytes > 0 && len(diff) > opts.MaxBytes {
+		summaries, err := s.summarize(ctx, opts, diff)
+		if err != nil {
+			return "", err
+		}
+		changes = strings.Join(summaries, "\n")
+	}
+
+	out, err := s.LLM.Generate(ctx, opts.Endpoint, newRequest(opts.Model, prompt.Explain(subject, commits, changes), explainDefaults, opts.LLMOptions))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// fileHistory joins the changes each commit made to path, newest first,
+// until opts.MaxBytes is reached.
+func fileHistory(ctx context.Context, opts Options, repo archaeologist, path string, entries []git.LogEntry) (string, error) {
+	var b strings.Builder
+	for _, e := range entries {
+		d, err := repo.ChangeDiff(ctx, e.Hash, []string{path}, opts.Diff)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "commit %s %s\n%s\n", shortHash(e.Hash), e.Subject, d)
+		if opts.MaxBytes > 0 && b.Len() > opts.MaxBytes {
+			break
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+)
+
+// maxFollowUps caps the review findings carried into a commit body; the
+// rest stay in the printed review.
+const maxFollowUps = 5
+
+// Finding is one "- " line of the review, anchored to a file line when the
+// reviewer named one.
+type Finding struct {
+	File string
+	Line int
+	Text string
+	// Severity is "high", "medium" or "low" as the reviewer tagged it, or
+	// "" for an untagged finding.
+	Severity string
+}
+
+var (
+	findingAnchor   = regexp.MustCompile("^`?([^\\s:`]+):(\\d+)`?:?\\s+(.+)$")
+	findingSeverity = regexp.MustCompile(`(?i)^\[(high|medium|low)\]\s*`)
+)
+
+// Findings splits review text into its findings.
+func Findings(review string) []Finding {
+	var out []Finding
+	for _, line := range strings.Split(review, "\n") {
+		text, ok := strings.CutPrefix(strings.TrimSpace(line), "- ")
+		if !ok || strings.TrimSpace(text) == "" {
+			continue
+		}
+		var f Finding
+		f.Severity, f.Text = cutSeverity(strings.TrimSpace(text))
+		if m := findingAnchor.FindStringSubmatch(f.Text); m != nil {
+			f.File, f.Text = m[1], m[3]
+			f.Line, _ = strconv.Atoi(m[2])
+		}
+		if f.Severity == "" {
+			// "path:line: [high] ..." puts the tag after the anchor
+			f.Severity, f.Text = cutSeverity(f.Text)
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// cutSeverity splits a leading "[high]", "[medium]" or "[low]" tag off text.
+func cutSeverity(text string) (severity, rest string) {
+	m := findingSeverity.FindStringSubmatch(text)
+	if m == nil {
+		return "", text
+	}
+	return strings.ToLower(m[1]), strings.TrimSpace(text[len(m[0]):])
+}
+
+func (f Finding) String() string {
+	if f.File == "" {
+		return f.Text
+	}
+	return f.File + ":" + strconv.Itoa(f.Line) + ": " + f.Text
+}
+
+// followUpSection returns the "Known issues / follow-ups" paragraph listing
+// findings, or "" without any.
+func followUpSection(findings []Finding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Known issues / follow-ups:")
+	for _, f := range findings[:min(len(findings), maxFollowUps)] {
+		b.WriteString("\n- " + f.String())
+	}
+	if extra := len(findings) - maxFollowUps; extra > 0 {
+		fmt.Fprintf(&b, "\n- %d more in the review", extra)
+	}
+	return b.String()
+}
+
+// followUpTrailers returns one "TODO:" trailer per finding.
+func followUpTrailers(findings []Finding) []string {
+	var out []string
+	for _, f := range findings[:min(len(findings), maxFollowUps)] {
+		out = append(out, "TODO: "+f.String())
+	}
+	return out
+}
+
+// withFollowUps carries the review findings into msg as opts.FollowUps
+// asks: "section" adds them as a paragraph before the trailers,
+// "trailers" as TODO trailers.
+func withFollowUps(opts Options, msg commit.Message, findings []Finding) commit.Message {
+	switch opts.FollowUps {
+	case "section":
+		return commit.WithSection(msg, followUpSection(findings))
+	case "trailers":
+		return commit.WithTrailers(msg, followUpTrailers(findings))
+	}
+	return msg
+}
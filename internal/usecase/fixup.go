@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	difftext "github.com/riskibarqy/go-commitgen/internal/diff"
+	"github.com/riskibarqy/go-commitgen/internal/git"
+)
+
+// Fixupper is what finding and creating fixup commits needs beyond the
+// Repository interface; git.CLIRepository implements it.
+type Fixupper interface {
+	MergeBase(ctx context.Context, a, b string) (string, error)
+	CommitDiff(ctx context.Context, hash string, opts git.DiffOptions) (string, error)
+	Blame(ctx context.Context, path string, start, end int) ([]string, error)
+	Fixup(ctx context.Context, hash string) error
+}
+
+// FixupCandidate is a commit of the branch the staged changes may amend.
+type FixupCandidate struct {
+	Hash    string
+	Subject string
+	// Lines counts the staged hunk lines last changed by the commit and
+	// Files the staged files it touched.
+	Lines int
+	Files int
+}
+
+func (c FixupCandidate) String() string {
+	return fmt.Sprintf("%.12s %s (%d lines, %d files)", c.Hash, c.Subject, c.Lines, c.Files)
+}
+
+// FixupCandidates ranks the commits between trunk and HEAD by how much of
+// the staged change they overlap: the lines the staged hunks replace that
+// each commit last changed (git blame), then the staged files it touched.
+// Commits with no overlap are left out.
+func (s *Service) FixupCandidates(ctx context.Context, opts Options, fixupper Fixupper, trunk string) ([]FixupCandidate, error) {
+	if s == nil || s.Repo == nil {
+		return nil, errors.New("service not properly initialized")
+	}
+	staged, err := s.Repo.StagedDiff(ctx, opts.Diff)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(staged) == "" {
+		return nil, errNoChanges
+	}
+	base, err := fixupper.MergeBase(ctx, trunk, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	logged, err := s.Repo.Log(ctx, base+"..HEAD", 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(logged) == 0 {
+		return nil, fmt.Errorf("no commits between %s and HEAD to fix up", trunk)
+	}
+
+	candidates := map[string]*FixupCandidate{}
+	for _, e := range logged {
+		candidates[e.Hash] = &FixupCandidate{Hash: e.Hash, Subject: e.Subject}
+	}
+
+	files := difftext.SplitFiles(staged)
+	stagedPaths := map[string]bool{}
+	for _, f := range files {
+		stagedPaths[f.Path] = true
+		for _, r := range difftext.OriginalRanges(f.Text) {
+			hashes, err := fixupper.Blame(ctx, f.Path, r.Start, r.End)
+			if err != nil {
+				// new or renamed files have nothing to blame
+				s.log().Debug("blame failed", "path", f.Path, "err", err)
+				break
+			}
+			for _, h := range hashes {
+				if c, ok := candidates[h]; ok {
+					c.Lines++
+				}
+			}
+		}
+	}
+	for _, e := range logged {
+		d, err := fixupper.CommitDiff(ctx, e.Hash, git.DiffOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range difftext.SplitFiles(d) {
+			if stagedPaths[f.Path] {
+				candidates[e.Hash].Files++
+			}
+		}
+	}
+
+	var out []FixupCandidate
+	for _, e := range logged {
+		if c := candidates[e.Hash]; c.Lines > 0 || c.Files > 0 {
+			out = append(out, *c)
+		}
+	}
+	// logged is newest first, so ties go to the most recent commit
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Lines != out[j].Lines {
+			return out[i].Lines > out[j].Lines
+		}
+		return out[i].Files > out[j].Files
+	})
+	return out, nil
+}
+
+// Fixup creates the fixup! commit for target unless ctx was cancelled
+// while the user was confirming it; see Commit.
+func (s *Service) Fixup(ctx context.Context, fixupper Fixupper, target FixupCandidate) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("fixup aborted: %w", err)
+	}
+	return fixupper.Fixup(context.WithoutCancel(ctx), target.Hash)
+}
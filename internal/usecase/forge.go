@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/forge"
+	"github.com/riskibarqy/go-commitgen/internal/gitea"
+	"github.com/riskibarqy/go-commitgen/internal/github"
+	"github.com/riskibarqy/go-commitgen/internal/gitlab"
+)
+
+// ForgeConfig selects and authenticates the code host of the origin remote.
+type ForgeConfig struct {
+	// Kind is the forge to talk to; "" or "auto" detects it from the remote.
+	Kind   forge.Kind
+	Tokens map[forge.Kind]string
+	// GitHubAPI overrides the GitHub API URL; GitHub Enterprise remotes
+	// default to <instance>/api/v3.
+	GitHubAPI string
+	HTTP      *http.Client
+}
+
+// remoteURLer is implemented by repositories with named remotes;
+// git.CLIRepository implements it.
+type remoteURLer interface {
+	RemoteURL(ctx context.Context, name string) (string, error)
+}
+
+// Forge opens the client for the forge hosting the origin remote.
+func (s *Service) Forge(ctx context.Context, cfg ForgeConfig) (forge.Forge, forge.Kind, forge.Remote, error) {
+	repo, ok := s.Repo.(remoteURLer)
+	if !ok {
+		return nil, "", forge.Remote{}, errors.New("posting to a forge needs a git repository")
+	}
+	url, err := repo.RemoteURL(ctx, "origin")
+	if err != nil {
+		return nil, "", forge.Remote{}, err
+	}
+	remote, err := forge.ParseRemote(url)
+	if err != nil {
+		return nil, "", forge.Remote{}, err
+	}
+
+	kind := cfg.Kind
+	if kind == "" || kind == "auto" {
+		if kind = forge.Detect(remote); kind == "" {
+			kind = forge.Probe(ctx, cfg.HTTP, remote)
+		}
+		if kind == "" {
+			return nil, "", remote, fmt.Errorf("cannot tell which forge runs %s; pass --forge", remote.Host)
+		}
+	}
+
+	token := cfg.Tokens[kind]
+	switch kind {
+	case forge.GitHub:
+		api := cfg.GitHubAPI
+		if api == "" && remote.Host != "github.com" {
+			api = remote.Instance + "/api/v3"
+		}
+		return github.Client{HTTP: cfg.HTTP, API: api, Token: token, Owner: remote.Owner(), Repo: remote.Name()}, kind, remote, nil
+	case forge.GitLab:
+		return gitlab.Client{HTTP: cfg.HTTP, URL: remote.Instance, Token: token, Project: remote.Path}, kind, remote, nil
+	case forge.Gitea:
+		return gitea.Client{HTTP: cfg.HTTP, URL: remote.Instance, Token: token, Owner: remote.Owner(), Repo: remote.Name()}, kind, remote, nil
+	}
+	return nil, "", remote, fmt.Errorf("unknown forge %q", kind)
+}
+
+// Identity is what `whoami` reports.
+type Identity struct {
+	Kind    forge.Kind
+	Project string
+	URL     string
+	User    string
+}
+
+func (i Identity) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "forge:   %s\n", i.Kind)
+	fmt.Fprintf(&b, "project: %s (%s)\n", i.Project, i.URL)
+	fmt.Fprintf(&b, "user:    %s\n", i.User)
+	return b.String()
+}
+
+// WhoAmI detects the forge and project of the origin remote and checks the
+// configured token by asking the forge who it belongs to.
+func (s *Service) WhoAmI(ctx context.Context, cfg ForgeConfig) (Identity, error) {
+	client, kind, remote, err := s.Forge(ctx, cfg)
+	if err != nil {
+		return Identity{}, err
+	}
+	id := Identity{Kind: kind, Project: remote.Path, URL: remote.Instance + "/" + remote.Path}
+	if cfg.Tokens[kind] == "" {
+		return id, fmt.Errorf("no token configured for %s", kind)
+	}
+	if id.User, err = client.User(ctx); err != nil {
+		return id, fmt.Errorf("token rejected by %s: %w", kind, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	"github.com/riskibarqy/go-commitgen/internal/forge"
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// TicketReader reads tracker tickets such as Jira's PROJ-123;
+// jira.Client implements it.
+type TicketReader interface {
+	Issue(ctx context.Context, key string) (forge.Issue, error)
+}
+
+// maxIssueIntent bounds the part of the issue description given to the
+// model; the opening paragraphs usually carry the why.
+const maxIssueIntent = 1200
+
+// FetchIssue reads the issue ref names: numbers from s.Tracker, ticket
+// keys from s.Tickets.
+func (s *Service) FetchIssue(ctx context.Context, ref string) (forge.Issue, error) {
+	number, key, ok := commit.ParseIssueRef(ref)
+	switch {
+	case !ok:
+		return forge.Issue{}, fmt.Errorf("%q is neither an issue number such as #12 nor a ticket key such as PROJ-123", ref)
+	case key != "":
+		if s.Tickets == nil {
+			return forge.Issue{}, fmt.Errorf("reading ticket %s needs a tracker; see --jira-url", key)
+		}
+		issue, err := s.Tickets.Issue(ctx, key)
+		if err != nil {
+			return forge.Issue{}, fmt.Errorf("read ticket %s: %w", key, err)
+		}
+		return issue, nil
+	}
+	if s.Tracker == nil {
+		return forge.Issue{}, fmt.Errorf("reading issue #%d needs a forge; see --forge", number)
+	}
+	issue, err := s.Tracker.Issue(ctx, number)
+	if err != nil {
+		return forge.Issue{}, fmt.Errorf("read issue #%d: %w", number, err)
+	}
+	return issue, nil
+}
+
+// WithIssue makes issue the primary intent of the commit, ahead of any
+// --context, and its reference the ticket of the headline and of the
+// issue keyword, which defaults to commit.DefaultIssueKeywords.
+func WithIssue(opts Options, issue forge.Issue) Options {
+	intent := fmt.Sprintf("resolves %s %q", issue.Ref(), strings.TrimSpace(issue.Title))
+	if body := util.CondenseSpaces(issue.Body); body != "" {
+		intent += ": " + util.TruncateShorten(body, maxIssueIntent)
+	}
+	if extra := strings.TrimSpace(opts.Context); extra != "" {
+		intent += "\n" + extra
+	}
+	opts.Context = intent
+	opts.Conventions.Ticket = issue.Ref()
+	if opts.IssueKeywords == nil {
+		opts.IssueKeywords = commit.DefaultIssueKeywords
+	}
+	return opts
+}
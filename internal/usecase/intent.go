@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	difftext "github.com/riskibarqy/go-commitgen/internal/diff"
+)
+
+// intent combines the explicit context with the in-code markers and the
+// branch name, most deliberate source first.
+func intent(explicit string, markers []difftext.Marker, branch string) string {
+	var parts []string
+	if explicit != "" {
+		parts = append(parts, explicit)
+	}
+	if len(markers) > 0 {
+		notes := make([]string, 0, len(markers))
+		for _, m := range markers {
+			notes = append(notes, m.String())
+		}
+		parts = append(parts, "notes left in the code: "+strings.Join(notes, "; "))
+	}
+	if words := commit.BranchIntent(branch); words != "" {
+		parts = append(parts, "branch name: "+words)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// StripMarkers removes the intent markers from the staged files, and from
+// the working tree where the same line is still there, so they do not end
+// up in the commit. Markers in files that are not staged, such as the
+// untracked ones of --include-untracked, are not committed and are left
+// alone. It returns the markers that could not be removed because the
+// staged line no longer matches.
+func (s *Service) StripMarkers(ctx context.Context, markers []difftext.Marker) ([]difftext.Marker, error) {
+	stagedFiles, err := s.Repo.StagedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isStaged := make(map[string]bool, len(stagedFiles))
+	for _, f := range stagedFiles {
+		isStaged[f] = true
+	}
+
+	byFile := map[string][]difftext.Marker{}
+	var files []string
+	for _, m := range markers {
+		if !isStaged[m.File] {
+			continue
+		}
+		if _, ok := byFile[m.File]; !ok {
+			files = append(files, m.File)
+		}
+		byFile[m.File] = append(byFile[m.File], m)
+	}
+
+	root, _ := s.Repo.Root(ctx)
+	var left []difftext.Marker
+	for _, file := range files {
+		fileMarkers := byFile[file]
+		// bottom-up so deleting a line does not shift the ones still to go
+		sort.Slice(fileMarkers, func(i, j int) bool { return fileMarkers[i].Line > fileMarkers[j].Line })
+
+		staged, err := s.Repo.StagedContent(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+		content := string(staged)
+		var stripped []difftext.Marker
+		for _, m := range fileMarkers {
+			var ok bool
+			if content, ok = difftext.StripMarker(content, m); ok {
+				stripped = append(stripped, m)
+			} else {
+				left = append(left, m)
+			}
+		}
+		if len(stripped) == 0 {
+			continue
+		}
+		if err := s.Repo.UpdateStaged(ctx, file, []byte(content)); err != nil {
+			return nil, err
+		}
+		if root != "" {
+			stripWorktree(filepath.Join(root, file), stripped)
+		}
+	}
+	return left, nil
+}
+
+// stripWorktree removes the stripped markers from the working copy too,
+// locating each by its line text since unstaged edits may have moved it.
+// It is best effort: a file that diverged keeps its markers.
+func stripWorktree(path string, markers []difftext.Marker) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	content := string(data)
+	for _, m := range markers {
+		lines := strings.Split(content, "\n")
+		m.Line = 0
+		for i := len(lines) - 1; i >= 0; i-- {
+			if strings.TrimRight(lines[i], "\r") == m.Source {
+				m.Line = i + 1
+				break
+			}
+		}
+		if m.Line == 0 {
+			continue
+		}
+		content, _ = difftext.StripMarker(content, m)
+	}
+	if content == string(data) {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(path, []byte(content), info.Mode().Perm())
+}
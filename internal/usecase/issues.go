@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	"github.com/riskibarqy/go-commitgen/internal/forge"
+)
+
+// maxIssueTitle keeps issue titles readable in issue lists.
+const maxIssueTitle = 80
+
+// issuesLabel starts the body paragraph listing the follow-up issues.
+const issuesLabel = "Follow-up issues:"
+
+// rewording is implemented by repositories that can replace the message
+// of the commit just made; git.CLIRepository implements it.
+type rewording interface {
+	Reword(ctx context.Context, headline, body string) error
+}
+
+// CreateIssues opens one issue on s.Tracker per high-severity finding of
+// result (Options.CreateIssues), refers to them in the body of HEAD and
+// stores them in result.Issues. Call it once, after a successful Commit
+// of result's message; a HEAD that already lists follow-up issues is left
+// alone. Issue creation is best effort: without a tracker, or when the
+// forge refuses an issue, the finding only stays in the review.
+func (s *Service) CreateIssues(ctx context.Context, opts Options, result *Result) error {
+	if !opts.CreateIssues {
+		return nil
+	}
+	var high []Finding
+	for _, f := range result.Findings {
+		if f.Severity == "high" {
+			high = append(high, f)
+		}
+	}
+	if len(high) == 0 {
+		return nil
+	}
+	if s.Tracker == nil {
+		s.log().Warn("cannot create issues from review findings: no forge configured")
+		return nil
+	}
+	latest, err := s.Repo.Log(ctx, "HEAD", 1)
+	if err != nil {
+		return fmt.Errorf("reading the new commit: %w", err)
+	}
+	if len(latest) != 1 {
+		return errors.New("reading the new commit: no commit found")
+	}
+	head := latest[0]
+	if strings.Contains(head.Body, issuesLabel) {
+		s.log().Debug("commit already refers to follow-up issues", "commit", shortHash(head.Hash))
+		return nil
+	}
+
+	var issues []forge.Issue
+	for _, f := range high {
+		issue, err := s.Tracker.CreateIssue(ctx, issueTitle(f), issueBody(f, result.Branch, result.ReviewModel, shortHash(head.Hash)), opts.IssueLabels)
+		if err != nil {
+			s.log().Warn("creating an issue from a review finding failed", "finding", f.String(), "err", err)
+			continue
+		}
+		s.log().Info("created issue from review finding", "issue", issue.URL)
+		issues = append(issues, issue)
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	result.Issues = issues
+
+	repo, ok := s.Repo.(rewording)
+	if !ok {
+		s.log().Warn("cannot refer to the follow-up issues in the commit: the repository cannot reword it")
+		return nil
+	}
+	msg := withIssues(commit.Message{Headline: head.Subject, Body: head.Body}, issues)
+	if err := repo.Reword(ctx, msg.Headline, msg.Body); err != nil {
+		return fmt.Errorf("referring to the follow-up issues in the commit: %w", err)
+	}
+	return nil
+}
+
+// issueTitle is the finding's text, cut at a word boundary when long.
+func issueTitle(f Finding) string {
+	title := f.Text
+	if f.File != "" {
+		title = f.File + ": " + title
+	}
+	if r := []rune(title); len(r) > maxIssueTitle {
+		title = string(r[:maxIssueTitle])
+		if i := strings.LastIndex(title, " "); i > maxIssueTitle/2 {
+			title = title[:i]
+		}
+		title += "…"
+	}
+	return title
+}
+
+func issueBody(f Finding, branch, model, hash string) string {
+	var b strings.Builder
+	b.WriteString(f.Text + "\n\n")
+	if f.File != "" {
+		b.WriteString("- File: `" + f.File)
+		if f.Line > 0 {
+			b.WriteString(":" + strconv.Itoa(f.Line))
+		}
+		b.WriteString("`\n")
+	}
+	fmt.Fprintf(&b, "- Severity: %s\n", f.Severity)
+	if branch != "" {
+		fmt.Fprintf(&b, "- Branch: `%s`\n", branch)
+	}
+	fmt.Fprintf(&b, "- Commit: %s\n", hash)
+	fmt.Fprintf(&b, "\nFound by the go-commitgen review (%s) of this commit; its body links back here.\n", model)
+	return b.String()
+}
+
+// withIssues refers to the created issues in msg's body.
+func withIssues(msg commit.Message, issues []forge.Issue) commit.Message {
+	if len(issues) == 0 {
+		return msg
+	}
+	refs := make([]string, len(issues))
+	for i, issue := range issues {
+		refs[i] = issue.Ref()
+	}
+	return commit.WithSection(msg, issuesLabel+" "+strings.Join(refs, ", "))
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/prompt"
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+var logSummaryDefaults = map[string]interface{}{"temperature": 0.3, "top_p": 0.9, "num_predict": 400}
+
+// LogSummary summarises the commits in revRange as a paragraph or bullets.
+func (s *Service) LogSummary(ctx context.Context, opts Options, revRange, style string) (string, error) {
+	if strings.TrimSpace(revRange) == "" {
+		return "", fmt.Errorf("log-summary needs a commit range such as main..HEAD")
+	}
+
+	entries, err := s.Repo.Log(ctx, revRange, 200)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("no commits in %s", revRange)
+	}
+
+	commits := make([]string, 0, len(entries))
+	size := 0
+	for _, e := range entries {
+		line := "- " + e.Subject
+		if e.Body != "" {
+			line += ": " + util.TruncateShorten(util.CondenseSpaces(e.Body), 200)
+		}
+		if size += len(line); opts.MaxBytes > 0 && size > opts.MaxBytes {
+			break
+		}
+		commits = append(commits, line)
+	}
+
+	out, err := s.LLM.Generate(ctx, opts.Endpoint, newRequest(opts.Model, prompt.LogSummary(commits, style), logSummaryDefaults, opts.LLMOptions))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	difftext "github.com/riskibarqy/go-commitgen/internal/diff"
+	"github.com/riskibarqy/go-commitgen/internal/ollama"
+)
+
+// offlineNote ends the body of a message written without the model, so
+// it is not mistaken for a generated one.
+const offlineNote = "(written from file stats by go-commitgen: the model was unreachable)"
+
+// offline reports whether err means the model could not be reached and
+// opts allow falling back to a message built from file stats. An
+// interrupted generation is never replaced.
+func (s *Service) offline(ctx context.Context, opts Options, err error) bool {
+	if !opts.OfflineFallback || ctx.Err() != nil {
+		return false
+	}
+	var interrupted *ollama.Interrupted
+	if errors.As(err, &interrupted) || !ollama.Unreachable(err) {
+		return false
+	}
+	s.log().Warn("model unreachable, writing the message from file stats", "endpoint", opts.Endpoint, "err", err)
+	return true
+}
+
+// offlineFiles caps the files listed in the body of an offline message.
+const offlineFiles = 15
+
+// fileStat is what the offline message knows about a changed file.
+type fileStat struct {
+	path           string
+	status         string // "added", "deleted", "renamed" or "modified"
+	added, removed int
+}
+
+// offlineParts describes diff without a model: the commit type is guessed
+// from the kind of files touched, the description from how they changed
+// and where they live ("update 3 files in internal/ollama"), and the body
+// lists every file with its line counts.
+func offlineParts(diff string) commit.Parts {
+	var stats []fileStat
+	for _, f := range difftext.SplitFiles(diff) {
+		if f.Path == "" {
+			continue
+		}
+		st := fileStat{path: f.Path, status: "modified"}
+		for _, line := range strings.Split(f.Text, "\n") {
+			switch {
+			case strings.HasPrefix(line, "new file mode"):
+				st.status = "added"
+			case strings.HasPrefix(line, "deleted file mode"):
+				st.status = "deleted"
+			case strings.HasPrefix(line, "rename from "):
+				st.status = "renamed"
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			case strings.HasPrefix(line, "+"):
+				st.added++
+			case strings.HasPrefix(line, "-"):
+				st.removed++
+			}
+		}
+		stats = append(stats, st)
+	}
+
+	body := make([]string, 0, offlineFiles+2)
+	for i, st := range stats {
+		if i == offlineFiles {
+			body = append(body, fmt.Sprintf("- and %d more files", len(stats)-i))
+			break
+		}
+		body = append(body, fmt.Sprintf("- %s %s (+%d -%d)", st.status, st.path, st.added, st.removed))
+	}
+	body = append(body, offlineNote)
+	description := offlineDescription(stats)
+	return commit.Parts{
+		CommitType:  offlineType(stats),
+		Description: description,
+		Summary:     description,
+		Body:        strings.Join(body, "\n"),
+	}
+}
+
+// offlineDescription names the common verb and the file, or the number of
+// files and their closest common directory.
+func offlineDescription(stats []fileStat) string {
+	if len(stats) == 0 {
+		return "update project files"
+	}
+	verb := map[string]string{"added": "add", "deleted": "remove", "renamed": "rename", "modified": "update"}[stats[0].status]
+	dir := path.Dir(stats[0].path)
+	for _, st := range stats[1:] {
+		if st.status != stats[0].status {
+			verb = "update"
+		}
+		for dir != "." && !strings.HasPrefix(st.path, dir+"/") {
+			dir = path.Dir(dir)
+		}
+	}
+	if len(stats) == 1 {
+		return verb + " " + path.Base(stats[0].path)
+	}
+	if dir == "." {
+		return fmt.Sprintf("%s %d files", verb, len(stats))
+	}
+	return fmt.Sprintf("%s %d files in %s", verb, len(stats), dir)
+}
+
+// offlineType picks docs, test, ci or build when every file is of that
+// kind and chore otherwise; types the conventions lack fall back when the
+// parts are normalised.
+func offlineType(stats []fileStat) string {
+	kinds := []struct {
+		name  string
+		match func(p string) bool
+	}{
+		{"docs", func(p string) bool {
+			ext := strings.ToLower(path.Ext(p))
+			return ext == ".md" || ext == ".rst" || ext == ".adoc" || strings.HasPrefix(p, "docs/")
+		}},
+		{"test", func(p string) bool {
+			base := path.Base(p)
+			return strings.HasSuffix(base, "_test.go") || strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+				strings.HasPrefix(base, "test_") || strings.Contains("/"+p, "/testdata/")
+		}},
+		{"ci", func(p string) bool {
+			return strings.HasPrefix(p, ".github/workflows/") || p == ".gitlab-ci.yml" || strings.HasPrefix(p, ".circleci/")
+		}},
+		{"build", func(p string) bool {
+			switch path.Base(p) {
+			case "go.mod", "go.sum", "Makefile", "Dockerfile", "package.json", "package-lock.json", "Cargo.toml", "Cargo.lock":
+				return true
+			}
+			return false
+		}},
+	}
+	for _, kind := range kinds {
+		all := len(stats) > 0
+		for _, st := range stats {
+			if !kind.match(st.path) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return kind.name
+		}
+	}
+	return "chore"
+}
@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	"github.com/riskibarqy/go-commitgen/internal/prompt"
+	"github.com/riskibarqy/go-commitgen/internal/trace"
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+var polishDefaults = map[string]interface{}{"temperature": 0.0, "top_p": 0.9, "num_predict": 400}
+
+// maxPolishChange is the share of a field the proofreading pass may
+// rewrite; more means the model reworded it, and the original is kept.
+const maxPolishChange = 0.2
+
+// polish runs the proofreading pass over the description and body of msg
+// with PolishModel. It is best effort: a failed call, an unusable answer
+// or a field changed beyond typo fixes keeps the original text.
+func (s *Service) polish(ctx context.Context, opts Options, msg commit.Message) commit.Message {
+	if !opts.Polish {
+		return msg
+	}
+	model := opts.PolishModel
+	if model == "" {
+		model = opts.Model
+	}
+	prefix, description := commit.SplitHeadline(msg.Headline)
+
+	req := newRequest(model, prompt.Polish(description, msg.Body), polishDefaults, opts.LLMOptions)
+	if opts.ResponseFormat == "schema" || opts.ResponseFormat == "json" {
+		req.Format = "json"
+	}
+	callCtx, span := trace.Start(ctx, "llm.polish")
+	span.Set("llm.model", model)
+	raw, err := s.LLM.Generate(callCtx, opts.Endpoint, req)
+	span.End(err)
+	if err != nil {
+		s.log().Warn("polish failed; keeping the message as generated", "model", model, "err", err)
+		return msg
+	}
+
+	var out struct {
+		Description string `json:"description"`
+		Body        string `json:"body"`
+	}
+	if err := commit.DecodeJSON(raw, &out); err != nil {
+		s.log().Warn("polish answer is not JSON; keeping the message as generated", "model", model, "err", err)
+		return msg
+	}
+	if fixed, ok := s.polished(description, out.Description); ok {
+		msg.Headline = prefix + util.CondenseSpaces(fixed)
+	}
+	if fixed, ok := s.polished(msg.Body, out.Body); ok {
+		msg.Body = fixed
+	}
+	return msg
+}
+
+// polished reports whether fixed is a usable correction of original.
+func (s *Service) polished(original, fixed string) (string, bool) {
+	fixed = strings.TrimSpace(fixed)
+	if fixed == "" || fixed == original {
+		return original, false
+	}
+	distance := util.EditDistance(original, fixed)
+	if float64(distance) > maxPolishChange*float64(len([]rune(original))) {
+		s.log().Debug("polish rewrote too much; keeping the original", "changed", distance, "original", original)
+		return original, false
+	}
+	s.log().Debug("polished", "original", original, "fixed", fixed)
+	return fixed, true
+}
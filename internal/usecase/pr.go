@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	difftext "github.com/riskibarqy/go-commitgen/internal/diff"
+	"github.com/riskibarqy/go-commitgen/internal/forge"
+	"github.com/riskibarqy/go-commitgen/internal/prompt"
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+var describeDefaults = map[string]interface{}{"temperature": 0.3, "top_p": 0.9, "num_predict": 700}
+
+// Describe writes a pull request title and markdown description for the
+// commits of the current branch since its merge base with target.
+func (s *Service) Describe(ctx context.Context, opts Options, target string) (title, body string, err error) {
+	differ, ok := s.Repo.(branchDiffer)
+	if !ok {
+		return "", "", errors.New("describing a branch needs a git repository")
+	}
+	if err := differ.EnsureRef(ctx, target); err != nil {
+		return "", "", err
+	}
+	base, err := differ.MergeBase(ctx, target, "HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	revRange := base + "..HEAD"
+	entries, err := s.Repo.Log(ctx, revRange, 100)
+	if err != nil {
+		return "", "", err
+	}
+	if len(entries) == 0 {
+		return "", "", fmt.Errorf("no commits between %s and HEAD", target)
+	}
+	commits := make([]string, 0, len(entries))
+	for _, e := range entries {
+		line := "- " + e.Subject
+		if e.Body != "" {
+			line += ": " + util.TruncateShorten(util.CondenseSpaces(e.Body), 200)
+		}
+		commits = append(commits, line)
+	}
+
+	d, err := differ.RangeDiff(ctx, revRange, opts.Diff)
+	if err != nil {
+		return "", "", err
+	}
+	changes := s.promptDiff(ctx, opts, util.TrimTo(d, opts.MaxBytes))
+	if opts.SummarizeLarge && opts.MaxBytes > 0 && len(d) > opts.MaxBytes {
+		summaries, err := s.summarize(ctx, opts, d)
+		if err != nil {
+			return "", "", err
+		}
+		changes = strings.Join(summaries, "\n")
+	}
+
+	branch, _ := s.Repo.CurrentBranch(ctx)
+	out, err := s.LLM.Generate(ctx, opts.Endpoint, newRequest(opts.Model, prompt.PullRequest(branch, commits, changes), describeDefaults, opts.LLMOptions))
+	if err != nil {
+		return "", "", err
+	}
+	title, body = splitDescription(out)
+	if title == "" {
+		title = entries[len(entries)-1].Subject
+	}
+	return title, body, nil
+}
+
+// PublishDescription describes the current branch and updates its open
+// pull request on prs, or opens one into target's branch when there is
+// none. target defaults to the pull request's base on origin, then to
+// "origin/main". It returns the pull request's URL.
+func (s *Service) PublishDescription(ctx context.Context, opts Options, prs forge.Forge, target string) (title, body, url string, err error) {
+	branch, err := s.Repo.CurrentBranch(ctx)
+	if err != nil {
+		return "", "", "", err
+	}
+	pr, err := prs.FindPullRequest(ctx, branch)
+	exists := err == nil
+	if err != nil && !errors.Is(err, forge.ErrNoPullRequest) {
+		return "", "", "", err
+	}
+	switch {
+	case target != "":
+	case exists:
+		target = "origin/" + pr.BaseRef
+	default:
+		target = "origin/main"
+	}
+
+	title, body, err = s.Describe(ctx, opts, target)
+	if err != nil {
+		return "", "", "", err
+	}
+	if exists {
+		return title, body, pr.URL, prs.UpdatePullRequest(ctx, pr, title, body)
+	}
+	pr, err = prs.CreatePullRequest(ctx, branch, strings.TrimPrefix(target, "origin/"), title, body)
+	return title, body, pr.URL, err
+}
+
+// splitDescription separates the title line from the markdown body.
+func splitDescription(out string) (title, body string) {
+	out = strings.TrimSpace(out)
+	title, body, _ = strings.Cut(out, "\n")
+	title = strings.TrimSpace(strings.TrimLeft(title, "# "))
+	title = strings.TrimSpace(strings.TrimPrefix(title, "Title:"))
+	return title, strings.TrimSpace(body)
+}
+
+// PostReview reviews the open pull request of the current branch against
+// target (its base branch on origin when empty) and posts the findings:
+// those anchored to a changed line as line comments, the rest in the
+// review body. It returns the review and the URL of the posted comments.
+func (s *Service) PostReview(ctx context.Context, opts Options, prs forge.Forge, target string) (Result, string, error) {
+	branch, err := s.Repo.CurrentBranch(ctx)
+	if err != nil {
+		return Result{}, "", err
+	}
+	pr, err := prs.FindPullRequest(ctx, branch)
+	if err != nil {
+		return Result{}, "", err
+	}
+	if head, err := s.Repo.Log(ctx, "HEAD", 1); err == nil && len(head) == 1 && pr.HeadSHA != "" && head[0].Hash != pr.HeadSHA {
+		return Result{}, "", fmt.Errorf("pull request #%d is at %s but HEAD is %s; push first so the comments land on the reviewed lines", pr.Number, shortHash(pr.HeadSHA), shortHash(head[0].Hash))
+	}
+	if target == "" {
+		target = "origin/" + pr.BaseRef
+	}
+
+	result, err := s.ReviewAgainst(ctx, opts, target)
+	if err != nil {
+		return Result{}, "", err
+	}
+	if result.ReviewErr != nil {
+		return result, "", result.ReviewErr
+	}
+	if result.ReviewModel == "" {
+		return result, "", errors.New("review was skipped; nothing to post")
+	}
+
+	comments, rest := anchorFindings(result.Findings, result.DiffUsed)
+	body := "Review by go-commitgen (" + result.ReviewModel + ")"
+	switch {
+	case len(rest) > 0:
+		lines := make([]string, 0, len(rest))
+		for _, f := range rest {
+			lines = append(lines, "- "+f.String())
+		}
+		body += "\n\n" + strings.Join(lines, "\n")
+	case len(comments) == 0:
+		body += "\n\n" + result.Review
+	}
+	url, err := prs.PostReview(ctx, pr, body, comments)
+	return result, url, err
+}
+
+// anchorFindings turns the findings that point at a line changed in diff
+// into line comments and returns the others.
+func anchorFindings(findings []Finding, diff string) ([]forge.Comment, []Finding) {
+	ranges := map[string][]difftext.LineRange{}
+	for _, f := range difftext.SplitFiles(diff) {
+		ranges[f.Path] = difftext.ChangedRanges(f.Text)
+	}
+
+	var comments []forge.Comment
+	var rest []Finding
+	for _, f := range findings {
+		if f.File != "" && f.Line > 0 && inRanges(ranges[f.File], f.Line) {
+			comments = append(comments, forge.Comment{Path: f.File, Line: f.Line, Body: f.Text})
+			continue
+		}
+		rest = append(rest, f)
+	}
+	return comments, rest
+}
+
+func inRanges(ranges []difftext.LineRange, line int) bool {
+	for _, r := range ranges {
+		if line >= r.Start && line <= r.End {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/riskibarqy/go-commitgen/internal/git"
+)
+
+// ErrPushBlocked is returned by PushCheck when a finding reaches the
+// blocking severity; the pre-push hook then stops the push.
+var ErrPushBlocked = errors.New("push blocked by review findings")
+
+// PushUpdate is one ref git is about to push, as pre-push reads it from
+// stdin: "<local ref> <local sha> <remote ref> <remote sha>".
+type PushUpdate struct {
+	LocalRef, LocalSHA   string
+	RemoteRef, RemoteSHA string
+}
+
+// ParsePushUpdates reads the lines git passes to the pre-push hook.
+func ParsePushUpdates(r io.Reader) ([]PushUpdate, error) {
+	var updates []PushUpdate
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected pre-push line %q", scanner.Text())
+		}
+		updates = append(updates, PushUpdate{fields[0], fields[1], fields[2], fields[3]})
+	}
+	return updates, scanner.Err()
+}
+
+// zeroSHA reports git's all-zero object name, which pre-push uses for a
+// ref that does not exist on one side.
+func zeroSHA(sha string) bool {
+	return strings.Trim(sha, "0") == ""
+}
+
+// PushReview is the review of the commits one ref update sends.
+type PushReview struct {
+	Update  PushUpdate
+	Commits []git.LogEntry
+	Result  Result
+	// Blocking are the findings at or above the blocking severity.
+	Blocking []Finding
+}
+
+func (p PushReview) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s -> %s: %d outgoing commit(s)\n", p.Update.LocalRef, p.Update.RemoteRef, len(p.Commits))
+	for _, c := range p.Commits {
+		fmt.Fprintf(&b, "  %s %s\n", shortHash(c.Hash), c.Subject)
+	}
+	switch {
+	case p.Result.ReviewErr != nil:
+		fmt.Fprintf(&b, "Review failed: %v\n", p.Result.ReviewErr)
+	case p.Result.Review != "":
+		b.WriteString("Review findings:\n" + p.Result.Review + "\n")
+	}
+	return b.String()
+}
+
+// severityRank orders the finding severities; untagged findings rank
+// lowest.
+var severityRank = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// PushCheck reviews the commits each update sends to remote: those after
+// the remote's current commit, or for a new branch those after its merge
+// base with trunk on remote. With opts.BlockOn set ("high", "medium" or
+// "low") it returns ErrPushBlocked when any finding reaches that severity.
+// Deleted refs and updates sending nothing new are skipped.
+func (s *Service) PushCheck(ctx context.Context, opts Options, remote, trunk string, updates []PushUpdate) ([]PushReview, error) {
+	if s == nil || s.Repo == nil || s.LLM == nil {
+		return nil, errors.New("service not properly initialized")
+	}
+	differ, ok := s.Repo.(branchDiffer)
+	if !ok {
+		return nil, errors.New("push-check needs a git repository")
+	}
+	if opts.ReviewModel == "" {
+		opts.ReviewModel = opts.Model
+	}
+
+	var reviews []PushReview
+	blocked := 0
+	for _, u := range updates {
+		if zeroSHA(u.LocalSHA) {
+			continue
+		}
+		started := time.Now()
+		base := u.RemoteSHA
+		if zeroSHA(base) {
+			start := remote + "/" + trunk
+			if err := differ.EnsureRef(ctx, start); err != nil {
+				return reviews, fmt.Errorf("new branch %s: cannot find where it starts: %w", u.RemoteRef, err)
+			}
+			var err error
+			if base, err = differ.MergeBase(ctx, start, u.LocalSHA); err != nil {
+				return reviews, err
+			}
+		}
+		revRange := base + ".." + u.LocalSHA
+		commits, err := s.Repo.Log(ctx, revRange, 0)
+		if err != nil {
+			return reviews, err
+		}
+		if len(commits) == 0 {
+			continue
+		}
+
+		result, err := s.reviewRange(ctx, opts, differ, revRange)
+		if errors.Is(err, errNoChanges) {
+			continue
+		}
+		if err != nil {
+			return reviews, err
+		}
+		result.Branch = strings.TrimPrefix(u.LocalRef, "refs/heads/")
+		result.Elapsed = time.Since(started)
+		review := PushReview{Update: u, Commits: commits, Result: result}
+		if limit := severityRank[opts.BlockOn]; limit > 0 {
+			for _, f := range result.Findings {
+				if severityRank[f.Severity] >= limit {
+					review.Blocking = append(review.Blocking, f)
+				}
+			}
+		}
+		blocked += len(review.Blocking)
+		reviews = append(reviews, review)
+	}
+	if blocked > 0 {
+		return reviews, fmt.Errorf("%w: %d finding(s) of severity %s or higher; fix them or push with --no-verify", ErrPushBlocked, blocked, opts.BlockOn)
+	}
+	return reviews, nil
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/git"
+	"github.com/riskibarqy/go-commitgen/internal/prompt"
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+var releaseDefaults = map[string]interface{}{"temperature": 0.3, "top_p": 0.9, "num_predict": 900}
+
+// rangeDiffer is implemented by repositories that can diff a commit range;
+// without it the notes are written from the commit messages alone.
+type rangeDiffer interface {
+	RangeDiff(ctx context.Context, revRange string, opts git.DiffOptions) (string, error)
+}
+
+// ReleaseNotes writes markdown release notes for the commits since the
+// given tag or revision, for "users" or "developers".
+func (s *Service) ReleaseNotes(ctx context.Context, opts Options, since, audience string) (string, error) {
+	if strings.TrimSpace(since) == "" {
+		return "", fmt.Errorf("release-notes needs --since, e.g. --since v1.2.0")
+	}
+	revRange := since + "..HEAD"
+
+	entries, err := s.Repo.Log(ctx, revRange, 300)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("no commits since %s", since)
+	}
+
+	commits := make([]string, 0, len(entries))
+	for _, e := range entries {
+		line := "- " + e.Subject
+		if e.Body != "" {
+			line += ": " + util.TruncateShorten(util.CondenseSpaces(e.Body), 200)
+		}
+		commits = append(commits, line)
+	}
+
+	var changes string
+	if differ, ok := s.Repo.(rangeDiffer); ok {
+		d, err := differ.RangeDiff(ctx, revRange, opts.Diff)
+		if err != nil {
+			return "", err
+		}
+		changes = s.promptDiff(ctx, opts, util.TrimTo(d, opts.MaxBytes))
+		if opts.SummarizeLarge && opts.MaxBytes > 0 && len(d) > opts.MaxBytes {
+			summaries, err := s.summarize(ctx, opts, d)
+			if err != nil {
+				return "", err
+			}
+			changes = strings.Join(summaries, "\n")
+		}
+	}
+
+	out, err := s.LLM.Generate(ctx, opts.Endpoint, newRequest(opts.Model, prompt.ReleaseNotes(since, commits, changes, audience), releaseDefaults, opts.LLMOptions))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
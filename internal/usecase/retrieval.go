@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/retrieval"
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// queryBytes bounds the part of the diff embedded to find related modules.
+const queryBytes = 2000
+
+// IndexResult reports what BuildIndex did.
+type IndexResult struct {
+	Path     string
+	Entries  int
+	Embedded int
+}
+
+// BuildIndex describes the repository's directories and embeds the
+// descriptions with opts.EmbedModel, re-embedding only changed ones.
+func (s *Service) BuildIndex(ctx context.Context, opts Options) (IndexResult, error) {
+	if s == nil || s.Repo == nil || s.Embedder == nil {
+		return IndexResult{}, errors.New("service not properly initialized")
+	}
+	if opts.EmbedModel == "" {
+		return IndexResult{}, errors.New("no embedding model configured")
+	}
+	root, err := s.Repo.Root(ctx)
+	if err != nil {
+		return IndexResult{}, err
+	}
+	path := retrieval.Path(root)
+	previous, err := retrieval.Load(path)
+	if err != nil {
+		s.log().Warn("ignoring unreadable index", "path", path, "err", err)
+	}
+	idx, embedded, err := retrieval.Build(ctx, s.Embedder, opts.Endpoint, opts.EmbedModel, root, previous)
+	if err != nil {
+		return IndexResult{}, err
+	}
+	if err := retrieval.Save(path, idx); err != nil {
+		return IndexResult{}, err
+	}
+	return IndexResult{Path: path, Entries: len(idx.Entries), Embedded: embedded}, nil
+}
+
+// repoContext returns up to opts.RepoContext module descriptions: those of
+// the directories the change touches, then the ones whose embeddings are
+// closest to the change. Without an index nothing is added; retrieval only
+// sharpens the prompt, so failures are logged and skipped.
+func (s *Service) repoContext(ctx context.Context, opts Options, diff string, files []string) []string {
+	if opts.RepoContext <= 0 {
+		return nil
+	}
+	root, err := s.Repo.Root(ctx)
+	if err != nil {
+		return nil
+	}
+	idx, err := retrieval.Load(retrieval.Path(root))
+	if err != nil || len(idx.Entries) == 0 {
+		s.log().Debug("no repository index; run `go-commitgen index`", "err", err)
+		return nil
+	}
+
+	picked := idx.Touched(files)
+	if len(picked) > opts.RepoContext {
+		picked = picked[:opts.RepoContext]
+	}
+	if missing := opts.RepoContext - len(picked); missing > 0 && s.Embedder != nil {
+		query := strings.Join(files, "\n") + "\n" + util.TrimTo(diff, queryBytes)
+		vector, err := s.Embedder.Embed(ctx, opts.Endpoint, idx.Model, query)
+		if err != nil {
+			s.log().Warn("embedding the change failed", "model", idx.Model, "err", err)
+		} else {
+			picked = append(picked, idx.Nearest(vector, missing, picked)...)
+		}
+	}
+
+	out := make([]string, 0, len(picked))
+	for _, e := range picked {
+		out = append(out, e.Dir+": "+e.Summary)
+	}
+	return out
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	difftext "github.com/riskibarqy/go-commitgen/internal/diff"
+	"github.com/riskibarqy/go-commitgen/internal/git"
+	"github.com/riskibarqy/go-commitgen/internal/linter"
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// branchDiffer is what reviewing a whole branch needs beyond the
+// Repository interface; git.CLIRepository implements it.
+type branchDiffer interface {
+	EnsureRef(ctx context.Context, ref string) error
+	MergeBase(ctx context.Context, a, b string) (string, error)
+	RangeDiff(ctx context.Context, revRange string, opts git.DiffOptions) (string, error)
+	RangeFiles(ctx context.Context, revRange string) ([]string, error)
+}
+
+// ReviewAgainst reviews everything the current branch changes relative to
+// target (e.g. "origin/main"), from their merge base to HEAD, as a pre-PR
+// check. A remote target missing locally is fetched first.
+func (s *Service) ReviewAgainst(ctx context.Context, opts Options, target string) (Result, error) {
+	if s == nil || s.Repo == nil || s.LLM == nil {
+		return Result{}, errors.New("service not properly initialized")
+	}
+	differ, ok := s.Repo.(branchDiffer)
+	if !ok {
+		return Result{}, errors.New("review --against needs a git repository")
+	}
+	if opts.ReviewModel == "" {
+		opts.ReviewModel = opts.Model
+	}
+	started := time.Now()
+
+	if err := differ.EnsureRef(ctx, target); err != nil {
+		return Result{}, err
+	}
+	base, err := differ.MergeBase(ctx, target, "HEAD")
+	if err != nil {
+		return Result{}, err
+	}
+	result, err := s.reviewRange(ctx, opts, differ, base+"..HEAD")
+	if errors.Is(err, errNoChanges) {
+		return Result{}, fmt.Errorf("no changes between %s and HEAD", target)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	if branch, err := s.Repo.CurrentBranch(ctx); err == nil {
+		result.Branch = branch
+	}
+	result.Elapsed = time.Since(started)
+	return result, nil
+}
+
+// reviewRange reviews the changes of revRange, or returns errNoChanges
+// when it changes nothing.
+func (s *Service) reviewRange(ctx context.Context, opts Options, differ branchDiffer, revRange string) (Result, error) {
+	diff, err := differ.RangeDiff(ctx, revRange, opts.Diff)
+	if err != nil {
+		return Result{}, err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return Result{}, errNoChanges
+	}
+	files, err := differ.RangeFiles(ctx, revRange)
+	if err != nil {
+		return Result{}, err
+	}
+	if opts.MoveMinLines > 0 {
+		diff, _ = difftext.DetectMoves(diff, opts.MoveMinLines)
+	}
+	diff = util.TrimTo(diff, opts.MaxBytes)
+
+	result := Result{DiffUsed: diff}
+	ownerHints := s.codeOwners(ctx, files, &result)
+	opts.Review = true
+	s.review(ctx, opts, diff, files, ownerHints, &result)
+	return result, nil
+}
+
+// sensitivePath matches files whose changes deserve the strongest reviewer.
+var sensitivePath = regexp.MustCompile(`(?i)(auth|crypto|cipher|password|passwd|secret|token|session|permission|acl|oauth|jwt|tls|cert|sql|migration)|\.sql$`)
+
+// reviewPlan decides whether and with which model the review runs, keeping
+// latency proportional to risk. reason explains any deviation from the
+// configured review model.
+func reviewPlan(opts Options, diff string, files []string) (model string, run bool, reason string) {
+	if !opts.Review {
+		return "", false, ""
+	}
+
+	for _, f := range files {
+		if sensitivePath.MatchString(f) {
+			if opts.EscalationModel != "" {
+				return opts.EscalationModel, true, "security-sensitive path " + f
+			}
+			return opts.ReviewModel, true, ""
+		}
+	}
+
+	if opts.LargeDiffBytes > 0 && len(diff) >= opts.LargeDiffBytes && opts.EscalationModel != "" {
+		return opts.EscalationModel, true, "large diff"
+	}
+
+	if opts.SmallDiffBytes > 0 && len(diff) < opts.SmallDiffBytes {
+		if opts.SmallReviewModel != "" {
+			return opts.SmallReviewModel, true, "small diff"
+		}
+		if opts.SkipSmallReviews {
+			return "", false, "small diff, review skipped"
+		}
+	}
+
+	return opts.ReviewModel, true, ""
+}
+
+func (s *Service) runLinters(ctx context.Context, opts Options, files []string) []linter.Finding {
+	if len(opts.Linters) == 0 || len(files) == 0 {
+		return nil
+	}
+	runner := s.Linters
+	if runner == nil {
+		root, err := s.Repo.Root(ctx)
+		if err != nil {
+			return nil
+		}
+		runner = linter.ShellRunner{Dir: root}
+	}
+	return runner.Run(ctx, opts.Linters, files)
+}
+
+func findingLines(findings []linter.Finding) []string {
+	var lines []string
+	for _, f := range findings {
+		for _, line := range f.Lines {
+			lines = append(lines, "["+f.Linter+"] "+line)
+		}
+	}
+	return lines
+}
+
+func gapLines(gaps []difftext.Gap) []string {
+	lines := make([]string, 0, len(gaps))
+	for _, g := range gaps {
+		lines = append(lines, g.Symbol+" in "+g.File)
+	}
+	return lines
+}
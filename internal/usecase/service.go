@@ -3,12 +3,25 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/riskibarqy/go-commitgen/internal/codeowners"
 	"github.com/riskibarqy/go-commitgen/internal/commit"
+	difftext "github.com/riskibarqy/go-commitgen/internal/diff"
+	"github.com/riskibarqy/go-commitgen/internal/enrich"
+	"github.com/riskibarqy/go-commitgen/internal/forge"
 	"github.com/riskibarqy/go-commitgen/internal/git"
+	"github.com/riskibarqy/go-commitgen/internal/linter"
+	"github.com/riskibarqy/go-commitgen/internal/logging"
 	"github.com/riskibarqy/go-commitgen/internal/ollama"
+	"github.com/riskibarqy/go-commitgen/internal/postprocess"
 	"github.com/riskibarqy/go-commitgen/internal/prompt"
+	"github.com/riskibarqy/go-commitgen/internal/retrieval"
+	"github.com/riskibarqy/go-commitgen/internal/trace"
 	"github.com/riskibarqy/go-commitgen/internal/util"
 )
 
@@ -21,6 +34,25 @@ type LLMClient interface {
 type Service struct {
 	Repo git.Repository
 	LLM  LLMClient
+	// Linters runs external linters for the review; nil uses the shell
+	// in the repository root.
+	Linters linter.Runner
+	// Log receives debug and warning records; nil discards them.
+	Log *slog.Logger
+	// Tracker receives the issues opened for high-severity review findings
+	// (Options.CreateIssues); usually the client returned by Forge.
+	Tracker forge.Forge
+	// Tickets reads the tracker tickets `from-issue` commits against; nil
+	// limits it to forge issues read from Tracker.
+	Tickets TicketReader
+	// Embedder embeds the change for repository context retrieval; nil
+	// limits the context to the modules the change touches. Setting it to
+	// the *ollama.Client used as LLM, as Models usually is, keeps every
+	// call on one pool of keep-alive connections.
+	Embedder retrieval.Embedder
+	// Models lists the endpoint's models for Options.AutoModels; nil keeps
+	// Options.Model.
+	Models ModelLister
 }
 
 // Result captures the outputs of the use case.
@@ -28,17 +60,231 @@ type Result struct {
 	Review    string
 	ReviewErr error
 	Message   commit.Message
-	DiffUsed  string
-	Branch    string
+	// Parts is the normalised model answer Message was built from; merge
+	// messages leave it empty.
+	Parts    commit.Parts
+	DiffUsed string
+	Branch   string
+	// Violations lists lint rules the final model answer still broke after
+	// all retries; they are fixed up by normalisation before building Message.
+	Violations []commit.Violation
+	// StyleFixes lists what the style rules changed in the built message.
+	StyleFixes []commit.Violation
+	Attempts   int
+	// Owners is the sorted set of CODEOWNERS entries owning the staged
+	// paths, for tagging reviewers.
+	Owners []string
+	// StaticFindings holds the raw output of the external linters.
+	StaticFindings []linter.Finding
+	// TestGaps are the heuristic test-gap candidates given to the reviewer.
+	TestGaps []difftext.Gap
+	// ReviewModel is the model that actually reviewed and ReviewNote why
+	// it differs from the configured one (or why the review was skipped).
+	ReviewModel string
+	ReviewNote  string
+	// Findings are the review's "- " lines, with the file and line they
+	// point at when the reviewer gave one.
+	Findings []Finding
+	// Elapsed is the wall time spent generating, for latency stats.
+	Elapsed time.Duration
+	// Offline is set when the model was unreachable and the message was
+	// written from file stats (Options.OfflineFallback).
+	Offline bool
+	// Candidates holds every model's answer of an ensemble run
+	// (Options.Models), in the order of the models.
+	Candidates []Candidate
+	// Score is the critic's verdict on the message (Options.Critic) and
+	// Regenerations how often a low score sent it back to the model.
+	Score         *Score
+	Regenerations int
+	// Issues are the follow-up issues opened for review findings by
+	// CreateIssues once the message is committed.
+	Issues []forge.Issue
+	// Markers are the TODO(commit)/WHY comments read as intent; the ones
+	// still present in the staged files, when stripping was not requested
+	// or failed, so the caller can offer to remove them.
+	Markers []difftext.Marker
+	// Truncation is set when the model saw only part of the diff.
+	Truncation *Truncation
+}
+
+var errNoChanges = errors.New("no staged changes detected")
+
+// NoChanges reports whether err means there was nothing staged to describe.
+func NoChanges(err error) bool {
+	return errors.Is(err, errNoChanges)
 }
 
+var (
+	reviewDefaults    = map[string]interface{}{"temperature": 0.1, "top_p": 0.9, "num_predict": 200}
+	commitDefaults    = map[string]interface{}{"temperature": 0.2, "top_p": 0.9, "num_predict": 120}
+	summarizeDefaults = map[string]interface{}{"temperature": 0.1, "top_p": 0.9, "num_predict": 300}
+	mergeDefaults     = map[string]interface{}{"temperature": 0.2, "top_p": 0.9, "num_predict": 250}
+	repairDefaults    = map[string]interface{}{"temperature": 0.0, "top_p": 0.9, "num_predict": 400}
+)
+
 // Options is a light copy of the config options needed inside the use case.
 type Options struct {
 	Model       string
 	ReviewModel string
+	// Models asks several models at once; Judge, when set, picks or
+	// merges the best answer, otherwise the first model's answer is used
+	// and the others are offered as Result.Candidates.
+	Models      []string
+	Judge       string
 	Endpoint    string
 	MaxBytes    int
 	Review      bool
+	LintRetries int
+	// HistoryDepth is how many recent commit subjects touching the staged
+	// files are given to the model; zero disables the lookup.
+	HistoryDepth int
+	// RepeatCheck is how many of the latest commits on the branch the new
+	// description is compared against; a near-duplicate is sent back to
+	// the model like a lint violation. Zero disables the check.
+	RepeatCheck int
+	// Denylist holds phrases (vague wording, internal codenames) the
+	// description must not contain; a match is re-prompted like a lint
+	// violation, and with DenyFail an answer still matching after the
+	// retries is an error instead of being used.
+	Denylist []string
+	DenyFail bool
+	// IncludeUntracked appends untracked files to the diff, each cut to
+	// UntrackedMaxBytes.
+	IncludeUntracked  bool
+	UntrackedMaxBytes int
+	// LLMOptions overrides the per-call sampling defaults (temperature,
+	// top_p, num_predict, seed or any other provider option).
+	LLMOptions map[string]interface{}
+	// SummarizeLarge switches to a map-reduce pipeline when the diff exceeds
+	// MaxBytes: chunks are summarised first and the message is written from
+	// the summaries instead of a truncated diff.
+	SummarizeLarge bool
+	Diff           git.DiffOptions
+	// MoveMinLines is the minimum size of a block reported as moved code
+	// instead of shown as removed and re-added; zero disables detection.
+	MoveMinLines int
+	// Noise handles import-only, formatting-only and comment-only hunks:
+	// "summarize" replaces them with one line per file, "drop" removes
+	// them and anything else keeps them in the diff.
+	Noise string
+	// MinifyDiff sends the model a denser diff (see difftext.Minify); the
+	// diff kept in Result and used for line numbers is unchanged.
+	MinifyDiff bool
+	// AutoModels replaces Model with the largest of them that fits in
+	// GPUMemory bytes of GPU memory, less what other loaded models hold.
+	AutoModels []string
+	GPUMemory  int64
+	// HookSource is the commit source passed to prepare-commit-msg
+	// ("merge", "message", ...); "merge" forces merge handling.
+	HookSource string
+	// PostProcessors are shell commands that receive the message as JSON on
+	// stdin and print the rewritten message, applied in order.
+	PostProcessors []string
+	// IssueKeywords maps commit types to the keyword appended with the
+	// branch's issue reference ("Fixes #123"); nil disables the trailer.
+	IssueKeywords map[string]string
+	// Conventions carries the commit type taxonomy; the zero value uses
+	// the built-in types.
+	Conventions commit.Conventions
+	// Adaptive review: diffs under SmallDiffBytes skip the review (or use
+	// SmallReviewModel), large or security-sensitive ones use EscalationModel.
+	SkipSmallReviews bool
+	SmallDiffBytes   int
+	SmallReviewModel string
+	LargeDiffBytes   int
+	EscalationModel  string
+	// GoSymbols adds the Go functions/types touched by the diff to the prompt.
+	GoSymbols bool
+	// BlameContext names the commits that last changed the lines the
+	// staged hunks replace, so the message can relate to their intent.
+	BlameContext bool
+	// FewShot adds up to that many accepted messages of similar past
+	// changes from the library at ExamplesFile to the prompt.
+	FewShot      int
+	ExamplesFile string
+	// Tone names one of prompt.Tones, adjusting the body length, voice
+	// and token budget; "" keeps the default prompt.
+	Tone string
+	// Polish runs a proofreading pass over the finished description and
+	// body with PolishModel (default Model), fixing typos and grammar.
+	Polish      bool
+	PolishModel string
+	// RepoContext adds up to that many module descriptions from the
+	// repository index (built by BuildIndex with EmbedModel) to the prompt.
+	RepoContext int
+	EmbedModel  string
+	// ScopesFromDirs uses the repository's top-level directories as the
+	// scope list when Conventions has none.
+	ScopesFromDirs bool
+	// ResponseFormat constrains the commit answer: "schema" sends the Parts
+	// JSON schema, "json" plain JSON mode, anything else leaves it free.
+	ResponseFormat string
+	// Linters are run on the staged files during review and their output is
+	// merged into the review.
+	Linters []linter.Linter
+	// IntentMarkers reads "TODO(commit):" / "WHY:" comments added by the
+	// diff and the words of the branch name as extra author intent;
+	// StripMarkers removes the markers from the staged files afterwards.
+	IntentMarkers bool
+	StripMarkers  bool
+	// Context is the author's intent, given to the model as the why of
+	// the change. AllowEmpty generates a message from it alone when nothing
+	// is staged, for trigger commits and release markers.
+	Context    string
+	AllowEmpty bool
+	// Strict fails instead of using an answer that still breaks the
+	// conventions after LintRetries (or is not JSON at all), which would
+	// otherwise be fixed up heuristically.
+	Strict bool
+	// RepairJSON sends an answer that is not valid JSON, even after the
+	// local repairs, back to the model once to fix its syntax before it
+	// counts as a format violation.
+	RepairJSON bool
+	// OfflineFallback writes a message from file stats, marked as such,
+	// when the model cannot be reached, instead of failing the commit.
+	OfflineFallback bool
+	// RequireSignoff appends a Signed-off-by trailer (Developer Certificate
+	// of Origin) for Signoff, the "Name <email>" identity, which defaults
+	// to the repository's committer.
+	RequireSignoff bool
+	Signoff        string
+	// Trailers are trailer templates ("Build: {{.CI.Build}}") added to
+	// every message; see TrailerData for what they can reference.
+	Trailers []string
+	// FollowUps carries the review findings into the body: "section" as a
+	// "Known issues / follow-ups" paragraph, "trailers" as TODO trailers;
+	// anything else leaves them in the printed review only.
+	FollowUps string
+	// CreateIssues lets Service.CreateIssues open an issue with IssueLabels
+	// on Service.Tracker for every high-severity review finding of the
+	// commit and refer to them in its body.
+	CreateIssues bool
+	IssueLabels  []string
+	// BlockOn is the lowest review finding severity ("high", "medium" or
+	// "low") that makes PushCheck stop the push; "" never blocks.
+	BlockOn string
+	// Critic scores the message against the diff with CriticModel
+	// (default Model) and regenerates with its notes, up to CriticRetries
+	// times, while the lowest score is under CriticThreshold (1-10).
+	Critic          bool
+	CriticModel     string
+	CriticThreshold int
+	CriticRetries   int
+	// ReuseContext keeps the context tokens of the review when the review
+	// and commit models are the same, so the commit call continues from
+	// them instead of sending the diff a second time.
+	ReuseContext bool
+	// Force generates even while a merge, rebase, cherry-pick or revert
+	// has unresolved conflicts, instead of refusing.
+	Force bool
+	// Feedback regenerates with the user's requested changes to Previous,
+	// the message they rejected.
+	Feedback string
+	Previous string
+
+	// session holds the review's context tokens for ReuseContext.
+	session *ollama.Session
 }
 
 // NewService constructs a Service with the provided dependencies.
@@ -46,65 +292,669 @@ func NewService(repo git.Repository, llm LLMClient) *Service {
 	return &Service{Repo: repo, LLM: llm}
 }
 
+func (s *Service) log() *slog.Logger {
+	return logging.Or(s.Log)
+}
+
 // Execute performs the review+generation workflow.
 func (s *Service) Execute(ctx context.Context, opts Options) (Result, error) {
+	ctx, span := trace.Start(ctx, "commitgen.execute")
+	result, err := s.execute(ctx, opts)
+	span.Set("commitgen.model", opts.Model)
+	span.Set("commitgen.attempts", result.Attempts)
+	span.End(err)
+	return result, err
+}
+
+func (s *Service) execute(ctx context.Context, opts Options) (Result, error) {
 	if s == nil || s.Repo == nil || s.LLM == nil {
 		return Result{}, errors.New("service not properly initialized")
 	}
 
+	opts.Model = s.pickModel(ctx, opts)
 	if opts.ReviewModel == "" {
 		opts.ReviewModel = opts.Model
 	}
-
-	diff, err := s.Repo.StagedDiff(ctx)
+	if opts.ScopesFromDirs && len(opts.Conventions.Scopes) == 0 {
+		opts.Conventions.Scopes = s.topLevelScopes(ctx)
+	}
+	if t := tone(opts); t.MaxBody > 0 && opts.Conventions.MaxBody == 0 {
+		opts.Conventions.MaxBody = t.MaxBody
+	}
+	opts, err := s.withSignoff(ctx, opts)
 	if err != nil {
 		return Result{}, err
 	}
-	if strings.TrimSpace(diff) == "" {
-		return Result{}, errors.New("no staged changes detected")
-	}
+	started := time.Now()
 
-	diff = util.TrimTo(diff, opts.MaxBytes)
+	diff, fullDiff, moves, noise, err := s.stagedDiff(ctx, opts)
+	if errors.Is(err, errNoChanges) && opts.AllowEmpty {
+		return s.emptyCommit(ctx, opts, started)
+	}
+	if err != nil {
+		return Result{}, err
+	}
 
 	branch, err := s.Repo.CurrentBranch(ctx)
 	if err != nil {
 		return Result{}, err
 	}
+	opts.Conventions = opts.Conventions.FitHeadline(branch)
+	s.log().Debug("staged diff", "branch", branch, "bytes", len(diff), "full_bytes", len(fullDiff), "moves", len(moves))
 
 	result := Result{
 		DiffUsed: diff,
 		Branch:   branch,
 	}
 
-	if opts.Review {
-		review, err := s.LLM.Generate(ctx, opts.Endpoint, ollama.Request{
-			Model:   opts.ReviewModel,
-			Prompt:  prompt.Review(diff),
-			Stream:  true,
-			Options: map[string]interface{}{"temperature": 0.1, "top_p": 0.9, "num_predict": 200},
-		})
+	merge, err := s.Repo.MergeState(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	if err := s.checkConflicts(opts, merge); err != nil {
+		return Result{}, err
+	}
+	if merge.InProgress || opts.HookSource == "merge" {
+		msg, err := s.mergeMessage(ctx, opts, merge, diff, &result)
 		if err != nil {
-			result.ReviewErr = err
-		} else {
-			result.Review = strings.TrimSpace(review)
+			return Result{}, err
 		}
+		if err := s.finish(ctx, opts, msg, &result); err != nil {
+			return Result{}, err
+		}
+		result.Elapsed = time.Since(started)
+		return result, nil
 	}
 
-	raw, err := s.LLM.Generate(ctx, opts.Endpoint, ollama.Request{
-		Model:   opts.Model,
-		Prompt:  prompt.Commit(diff, branch),
-		Stream:  true,
-		Options: map[string]interface{}{"temperature": 0.2, "top_p": 0.9, "num_predict": 120},
-	})
+	files, _ := s.Repo.StagedFiles(ctx)
+	ownerHints := s.codeOwners(ctx, files, &result)
+
+	if opts.ReuseContext {
+		opts.session = &ollama.Session{}
+	}
+	s.review(ctx, opts, diff, files, ownerHints, &result)
+
+	input := prompt.CommitInput{
+		Diff:          s.promptDiff(ctx, opts, diff),
+		Branch:        branch,
+		RecentCommits: s.recentCommits(ctx, files, opts.HistoryDepth),
+		Moves:         moves,
+		Noise:         noise,
+		Touched:       s.touchedSymbols(ctx, opts, diff),
+		Blame:         s.blameContext(ctx, opts, diff),
+		Types:         opts.Conventions.AllowedTypes(),
+		Intent:        strings.TrimSpace(opts.Context),
+		Sections:      opts.Conventions.Sections,
+		Examples:      s.fewShot(ctx, opts, diff),
+		RepoContext:   s.repoContext(ctx, opts, diff, files),
+		Scopes:        opts.Conventions.Scopes,
+		Submodules:    s.submoduleNotes(ctx, fullDiff),
+		Operation:     operationNote(merge),
+		Tone:          tone(opts),
+		Limits:        limits(opts),
+	}
+	if opts.IntentMarkers {
+		result.Markers = difftext.Markers(fullDiff)
+		input.Intent = intent(input.Intent, result.Markers, branch)
+	}
+	if opts.SummarizeLarge && opts.MaxBytes > 0 && len(fullDiff) > opts.MaxBytes {
+		summaries, err := s.summarize(ctx, opts, fullDiff)
+		switch {
+		case err == nil:
+			input.Summaries = summaries
+		case s.offline(ctx, opts, err):
+			result.Offline = true
+		default:
+			return Result{}, err
+		}
+	}
+	// summaries cover the whole diff; only a cut diff misses files
+	if t := truncation(fullDiff, diff); t != nil && len(input.Summaries) == 0 {
+		result.Truncation = t
+		s.log().Info("diff truncated for the model", "bytes", t.Bytes, "sent", t.Sent, "max_bytes", opts.MaxBytes, "dropped", len(t.Dropped), "partial", t.Partial)
+	}
+
+	var parts commit.Parts
+	if !result.Offline {
+		previous := s.branchSubjects(ctx, opts.RepeatCheck)
+		parts, err = s.ensemble(ctx, opts, input, previous, &result)
+		if err != nil && !s.offline(ctx, opts, err) {
+			return Result{}, err
+		}
+		result.Offline = err != nil
+		if !result.Offline {
+			parts = s.critique(ctx, opts, input, previous, parts, &result)
+		}
+	}
+	var msg commit.Message
+	if result.Offline {
+		parts = opts.Conventions.NormaliseParts(offlineParts(fullDiff))
+		msg = opts.Conventions.BuildMessage(branch, parts)
+	} else {
+		msg = s.polish(ctx, opts, opts.Conventions.BuildMessage(branch, parts))
+	}
+	result.Parts = parts
+	msg, result.StyleFixes = s.applyStyle(opts, msg)
+	msg = withFollowUps(opts, msg, result.Findings)
+	if opts.IssueKeywords != nil {
+		msg = opts.Conventions.WithIssueKeyword(msg, branch, parts.CommitType, opts.IssueKeywords)
+	}
+
+	if err := s.finish(ctx, opts, msg, &result); err != nil {
+		return Result{}, err
+	}
+	if opts.StripMarkers && len(result.Markers) > 0 {
+		if result.Markers, err = s.StripMarkers(ctx, result.Markers); err != nil {
+			return Result{}, err
+		}
+	}
+	result.Elapsed = time.Since(started)
+	return result, nil
+}
+
+// emptyCommit writes the message for a commit without changes from the
+// author's context, since there is no diff to describe.
+func (s *Service) emptyCommit(ctx context.Context, opts Options, started time.Time) (Result, error) {
+	if strings.TrimSpace(opts.Context) == "" {
+		return Result{}, errors.New("nothing staged: an empty commit needs --context describing why it is made")
+	}
+	branch, err := s.Repo.CurrentBranch(ctx)
 	if err != nil {
 		return Result{}, err
 	}
+	opts.Conventions = opts.Conventions.FitHeadline(branch)
 
-	parts, err := commit.ParseParts(raw)
+	result := Result{Branch: branch}
+	input := prompt.CommitInput{
+		Branch:   branch,
+		Types:    opts.Conventions.AllowedTypes(),
+		Intent:   strings.TrimSpace(opts.Context),
+		Sections: opts.Conventions.Sections,
+		Tone:     tone(opts),
+		Limits:   limits(opts),
+	}
+	parts, err := s.generateParts(ctx, opts, input, s.branchSubjects(ctx, opts.RepeatCheck), &result)
 	if err != nil {
-		parts = commit.FallbackParts(raw)
+		return Result{}, err
 	}
 
-	result.Message = commit.BuildMessage(branch, parts)
+	result.Parts = parts
+	msg, fixes := s.applyStyle(opts, s.polish(ctx, opts, opts.Conventions.BuildMessage(branch, parts)))
+	result.StyleFixes = fixes
+	if err := s.finish(ctx, opts, msg, &result); err != nil {
+		return Result{}, err
+	}
+	result.Elapsed = time.Since(started)
 	return result, nil
 }
+
+// Review runs only the review pass over the staged changes, for callers
+// that want feedback without a commit message.
+func (s *Service) Review(ctx context.Context, opts Options) (Result, error) {
+	if s == nil || s.Repo == nil || s.LLM == nil {
+		return Result{}, errors.New("service not properly initialized")
+	}
+	if opts.ReviewModel == "" {
+		opts.ReviewModel = opts.Model
+	}
+	started := time.Now()
+
+	diff, _, _, _, err := s.stagedDiff(ctx, opts)
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{DiffUsed: diff}
+	files, _ := s.Repo.StagedFiles(ctx)
+	ownerHints := s.codeOwners(ctx, files, &result)
+	s.review(ctx, opts, diff, files, ownerHints, &result)
+	result.Elapsed = time.Since(started)
+	return result, nil
+}
+
+// stagedDiff loads the staged diff, replaces moved blocks with move notes,
+// appends untracked files and trims it to opts.MaxBytes. The untrimmed diff
+// is returned too for summarisation.
+func (s *Service) stagedDiff(ctx context.Context, opts Options) (diff, fullDiff string, moves, noise []string, err error) {
+	readCtx, span := trace.Start(ctx, "diff.read")
+	diff, err = s.Repo.StagedDiff(readCtx, opts.Diff)
+	span.Set("diff.bytes", len(diff))
+	span.End(err)
+	if err != nil {
+		return "", "", nil, nil, err
+	}
+
+	if opts.MoveMinLines > 0 {
+		var detected []difftext.Move
+		diff, detected = difftext.DetectMoves(diff, opts.MoveMinLines)
+		for _, m := range detected {
+			moves = append(moves, m.String())
+		}
+	}
+
+	if opts.Noise == "summarize" || opts.Noise == "drop" {
+		var collapsed []difftext.Noise
+		diff, collapsed = difftext.CollapseNoise(diff)
+		if opts.Noise == "summarize" {
+			for _, n := range collapsed {
+				noise = append(noise, n.String())
+			}
+		}
+		s.log().Debug("collapsed noise hunks", "files", len(collapsed))
+	}
+
+	if opts.IncludeUntracked {
+		untracked, err := s.Repo.UntrackedDiff(ctx, opts.UntrackedMaxBytes)
+		if err != nil {
+			return "", "", nil, nil, err
+		}
+		diff += untracked
+	}
+	// untracked files alone are worth describing
+	if strings.TrimSpace(diff) == "" {
+		return "", "", nil, nil, errNoChanges
+	}
+
+	_, span = trace.Start(ctx, "diff.trim")
+	trimmed := util.TrimTo(diff, opts.MaxBytes)
+	span.Set("diff.bytes", len(diff))
+	span.Set("diff.trimmed_bytes", len(trimmed))
+	span.End(nil)
+	return trimmed, diff, moves, noise, nil
+}
+
+// minifyLineBytes is where difftext.Minify cuts long lines.
+const minifyLineBytes = 240
+
+// promptDiff returns diff as the model should see it: minified when
+// opts.MinifyDiff is set.
+func (s *Service) promptDiff(ctx context.Context, opts Options, diff string) string {
+	if !opts.MinifyDiff {
+		return diff
+	}
+	_, span := trace.Start(ctx, "diff.minify")
+	minified := difftext.Minify(diff, minifyLineBytes)
+	span.Set("diff.bytes", len(diff))
+	span.Set("diff.minified_bytes", len(minified))
+	span.End(nil)
+	s.log().Debug("minified diff", "bytes", len(diff), "minified_bytes", len(minified))
+	return minified
+}
+
+// review runs the planned review, recording its outcome on result. A failed
+// model call is kept in ReviewErr rather than aborting the generation.
+func (s *Service) review(ctx context.Context, opts Options, diff string, files, ownerHints []string, result *Result) {
+	reviewModel, runReview, note := reviewPlan(opts, diff, files)
+	result.ReviewNote = note
+	if !runReview {
+		s.log().Debug("review skipped", "reason", note)
+		return
+	}
+	s.log().Debug("review", "model", reviewModel, "note", note)
+	result.ReviewModel = reviewModel
+	result.StaticFindings = s.runLinters(ctx, opts, files)
+	result.TestGaps = difftext.TestGaps(diff)
+	reviewPrompt := prompt.Review(prompt.ReviewInput{
+		Diff:           s.promptDiff(ctx, opts, diff),
+		Owners:         ownerHints,
+		StaticFindings: findingLines(result.StaticFindings),
+		TestGaps:       gapLines(result.TestGaps),
+	})
+	callCtx, span := trace.Start(ctx, "llm.review")
+	span.Set("llm.model", reviewModel)
+	if opts.session != nil {
+		callCtx = ollama.WithSession(callCtx, opts.session)
+	}
+	review, err := s.LLM.Generate(callCtx, opts.Endpoint, newRequest(reviewModel, reviewPrompt, reviewDefaults, opts.LLMOptions))
+	span.End(err)
+	if err != nil {
+		s.log().Warn("review failed", "model", reviewModel, "err", err)
+		result.ReviewErr = err
+		return
+	}
+	result.Review = strings.TrimSpace(review)
+	result.Findings = Findings(result.Review)
+}
+
+// Commit records msg in the repository unless ctx was cancelled in the
+// meantime, so an interrupt between generation and confirmation never
+// creates a commit. Once started the commit runs to completion: killing git
+// halfway would leave a stale index.lock behind.
+func (s *Service) Commit(ctx context.Context, msg commit.Message) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("commit aborted: %w", err)
+	}
+	ctx, span := trace.Start(context.WithoutCancel(ctx), "git.commit")
+	err := s.Repo.Commit(ctx, msg.Headline, msg.Body)
+	span.End(err)
+	return err
+}
+
+// applyStyle runs the style rules over the built message, before the
+// issue keyword and post-processors add text the rules do not own.
+func (s *Service) applyStyle(opts Options, msg commit.Message) (commit.Message, []commit.Violation) {
+	msg, fixes := opts.Conventions.Style.Apply(msg)
+	for _, fix := range fixes {
+		s.log().Debug("style fix", "rule", fix.Rule, "fix", fix.Message)
+	}
+	return msg, fixes
+}
+
+func postProcess(ctx context.Context, opts Options, msg commit.Message) (commit.Message, error) {
+	chain := make(postprocess.Chain, 0, len(opts.PostProcessors))
+	for _, command := range opts.PostProcessors {
+		chain = append(chain, postprocess.Exec{Command: command})
+	}
+	return chain.Process(ctx, msg)
+}
+
+// generateParts asks the model for commit parts and re-prompts it with the
+// lint violations of its previous answer up to opts.LintRetries times. An
+// answer repeating one of the previous subjects counts as a violation.
+func (s *Service) generateParts(ctx context.Context, opts Options, input prompt.CommitInput, previous []string, result *Result) (commit.Parts, error) {
+	// continue from the review of the same diff instead of sending it again
+	var reuse []int
+	if len(input.Summaries) == 0 {
+		if reuse = opts.session.Tokens(opts.Model); reuse != nil {
+			input.DiffInContext = true
+			s.log().Debug("continuing from the review's context", "model", opts.Model, "tokens", len(reuse))
+		}
+	}
+	promptText := prompt.Commit(input)
+	if opts.Feedback != "" {
+		promptText = prompt.CommitFeedback(input, opts.Previous, opts.Feedback)
+	}
+	// the model is asked to fix its JSON once per message, not per attempt
+	repaired := false
+	for attempt := 0; ; attempt++ {
+		req := newRequest(opts.Model, promptText, llmOptions(commitDefaults, toneOptions(opts)), opts.LLMOptions)
+		req.Format = responseFormat(opts)
+		req.Context = reuse
+		callCtx, span := trace.Start(ctx, "llm.generate")
+		span.Set("llm.model", opts.Model)
+		span.Set("llm.attempt", attempt+1)
+		raw, err := s.LLM.Generate(callCtx, opts.Endpoint, req)
+		span.End(err)
+		if err != nil {
+			return commit.Parts{}, err
+		}
+		result.Attempts = attempt + 1
+
+		_, span = trace.Start(ctx, "commit.parse")
+		parts, err := commit.DecodeParts(raw)
+		if err != nil && opts.RepairJSON && !repaired {
+			repaired = true
+			if fixed, fixedParts, repairErr := s.repairJSON(ctx, opts, raw, err); repairErr == nil {
+				raw, parts, err = fixed, fixedParts, nil
+				span.Set("commit.repaired", true)
+			}
+		}
+		var violations []commit.Violation
+		if err != nil {
+			violations = []commit.Violation{{Rule: "format", Message: "response was not a valid JSON object: " + err.Error()}}
+		} else {
+			violations = opts.Conventions.Lint(parts)
+			if subject := commit.Repeats(parts.Description, previous); subject != "" {
+				violations = append(violations, commit.RepeatViolation(subject))
+			}
+			if term := commit.Denied(parts.Description, opts.Denylist); term != "" {
+				violations = append(violations, commit.DenyViolation(term))
+			}
+		}
+		span.Set("commit.violations", len(violations))
+		span.End(err)
+
+		if len(violations) == 0 {
+			return opts.Conventions.NormaliseParts(parts), nil
+		}
+		s.log().Debug("commit message violates conventions", "attempt", attempt+1, "violations", len(violations), "first", violations[0].String())
+		if attempt >= opts.LintRetries {
+			if term := commit.Denied(parts.Description, opts.Denylist); term != "" && opts.DenyFail {
+				return commit.Parts{}, fmt.Errorf("generated description %q contains the forbidden phrase %q", parts.Description, term)
+			}
+			if opts.Strict {
+				return commit.Parts{}, fmt.Errorf("strict: the answer still broke %d rules after %d attempts (%s); write the message yourself, raise --lint-retries or use a larger model", len(violations), attempt+1, violations[0])
+			}
+			s.log().Warn("commit message still violates conventions after retries", "attempts", attempt+1, "violations", len(violations))
+			result.Violations = violations
+			if err != nil {
+				return opts.Conventions.FallbackParts(raw), nil
+			}
+			return opts.Conventions.NormaliseParts(parts), nil
+		}
+
+		messages := make([]string, 0, len(violations))
+		for _, v := range violations {
+			messages = append(messages, v.String())
+		}
+		promptText = prompt.CommitRetry(input, raw, messages)
+	}
+}
+
+// repairJSON asks the model to fix the syntax of raw, which failed to
+// decode with decodeErr, and returns the fixed answer with its parts.
+func (s *Service) repairJSON(ctx context.Context, opts Options, raw string, decodeErr error) (string, commit.Parts, error) {
+	req := newRequest(opts.Model, prompt.RepairJSON(raw, decodeErr.Error()), repairDefaults, opts.LLMOptions)
+	req.Format = responseFormat(opts)
+	callCtx, span := trace.Start(ctx, "llm.repair")
+	span.Set("llm.model", opts.Model)
+	fixed, err := s.LLM.Generate(callCtx, opts.Endpoint, req)
+	span.End(err)
+	if err != nil {
+		s.log().Debug("asking the model to repair its JSON failed", "err", err)
+		return "", commit.Parts{}, err
+	}
+	parts, err := commit.DecodeParts(fixed)
+	if err != nil {
+		s.log().Debug("the repaired answer is still not JSON", "err", err)
+		return "", commit.Parts{}, err
+	}
+	s.log().Debug("the model repaired its JSON", "model", opts.Model)
+	return fixed, parts, nil
+}
+
+// mergeMessage keeps git's merge headline and asks the model for a body
+// summarising what the incoming branch brings in.
+func (s *Service) mergeMessage(ctx context.Context, opts Options, merge git.MergeState, diff string, result *Result) (commit.Message, error) {
+	headline := "Merge changes"
+	if lines := util.TrimLines(merge.Message); len(lines) > 0 {
+		headline = lines[0]
+	}
+
+	callCtx, span := trace.Start(ctx, "llm.merge")
+	body, err := s.LLM.Generate(callCtx, opts.Endpoint, newRequest(opts.Model, prompt.Merge(headline, merge.Incoming, s.promptDiff(ctx, opts, diff)), mergeDefaults, opts.LLMOptions))
+	span.End(err)
+	if err != nil {
+		if !s.offline(ctx, opts, err) {
+			return commit.Message{}, err
+		}
+		result.Offline = true
+		return commit.Message{Headline: headline, Body: offlineNote}, nil
+	}
+
+	return commit.Message{Headline: headline, Body: strings.TrimSpace(body)}, nil
+}
+
+// summarize splits an oversized diff into per-file chunks that fit the byte
+// budget and asks the model for one summary line per file.
+func (s *Service) summarize(ctx context.Context, opts Options, fullDiff string) ([]string, error) {
+	var summaries []string
+	for _, chunk := range difftext.Chunk(difftext.SplitFiles(fullDiff), opts.MaxBytes) {
+		callCtx, span := trace.Start(ctx, "llm.summarize")
+		span.Set("llm.files", len(chunk))
+		out, err := s.LLM.Generate(callCtx, opts.Endpoint, newRequest(opts.Model, prompt.Summarize(s.promptDiff(ctx, opts, difftext.Join(chunk))), summarizeDefaults, opts.LLMOptions))
+		span.End(err)
+		if err != nil {
+			return nil, fmt.Errorf("summarize diff chunk: %w", err)
+		}
+		for _, line := range util.TrimLines(out) {
+			if !strings.HasPrefix(line, "- ") {
+				line = "- " + line
+			}
+			summaries = append(summaries, line)
+		}
+	}
+	return summaries, nil
+}
+
+// maxEnrichedFiles bounds how many Go files are parsed for the prompt.
+const maxEnrichedFiles = 30
+
+// touchedSymbols parses the staged version of each changed Go file and
+// lists the declarations overlapping its hunks. Files that fail to load or
+// parse are skipped.
+func (s *Service) touchedSymbols(ctx context.Context, opts Options, d string) []string {
+	if !opts.GoSymbols {
+		return nil
+	}
+	var out []string
+	for _, f := range difftext.SplitFiles(d) {
+		if len(out) >= maxEnrichedFiles {
+			break
+		}
+		if !strings.HasSuffix(f.Path, ".go") {
+			continue
+		}
+		src, err := s.Repo.StagedContent(ctx, f.Path)
+		if err != nil {
+			continue
+		}
+		symbols, err := enrich.GoSymbols(src, difftext.ChangedRanges(f.Text))
+		if err != nil || len(symbols) == 0 {
+			continue
+		}
+		out = append(out, f.Path+": "+strings.Join(symbols, ", "))
+	}
+	return out
+}
+
+// topLevelScopes lists the visible top-level directories of the
+// repository, the scopes most teams use; nil when the root is unknown.
+func (s *Service) topLevelScopes(ctx context.Context) []string {
+	root, err := s.Repo.Root(ctx)
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	var scopes []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			scopes = append(scopes, e.Name())
+		}
+	}
+	return scopes
+}
+
+// recentCommits is best effort: history only sharpens the prompt, so lookup
+// failures are not worth aborting the generation for.
+func (s *Service) recentCommits(ctx context.Context, files []string, depth int) []string {
+	if depth <= 0 || len(files) == 0 {
+		return nil
+	}
+	recent, err := s.Repo.RecentCommits(ctx, files, depth)
+	if err != nil {
+		return nil
+	}
+	return recent
+}
+
+// branchSubjects returns the subjects of the latest n commits on the current
+// branch. Like recentCommits it is best effort: an unborn branch or a patch
+// file without history simply disables the repeat check.
+func (s *Service) branchSubjects(ctx context.Context, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	entries, err := s.Repo.Log(ctx, "", n)
+	if err != nil {
+		return nil
+	}
+	subjects := make([]string, 0, len(entries))
+	for _, e := range entries {
+		subjects = append(subjects, e.Subject)
+	}
+	return subjects
+}
+
+func responseFormat(opts Options) interface{} {
+	switch opts.ResponseFormat {
+	case "schema":
+		return opts.Conventions.JSONSchema()
+	case "json":
+		return "json"
+	}
+	return nil
+}
+
+func tone(opts Options) prompt.Tone {
+	t, _ := prompt.LookupTone(opts.Tone)
+	return t
+}
+
+// limits returns the field lengths the conventions hold the answer to.
+func limits(opts Options) prompt.Limits {
+	c := opts.Conventions
+	return prompt.Limits{Description: c.DescriptionLimit(), Summary: c.SummaryLimit(), Body: c.BodyLimit(), NoBody: c.NoBody}
+}
+
+// toneOptions returns the tone's token budget for the commit call.
+func toneOptions(opts Options) map[string]interface{} {
+	if t := tone(opts); t.NumPredict > 0 {
+		return map[string]interface{}{"num_predict": t.NumPredict}
+	}
+	return nil
+}
+
+// newRequest builds a streaming request from a role-split prompt with the
+// call's own sampling defaults and the user's options.
+func newRequest(model string, p prompt.Prompt, defaults, options map[string]interface{}) ollama.Request {
+	return ollama.Request{
+		Model:    model,
+		System:   p.System,
+		Prompt:   p.User,
+		Stream:   true,
+		Options:  options,
+		Defaults: defaults,
+	}
+}
+
+// llmOptions layers overrides on top of the per-call defaults.
+func llmOptions(defaults, overrides map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(defaults)+len(overrides))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+// codeOwners records the owners of the staged paths on result and returns
+// "path: @owner" hints for the review prompt. Like history it is best effort.
+func (s *Service) codeOwners(ctx context.Context, files []string, result *Result) []string {
+	if len(files) == 0 {
+		return nil
+	}
+	root, err := s.Repo.Root(ctx)
+	if err != nil {
+		return nil
+	}
+	rules, err := codeowners.Load(root)
+	if err != nil || len(rules) == 0 {
+		return nil
+	}
+
+	byPath, all := rules.ByPath(files)
+	result.Owners = all
+
+	hints := make([]string, 0, len(byPath))
+	for _, f := range files {
+		if owners, ok := byPath[f]; ok {
+			hints = append(hints, f+": "+strings.Join(owners, " "))
+		}
+	}
+	return hints
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/ci"
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+)
+
+// Identifier is what signing off needs beyond the Repository interface;
+// the git, jj and sl repositories implement it.
+type Identifier interface {
+	Identity(ctx context.Context) (string, error)
+}
+
+// withSignoff resolves the identity to sign off with when opts require a
+// sign-off and do not carry one yet, so sub-services describing a patch
+// (which has no identity) sign with the repository's.
+func (s *Service) withSignoff(ctx context.Context, opts Options) (Options, error) {
+	if !opts.RequireSignoff || opts.Signoff != "" {
+		return opts, nil
+	}
+	id, ok := s.Repo.(Identifier)
+	if !ok {
+		return opts, errors.New("--require-signoff needs the committer identity, which this repository cannot provide")
+	}
+	ident, err := id.Identity(ctx)
+	if err != nil {
+		return opts, fmt.Errorf("--require-signoff: %w", err)
+	}
+	opts.Signoff = ident
+	return opts, nil
+}
+
+// TrailerData is what trailer templates see: {{.Env.GITHUB_RUN_ID}},
+// {{.CI.URL}} or {{.Branch}}. Env holds only the variables CI systems
+// describe a build with (trailerEnv), since the message gets pushed.
+type TrailerData struct {
+	Env    map[string]string
+	CI     ci.Info
+	Branch string
+}
+
+// trailerEnvPrefixes are the prefixes of the variables CI systems name a
+// build, job or revision with; trailerEnvNames are the ones without a
+// common prefix.
+var (
+	trailerEnvPrefixes = []string{
+		"CI_", "BUILD_", "JOB_", "GITHUB_", "GITEA_", "GITLAB_", "JENKINS_",
+		"CIRCLE_", "BUILDKITE_", "SYSTEM_", "DRONE_", "BITBUCKET_", "TRAVIS_",
+	}
+	trailerEnvNames = map[string]bool{"CI": true, "GIT_COMMIT": true, "GIT_BRANCH": true, "BRANCH_NAME": true, "TAG_NAME": true}
+	// credentialWords mark variables that hold secrets even under a CI
+	// prefix (GITHUB_TOKEN, CI_JOB_TOKEN, CI_REGISTRY_PASSWORD).
+	credentialWords = []string{"TOKEN", "SECRET", "PASS", "KEY", "CREDENTIAL", "AUTH", "PRIVATE", "SESSION", "COOKIE"}
+)
+
+// trailerEnv reports whether the variable name may be shown to trailer
+// templates: CI build metadata, and nothing that names a credential.
+func trailerEnv(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, word := range credentialWords {
+		if strings.Contains(upper, word) {
+			return false
+		}
+	}
+	if trailerEnvNames[upper] {
+		return true
+	}
+	for _, prefix := range trailerEnvPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// trailers renders opts.Trailers for a commit on branch.
+func trailers(opts Options, branch string) ([]string, error) {
+	if len(opts.Trailers) == 0 {
+		return nil, nil
+	}
+	env := map[string]string{}
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok && trailerEnv(k) {
+			env[k] = v
+		}
+	}
+	return commit.RenderTrailers(opts.Trailers, TrailerData{Env: env, CI: ci.Detect(), Branch: branch})
+}
+
+// finish adds the configured trailers and signs msg off when required,
+// runs the post-processors and checks that the sign-off survived them; a
+// missing one is a violation, or an error in strict mode.
+func (s *Service) finish(ctx context.Context, opts Options, msg commit.Message, result *Result) error {
+	extra, err := trailers(opts, result.Branch)
+	if err != nil {
+		return err
+	}
+	msg = commit.WithTrailers(msg, extra)
+	if opts.Signoff != "" {
+		msg = commit.WithTrailers(msg, []string{commit.Signoff(opts.Signoff)})
+	}
+	if result.Message, err = postProcess(ctx, opts, msg); err != nil {
+		return err
+	}
+	if opts.Signoff == "" {
+		return nil
+	}
+	for _, v := range commit.LintSignoff(result.Message.Body, opts.Signoff) {
+		if opts.Strict {
+			return fmt.Errorf("strict: %s (removed by a post-processor)", v)
+		}
+		s.log().Warn("sign-off removed by a post-processor", "trailer", commit.Signoff(opts.Signoff))
+		result.Violations = append(result.Violations, v)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	"github.com/riskibarqy/go-commitgen/internal/git"
+)
+
+// Stacker is what rewriting a stack needs beyond the Repository interface;
+// git.CLIRepository implements it.
+type Stacker interface {
+	MergeBase(ctx context.Context, a, b string) (string, error)
+	CommitDiff(ctx context.Context, hash string, opts git.DiffOptions) (string, error)
+	Amend(ctx context.Context, hash, message string) error
+	Autosquash(ctx context.Context, base string) error
+}
+
+// StackEntry is one commit of the stack with its old and new message.
+type StackEntry struct {
+	Hash    string
+	Old     string
+	Message commit.Message
+}
+
+// Stack regenerates the messages of the commits between trunk and HEAD,
+// oldest first, numbering them "(k/n)" and keeping their trailers (stack
+// tool metadata, Change-Ids, sign-offs). With apply set the new messages
+// are written through amend! commits and an autosquash rebase.
+func (s *Service) Stack(ctx context.Context, opts Options, stacker Stacker, trunk string, apply bool) ([]StackEntry, error) {
+	base, err := stacker.MergeBase(ctx, trunk, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	logged, err := s.Repo.Log(ctx, base+"..HEAD", 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(logged) == 0 {
+		return nil, fmt.Errorf("no commits between %s and HEAD", trunk)
+	}
+	branch, err := s.Repo.CurrentBranch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	layer, err := s.withSignoff(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	layer.Review = false
+	layer.IncludeUntracked = false
+	layer.HookSource = ""
+
+	entries := make([]StackEntry, 0, len(logged))
+	for i := len(logged) - 1; i >= 0; i-- {
+		e := logged[i]
+		d, err := stacker.CommitDiff(ctx, e.Hash, opts.Diff)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(d) == "" {
+			// merges and empty commits keep their message
+			continue
+		}
+
+		// describe each layer from its own diff, like a --diff-file run
+		sub := Service{Repo: &git.PatchRepository{Diff: d, Branch: branch}, LLM: s.LLM, Linters: s.Linters, Log: s.Log, Embedder: s.Embedder, Models: s.Models}
+		result, err := sub.Execute(ctx, layer)
+		if err != nil {
+			return nil, fmt.Errorf("describe %s: %w", shortHash(e.Hash), err)
+		}
+		msg := commit.WithTrailers(result.Message, commit.Trailers(e.Body))
+		entries = append(entries, StackEntry{Hash: e.Hash, Old: e.Subject, Message: msg})
+	}
+
+	for i := range entries {
+		entries[i].Message.Headline = fmt.Sprintf("%s (%d/%d)", entries[i].Message.Headline, i+1, len(entries))
+	}
+	if !apply {
+		return entries, nil
+	}
+
+	for _, e := range entries {
+		full := e.Message.Headline
+		if e.Message.Body != "" {
+			full += "\n\n" + e.Message.Body
+		}
+		if err := stacker.Amend(ctx, e.Hash, full); err != nil {
+			return nil, err
+		}
+	}
+	if err := stacker.Autosquash(ctx, base); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
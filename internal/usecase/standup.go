@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/git"
+	"github.com/riskibarqy/go-commitgen/internal/prompt"
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+var standupDefaults = map[string]interface{}{"temperature": 0.3, "top_p": 0.9, "num_predict": 300}
+
+// maxStandupCommits bounds the commits read per repository.
+const maxStandupCommits = 100
+
+// authorLogger lists one author's commits in any repository; the git
+// repository implements it.
+type authorLogger interface {
+	AuthorLog(ctx context.Context, dir, since, author string, limit int) ([]git.LogEntry, error)
+}
+
+// Standup summarises the commits author made since since across repos
+// (directories; none is the current repository) for a standup. author ""
+// is each repository's configured user.email. A repository that cannot be
+// read fails the summary, so a typo in the list is not silently left out.
+func (s *Service) Standup(ctx context.Context, opts Options, repos []string, since, author string) (string, error) {
+	logger, ok := s.Repo.(authorLogger)
+	if !ok {
+		return "", errors.New("standup needs a git repository")
+	}
+	if len(repos) == 0 {
+		repos = []string{""}
+	}
+
+	var commits []string
+	size := 0
+read:
+	for _, dir := range repos {
+		entries, err := logger.AuthorLog(ctx, dir, since, author, maxStandupCommits)
+		if err != nil {
+			return "", err
+		}
+		name := s.repoName(ctx, dir)
+		for _, e := range entries {
+			line := fmt.Sprintf("- [%s] %s", name, e.Subject)
+			if e.Body != "" {
+				line += ": " + util.TruncateShorten(util.CondenseSpaces(e.Body), 200)
+			}
+			if size += len(line); opts.MaxBytes > 0 && size > opts.MaxBytes {
+				break read
+			}
+			commits = append(commits, line)
+		}
+	}
+	if len(commits) == 0 {
+		who := author
+		if who == "" {
+			who = "you"
+		}
+		return "", fmt.Errorf("no commits by %s since %s", who, since)
+	}
+	s.log().Debug("standup", "repos", len(repos), "commits", len(commits))
+
+	out, err := s.LLM.Generate(ctx, opts.Endpoint, newRequest(opts.Model, prompt.Standup(commits, since), standupDefaults, opts.LLMOptions))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// repoName is the directory name of the repository in dir.
+func (s *Service) repoName(ctx context.Context, dir string) string {
+	if dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			return filepath.Base(abs)
+		}
+		return filepath.Base(dir)
+	}
+	if root, err := s.Repo.Root(ctx); err == nil {
+		return filepath.Base(root)
+	}
+	return "."
+}
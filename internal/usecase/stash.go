@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/riskibarqy/go-commitgen/internal/commit"
+	"github.com/riskibarqy/go-commitgen/internal/git"
+)
+
+// Stasher is what committing a stash entry needs beyond the Repository
+// interface; git.CLIRepository implements it.
+type Stasher interface {
+	StashDiff(ctx context.Context, n int, opts git.DiffOptions) (string, error)
+	StashSubject(ctx context.Context, n int) (string, error)
+	StashPop(ctx context.Context, n int) error
+}
+
+// DescribeStash generates a message for stash entry n from its diff. The
+// message given to `git stash push -m` is used as the author's intent
+// unless opts.Context is set.
+func (s *Service) DescribeStash(ctx context.Context, opts Options, stasher Stasher, n int) (Result, error) {
+	if s == nil || s.Repo == nil || s.LLM == nil {
+		return Result{}, errors.New("service not properly initialized")
+	}
+	d, err := stasher.StashDiff(ctx, n, opts.Diff)
+	if err != nil {
+		return Result{}, err
+	}
+	if strings.TrimSpace(d) == "" {
+		return Result{}, fmt.Errorf("stash@{%d} holds no changes", n)
+	}
+	branch, err := s.Repo.CurrentBranch(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+
+	layer, err := s.withSignoff(ctx, opts)
+	if err != nil {
+		return Result{}, err
+	}
+	layer.IncludeUntracked = false
+	layer.HookSource = ""
+	if subject, err := stasher.StashSubject(ctx, n); err == nil && strings.TrimSpace(layer.Context) == "" {
+		layer.Context = stashIntent(subject)
+	}
+	// describe the stash like a --diff-file run
+	sub := Service{Repo: &git.PatchRepository{Diff: d, Branch: branch}, LLM: s.LLM, Linters: s.Linters, Log: s.Log, Embedder: s.Embedder, Models: s.Models}
+	return sub.Execute(ctx, layer)
+}
+
+// stashIntent returns the message of a `git stash push -m` entry ("On
+// main: half-done retry logic"); the automatic "WIP on main: ..." carries
+// no intent.
+func stashIntent(subject string) string {
+	if rest, ok := strings.CutPrefix(subject, "On "); ok {
+		if _, message, ok := strings.Cut(rest, ": "); ok {
+			return strings.TrimSpace(message)
+		}
+	}
+	return ""
+}
+
+// CommitStash pops stash entry n, staging what DescribeStash described,
+// and commits it with msg. It refuses while other changes are staged,
+// which would end up in the same commit, and unless ctx is still live.
+// Once the pop has started ctx is no longer consulted, so an interrupt
+// cannot leave the stash applied but uncommitted.
+func (s *Service) CommitStash(ctx context.Context, stasher Stasher, n int, msg commit.Message) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("stash-pop aborted: %w", err)
+	}
+	staged, err := s.Repo.StagedFiles(ctx)
+	if err != nil {
+		return err
+	}
+	if len(staged) > 0 {
+		return fmt.Errorf("%d files are already staged; commit or unstage them first so the commit holds only stash@{%d}", len(staged), n)
+	}
+	ctx = context.WithoutCancel(ctx)
+	if err := stasher.StashPop(ctx, n); err != nil {
+		return err
+	}
+	return s.Commit(ctx, msg)
+}
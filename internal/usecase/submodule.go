@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	difftext "github.com/riskibarqy/go-commitgen/internal/diff"
+)
+
+// maxSubmoduleCommits bounds the upstream subjects listed per bump.
+const maxSubmoduleCommits = 10
+
+// submoduleLogger reads the history of checked out submodules;
+// git.CLIRepository implements it.
+type submoduleLogger interface {
+	SubmoduleLog(ctx context.Context, path, old, new string, limit int) ([]string, error)
+}
+
+// submoduleNotes describes the submodule pointer bumps in diff with the
+// upstream commits they pull in, so the model can say what the bump brings
+// instead of quoting two hashes. Without the submodule's history only the
+// hashes are given.
+func (s *Service) submoduleNotes(ctx context.Context, diff string) []string {
+	bumps := difftext.SubmoduleBumps(diff)
+	if len(bumps) == 0 {
+		return nil
+	}
+	logger, _ := s.Repo.(submoduleLogger)
+	notes := make([]string, 0, len(bumps))
+	for _, b := range bumps {
+		switch {
+		case b.Old == "":
+			notes = append(notes, fmt.Sprintf("%s: submodule added at %.7s", b.Path, b.New))
+			continue
+		case b.New == "":
+			notes = append(notes, fmt.Sprintf("%s: submodule removed (was at %.7s)", b.Path, b.Old))
+			continue
+		}
+		note := fmt.Sprintf("%s: submodule moved from %.7s to %.7s", b.Path, b.Old, b.New)
+		if logger != nil {
+			subjects, err := logger.SubmoduleLog(ctx, b.Path, b.Old, b.New, maxSubmoduleCommits+1)
+			switch {
+			case err != nil:
+				s.log().Debug("submodule history unavailable", "path", b.Path, "err", err)
+			case len(subjects) == 0:
+				note += " (a rewind or unrelated history; no new upstream commits)"
+			default:
+				more := ""
+				if len(subjects) > maxSubmoduleCommits {
+					subjects, more = subjects[:maxSubmoduleCommits], "; ..."
+				}
+				note += ", upstream commits: " + strings.Join(subjects, "; ") + more
+			}
+		}
+		notes = append(notes, note)
+	}
+	return notes
+}
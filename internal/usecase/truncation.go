@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"strings"
+
+	difftext "github.com/riskibarqy/go-commitgen/internal/diff"
+	"github.com/riskibarqy/go-commitgen/internal/util"
+)
+
+// Truncation reports how the diff was cut to Options.MaxBytes before the
+// model saw it, so a message missing part of the change can be explained
+// and the limit raised deliberately.
+type Truncation struct {
+	// Bytes is the size of the whole diff and Sent of the part sent.
+	Bytes int `json:"bytes"`
+	Sent  int `json:"sent"`
+	// Dropped lists the files left out entirely and Partial the one cut
+	// short, if any.
+	Dropped []string `json:"dropped,omitempty"`
+	Partial string   `json:"partial,omitempty"`
+}
+
+// truncation compares the diff sent with the whole one; nil when nothing
+// was cut.
+func truncation(full, sent string) *Truncation {
+	kept, cut := strings.CutSuffix(sent, util.TrimMarker)
+	if !cut || len(kept) >= len(full) {
+		return nil
+	}
+	t := &Truncation{Bytes: len(full), Sent: len(kept)}
+	t.Partial, t.Dropped = difftext.Cut(full, len(kept))
+	return t
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/riskibarqy/go-commitgen/internal/ollama"
+)
+
+// ModelLister reports the models an endpoint has pulled and loaded;
+// ollama.Client implements it.
+type ModelLister interface {
+	Installed(ctx context.Context, endpoint string) ([]ollama.InstalledModel, error)
+	Loaded(ctx context.Context, endpoint string) ([]ollama.LoadedModel, error)
+}
+
+// vramOverhead scales the size of a model's weights to the memory it takes
+// once loaded: the KV cache and compute buffers come on top. A rule of
+// thumb for the default context; a loaded model reports its real size.
+const vramOverhead = 1.2
+
+// pickModel returns the largest of opts.AutoModels that fits in the GPU
+// memory the endpoint has free, so the model does not spill onto the CPU.
+// Free memory is opts.GPUMemory minus what the loaded models other than
+// the candidates hold; Ollama unloads an idle candidate to make room for
+// another. When none fits the smallest is used, and when the endpoint
+// cannot be asked opts.Model is kept.
+func (s *Service) pickModel(ctx context.Context, opts Options) string {
+	if len(opts.AutoModels) == 0 || opts.GPUMemory <= 0 || s.Models == nil {
+		return opts.Model
+	}
+	installed, err := s.Models.Installed(ctx, opts.Endpoint)
+	if err != nil {
+		s.log().Warn("cannot list installed models; keeping --model", "err", err)
+		return opts.Model
+	}
+	loaded, err := s.Models.Loaded(ctx, opts.Endpoint)
+	if err != nil {
+		s.log().Warn("cannot list loaded models; keeping --model", "err", err)
+		return opts.Model
+	}
+
+	model, reason := fitModel(opts.AutoModels, installed, loaded, opts.GPUMemory)
+	if model == "" {
+		s.log().Warn("no auto model is installed; keeping --model", "models", opts.AutoModels)
+		return opts.Model
+	}
+	s.log().Info("picked model for free GPU memory", "model", model, "reason", reason)
+	return model
+}
+
+type candidate struct {
+	name string
+	// need is the memory the model takes on the GPU; loaded says it is
+	// measured rather than estimated.
+	need   int64
+	loaded bool
+}
+
+// fitModel picks from candidates as pickModel describes, returning "" when
+// none is installed.
+func fitModel(candidates []string, installed []ollama.InstalledModel, loaded []ollama.LoadedModel, gpuMemory int64) (string, string) {
+	sizes := map[string]int64{}
+	for _, m := range installed {
+		sizes[ollama.ModelName(m.Name)] = m.Size
+	}
+	inMemory := map[string]ollama.LoadedModel{}
+	for _, m := range loaded {
+		inMemory[ollama.ModelName(m.Name)] = m
+	}
+
+	var (
+		options []candidate
+		wanted  = map[string]bool{}
+	)
+	for _, name := range candidates {
+		key := ollama.ModelName(name)
+		size, ok := sizes[key]
+		if !ok || wanted[key] {
+			continue
+		}
+		wanted[key] = true
+		c := candidate{name: name, need: int64(float64(size) * vramOverhead)}
+		if m, ok := inMemory[key]; ok {
+			c.need, c.loaded = m.Size, true
+		}
+		options = append(options, c)
+	}
+	if len(options) == 0 {
+		return "", ""
+	}
+
+	free := gpuMemory
+	for name, m := range inMemory {
+		if !wanted[name] {
+			free -= m.SizeVRAM
+		}
+	}
+	sort.SliceStable(options, func(i, j int) bool { return options[i].need > options[j].need })
+	for _, c := range options {
+		if c.need <= free {
+			how := "estimated"
+			if c.loaded {
+				how = "loaded"
+			}
+			return c.name, fmt.Sprintf("needs %s (%s), %s free", mib(c.need), how, mib(free))
+		}
+	}
+	smallest := options[len(options)-1]
+	return smallest.name, fmt.Sprintf("none fits in %s free; the smallest needs %s and runs partly on the CPU", mib(free), mib(smallest.need))
+}
+
+func mib(n int64) string {
+	return fmt.Sprintf("%d MiB", n>>20)
+}
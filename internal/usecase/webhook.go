@@ -0,0 +1,32 @@
+package usecase
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/riskibarqy/go-commitgen/internal/webhook"
+)
+
+// Announce posts the commit just made from result to hook. Call it after
+// a successful Commit: the hash and author are read from the repository's
+// latest commit, and a failed lookup leaves them empty rather than
+// holding the announcement back.
+func (s *Service) Announce(ctx context.Context, hook webhook.Hook, result Result) error {
+	c := webhook.Commit{
+		Branch:      result.Branch,
+		Headline:    result.Message.Headline,
+		Body:        result.Message.Body,
+		Type:        result.Parts.CommitType,
+		Scope:       result.Parts.Scope,
+		Description: result.Parts.Description,
+	}
+	if root, err := s.Repo.Root(ctx); err == nil {
+		c.Repo = filepath.Base(root)
+	}
+	if latest, err := s.Repo.Log(ctx, "HEAD", 1); err == nil && len(latest) == 1 {
+		c.Hash, c.Short, c.Author = latest[0].Hash, shortHash(latest[0].Hash), latest[0].Author
+	} else {
+		s.log().Debug("reading the new commit for the webhook failed", "err", err)
+	}
+	return hook.Post(ctx, c)
+}
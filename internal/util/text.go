@@ -41,6 +41,9 @@ func TrimLines(s string) []string {
 	return cleaned
 }
 
+// TrimMarker ends a string TrimTo cut short.
+const TrimMarker = "\n…[diff truncated]"
+
 // TrimTo limits a string to max bytes, attempting to cut on line boundaries.
 func TrimTo(s string, max int) string {
 	if max <= 0 || len(s) <= max {
@@ -50,5 +53,49 @@ func TrimTo(s string, max int) string {
 	if idx := strings.LastIndex(head, "\n"); idx > 0 {
 		head = head[:idx]
 	}
-	return head + "\n…[diff truncated]"
+	return head + TrimMarker
+}
+
+// EditDistance returns the Levenshtein distance between a and b in runes.
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// Wrap breaks s into lines of at most width runes on word boundaries;
+// words longer than width get a line of their own.
+func Wrap(s string, width int) string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(s) {
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width:
+			lines = append(lines, line)
+			line = word
+		default:
+			line += " " + word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
 }
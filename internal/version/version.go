@@ -0,0 +1,83 @@
+// Package version holds the build metadata, set at link time with
+//
+//	-ldflags "-X github.com/riskibarqy/go-commitgen/internal/version.Version=v1.2.3
+//	          -X github.com/riskibarqy/go-commitgen/internal/version.Commit=$(git rev-parse HEAD)
+//	          -X github.com/riskibarqy/go-commitgen/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Builds without them (go install, go build) fall back to what the Go
+// toolchain records about the module and its VCS checkout.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Version is the release tag of the build, "dev" for local builds.
+var Version = "dev"
+
+// Commit is the full hash of the commit the binary was built from.
+var Commit = ""
+
+// Date is when the binary was built, in RFC 3339.
+var Date = ""
+
+// Info is the build metadata, as `go-commitgen version --json` prints it.
+type Info struct {
+	Version  string `json:"version"`
+	Commit   string `json:"commit,omitempty"`
+	Date     string `json:"date,omitempty"`
+	Modified bool   `json:"modified,omitempty"`
+	Go       string `json:"go"`
+	Platform string `json:"platform"`
+}
+
+// Get returns the build metadata, filling what ldflags did not set from
+// the build info: the module version of `go install ...@v1.2.3` and the
+// revision, time and dirty state of a build inside a checkout.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date, Go: runtime.Version(), Platform: runtime.GOOS + "/" + runtime.GOARCH}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+		info.Version = build.Main.Version
+	}
+	for _, s := range build.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = s.Value
+			}
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = s.Value
+			}
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
+
+// String renders the metadata on one line, e.g.
+// "v1.2.3 (commit 0a1b2c3d4e5f, built 2024-05-01T10:00:00Z, go1.22.2 linux/amd64)".
+func (i Info) String() string {
+	out := i.Version + " ("
+	if i.Commit != "" {
+		commit := i.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		if i.Modified {
+			commit += "-dirty"
+		}
+		out += "commit " + commit + ", "
+	}
+	if i.Date != "" {
+		out += "built " + i.Date + ", "
+	}
+	return out + fmt.Sprintf("%s %s)", i.Go, i.Platform)
+}
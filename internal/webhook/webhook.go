@@ -0,0 +1,88 @@
+// Package webhook announces commits in a chat channel through an incoming
+// webhook, as Slack, Microsoft Teams and Mattermost provide them.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// DefaultTemplate is the text posted when no template is configured.
+const DefaultTemplate = "{{.Author}} committed {{.Short}} to {{.Repo}}/{{.Branch}}:\n{{.Headline}}{{if .Body}}\n\n{{.Body}}{{end}}"
+
+// Commit is what a template sees of the commit just made.
+type Commit struct {
+	Repo   string
+	Branch string
+	Hash   string
+	// Short is Hash cut to 12 characters.
+	Short    string
+	Author   string
+	Headline string
+	Body     string
+	// Type, Scope and Description are the model's parts; empty for merge
+	// messages.
+	Type        string
+	Scope       string
+	Description string
+}
+
+// Parse reads a template over Commit, as --webhook-template is given.
+func Parse(text string) (*template.Template, error) {
+	tmpl, err := template.New("webhook").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	// fail on fields Commit does not have now rather than after the commit
+	if err := tmpl.Execute(io.Discard, Commit{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// Hook posts to one incoming webhook; the zero HTTP field uses
+// http.DefaultClient.
+type Hook struct {
+	HTTP     *http.Client
+	URL      string
+	Template *template.Template
+}
+
+// Post renders c with the template and sends it as the "text" of a JSON
+// message, the payload Slack, Teams and Mattermost webhooks share.
+func (h Hook) Post(ctx context.Context, c Commit) error {
+	var text bytes.Buffer
+	if err := h.Template.Execute(&text, c); err != nil {
+		return err
+	}
+	data, err := json.Marshal(map[string]string{"text": strings.TrimSpace(text.String())})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := h.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		// the URL is the webhook's secret, so only its host is reported
+		return fmt.Errorf("webhook %s: %d %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}